@@ -0,0 +1,299 @@
+// Package alerts avisa a equipe por webhook quando um provedor passa a falhar
+// demais, quando uma chave é recusada como inválida e quando o gasto estimado
+// do dia passa do orçamento, e manda um resumo do uso de cada dia.
+package alerts
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/hooks"
+	"lingobot-ai-engine/instance"
+	"lingobot-ai-engine/internal/env"
+	"lingobot-ai-engine/provider"
+	"lingobot-ai-engine/routing"
+)
+
+// Configuração, só com ALERT_WEBHOOK_URL definido:
+//
+//	ALERT_WEBHOOK_URL=https://hooks.slack.com/services/...  Slack ou Discord
+//	ALERT_ERROR_RATE=0.5        taxa de erro (EWMA) do provedor que dispara o alerta
+//	ALERT_MIN_CALLS=20          chamadas do provedor antes de a taxa valer
+//	ALERT_DAILY_BUDGET_USD=5    gasto estimado do dia (UTC) que dispara o alerta; vazio não alerta
+//	ALERT_SUMMARY_HOUR=0        hora UTC do resumo do dia anterior; off desliga
+//	ALERT_INTERVAL=1m           intervalo entre as verificações
+//
+// Os números são desta instância: com várias, cada uma manda os seus, com o
+// ID no texto. O gasto sai de MODEL_PRICES e só conta as respostas entregues.
+var (
+	webhookURL  = os.Getenv("ALERT_WEBHOOK_URL")
+	errorRate   = env.Float("ALERT_ERROR_RATE", 0.5)
+	minCalls    = env.Int("ALERT_MIN_CALLS", 20)
+	dailyBudget = env.Float("ALERT_DAILY_BUDGET_USD", 0)
+	summaryHour = loadSummaryHour()
+	interval    = env.PositiveDuration("ALERT_INTERVAL", time.Minute)
+)
+
+// O Discord recusa mensagem acima de 2000 caracteres
+const webhookLimit = 1900
+
+var client = &fasthttp.Client{}
+
+// loadSummaryHour devolve -1 quando o resumo está desligado
+func loadSummaryHour() int {
+	raw := os.Getenv("ALERT_SUMMARY_HOUR")
+	switch raw {
+	case "":
+		return 0
+	case "off":
+		return -1
+	}
+	h, err := strconv.Atoi(raw)
+	if err != nil || h < 0 || h > 23 {
+		log.Printf("⚠️  ALERT_SUMMARY_HOUR inválido, usando 0")
+		return 0
+	}
+	return h
+}
+
+// Enabled diz se os alertas estão ligados
+func Enabled() bool {
+	return webhookURL != ""
+}
+
+func init() {
+	if Enabled() {
+		hooks.Register(usageHook{})
+	}
+}
+
+// Uso de um dia UTC, acumulado pelo hook
+type dayUsage struct {
+	date             string
+	turns, errors    int
+	cached, unpriced int
+	prompt, output   int
+	cost             float64
+	providers        map[string]*providerUsage
+	budgetAlerted    bool
+}
+
+type providerUsage struct {
+	turns, errors int
+	cost          float64
+}
+
+var (
+	mu       sync.Mutex
+	today    = newDay(time.Now())
+	previous *dayUsage
+)
+
+func newDay(now time.Time) *dayUsage {
+	return &dayUsage{date: now.UTC().Format(time.DateOnly), providers: map[string]*providerUsage{}}
+}
+
+// roll vira o dia quando a data UTC mudou; chamar com mu
+func roll(now time.Time) {
+	if date := now.UTC().Format(time.DateOnly); date != today.date {
+		previous, today = today, newDay(now)
+	}
+}
+
+type usageHook struct{}
+
+func (usageHook) Name() string { return "alerts" }
+
+func (usageHook) OnFinish(f *hooks.Finish) {
+	mu.Lock()
+	roll(f.At)
+	d := today
+	d.turns++
+
+	p := d.providers[f.Provider]
+	if p == nil && f.Provider != "" {
+		p = &providerUsage{}
+		d.providers[f.Provider] = p
+	}
+	if p != nil {
+		p.turns++
+	}
+
+	switch {
+	case f.Err != nil:
+		d.errors++
+		if p != nil {
+			p.errors++
+		}
+	case f.Cached:
+		d.cached++
+	case f.Result != nil:
+		usage := f.Result.Usage
+		d.prompt += usage.PromptTokens
+		d.output += usage.CompletionTokens + f.Result.ReasoningTokens
+
+		model, reasoning := f.Model, false
+		if f.Request != nil {
+			reasoning = f.Request.Reasoning
+			if model == "" {
+				model = f.Request.Model
+			}
+		}
+		cost, ok := routing.Cost(f.Provider, model, reasoning, usage.PromptTokens, usage.CompletionTokens+f.Result.ReasoningTokens)
+		if !ok {
+			d.unpriced++
+		}
+		d.cost += cost
+		if p != nil {
+			p.cost += cost
+		}
+	}
+
+	overBudget := dailyBudget > 0 && d.cost >= dailyBudget && !d.budgetAlerted
+	if overBudget {
+		d.budgetAlerted = true
+	}
+	spent := d.cost
+	mu.Unlock()
+
+	if overBudget {
+		send(fmt.Sprintf("💸 Gasto estimado de hoje chegou a $%.4f, acima do orçamento de $%.2f (ALERT_DAILY_BUDGET_USD)", spent, dailyBudget))
+	}
+}
+
+// Run verifica provedores, chaves e a hora do resumo a cada ALERT_INTERVAL
+// até o processo terminar; sem ALERT_WEBHOOK_URL, volta na hora
+func Run() {
+	if !Enabled() {
+		return
+	}
+	log.Printf("🚨 Alertas ligados: taxa de erro %.0f%%, orçamento diário $%.2f, resumo às %dh UTC", errorRate*100, dailyBudget, summaryHour)
+
+	failing := map[string]bool{}
+	seenRejection := map[string]time.Time{}
+	// o processo que sobe depois da hora do resumo não tem os números de ontem
+	lastSummary := ""
+	if now := time.Now().UTC(); now.Hour() >= summaryHour {
+		lastSummary = now.AddDate(0, 0, -1).Format(time.DateOnly)
+	}
+	for range time.Tick(interval) {
+		checkProviders(failing)
+		checkKeys(seenRejection)
+		lastSummary = checkSummary(time.Now(), lastSummary)
+	}
+}
+
+// checkProviders alerta quando a taxa de erro cruza o limite e avisa de novo
+// quando ela cai abaixo da metade dele
+func checkProviders(failing map[string]bool) {
+	for _, h := range routing.Health() {
+		switch {
+		case !failing[h.Name] && h.Calls >= minCalls && h.ErrorRate >= errorRate:
+			failing[h.Name] = true
+			send(fmt.Sprintf("🔴 %s com %.0f%% de erro nas últimas chamadas (limite %.0f%%)", h.Name, h.ErrorRate*100, errorRate*100))
+		case failing[h.Name] && h.ErrorRate < errorRate/2:
+			delete(failing, h.Name)
+			send(fmt.Sprintf("🟢 %s recuperado: %.0f%% de erro", h.Name, h.ErrorRate*100))
+		}
+	}
+}
+
+// checkKeys alerta cada nova recusa de chave como inválida
+func checkKeys(seen map[string]time.Time) {
+	for _, k := range provider.Keys() {
+		if k.RejectedAt == nil || !k.RejectedAt.After(seen[k.Env]) {
+			continue
+		}
+		seen[k.Env] = *k.RejectedAt
+		send(fmt.Sprintf("🔑 Chave %s (%s) recusada pelo provedor como inválida", k.Env, k.Provider))
+	}
+}
+
+// checkSummary manda o resumo do dia anterior depois da ALERT_SUMMARY_HOUR,
+// uma vez por dia; devolve a data do último resumo mandado
+func checkSummary(now time.Time, last string) string {
+	if summaryHour < 0 || now.UTC().Hour() < summaryHour {
+		return last
+	}
+	yesterday := now.UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+	if last == yesterday {
+		return last
+	}
+
+	mu.Lock()
+	roll(now)
+	d := previous
+	if d == nil || d.date != yesterday {
+		d = &dayUsage{date: yesterday}
+	}
+	text := summary(d)
+	mu.Unlock()
+
+	send(text)
+	return yesterday
+}
+
+func summary(d *dayUsage) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 Resumo de %s (UTC)\n", d.date)
+	fmt.Fprintf(&b, "Turnos: %d (%d com erro, %d do cache)\n", d.turns, d.errors, d.cached)
+	fmt.Fprintf(&b, "Tokens: %d de entrada, %d de saída\n", d.prompt, d.output)
+	fmt.Fprintf(&b, "Gasto estimado: $%.4f", d.cost)
+	if d.unpriced > 0 {
+		fmt.Fprintf(&b, " (%d respostas sem preço)", d.unpriced)
+	}
+
+	names := make([]string, 0, len(d.providers))
+	for name := range d.providers {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return d.providers[names[i]].turns > d.providers[names[j]].turns })
+	for _, name := range names {
+		p := d.providers[name]
+		fmt.Fprintf(&b, "\n• %s: %d turnos, %d erros, $%.4f", name, p.turns, p.errors, p.cost)
+	}
+	return b.String()
+}
+
+// Corpo aceito pelos dois: o Slack lê text e o Discord lê content
+type webhookMessage struct {
+	Text     string `json:"text"`
+	Content  string `json:"content"`
+	Username string `json:"username"`
+}
+
+// send posta a mensagem no webhook; a falha só vai para o log
+func send(text string) {
+	text = "[" + instance.ID + "] " + text
+	if runes := []rune(text); len(runes) > webhookLimit {
+		text = string(runes[:webhookLimit]) + "…"
+	}
+	body, _ := sonic.Marshal(webhookMessage{Text: text, Content: text, Username: "Lingobot"})
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(webhookURL)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.SetContentType("application/json")
+	req.SetBody(body)
+
+	if err := client.DoTimeout(req, resp, 10*time.Second); err != nil {
+		log.Printf("⚠️  Alerta não enviado: %v", err)
+		return
+	}
+	if resp.StatusCode() >= 300 {
+		log.Printf("⚠️  Alerta recusado pelo webhook: HTTP %d", resp.StatusCode())
+	}
+}
@@ -0,0 +1,168 @@
+// Package compression reduz históricos longos antes da chamada ao provedor:
+// descarta turnos de pouca relevância e resume os antigos com um modelo barato.
+package compression
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bytedance/sonic"
+
+	"lingobot-ai-engine/provider"
+	"lingobot-ai-engine/routing"
+)
+
+// Configuração de compressão de uma persona
+type compressionConfig struct {
+	ThresholdTokens int    `json:"threshold_tokens"` // contexto acima disso é comprimido
+	KeepRecent      int    `json:"keep_recent"`      // últimos turnos que nunca são tocados
+	Summarize       bool   `json:"summarize"`        // resume os turnos antigos com modelo barato
+	Provider        string `json:"provider"`
+	Model           string `json:"model"`
+}
+
+var defaultCompression = compressionConfig{
+	ThresholdTokens: 3000,
+	KeepRecent:      6,
+	Summarize:       true,
+	Provider:        "groq",
+	Model:           "llama-3.1-8b-instant",
+}
+
+// PROMPT_COMPRESSION='{"default":{"threshold_tokens":3000},"kids":{"keep_recent":10}}'
+// Sem a variável a compressão fica desligada.
+var compressionPersonas = loadCompressionConfig(os.Getenv("PROMPT_COMPRESSION"))
+
+func loadCompressionConfig(raw string) map[string]compressionConfig {
+	if raw == "" {
+		return nil
+	}
+
+	var entries map[string]json.RawMessage
+	if err := sonic.UnmarshalString(raw, &entries); err != nil {
+		log.Printf("⚠️  PROMPT_COMPRESSION inválido, compressão desligada: %v", err)
+		return nil
+	}
+
+	personas := make(map[string]compressionConfig, len(entries))
+	for name, entry := range entries {
+		cfg := defaultCompression
+		if err := sonic.Unmarshal(entry, &cfg); err != nil {
+			log.Printf("⚠️  Persona %q inválida em PROMPT_COMPRESSION: %v", name, err)
+			continue
+		}
+
+		// o modelo padrão só vale para o provedor padrão
+		if cfg.Provider != defaultCompression.Provider && cfg.Model == defaultCompression.Model {
+			cfg.Model = ""
+		}
+		if cfg.ThresholdTokens <= 0 {
+			cfg.ThresholdTokens = defaultCompression.ThresholdTokens
+		}
+		if cfg.KeepRecent <= 0 {
+			cfg.KeepRecent = defaultCompression.KeepRecent
+		}
+		personas[name] = cfg
+	}
+	return personas
+}
+
+// compressionFor devolve a config da persona, caindo para "default"
+func compressionFor(persona string) (compressionConfig, bool) {
+	if compressionPersonas == nil {
+		return compressionConfig{}, false
+	}
+	if cfg, ok := compressionPersonas[persona]; ok {
+		return cfg, true
+	}
+	cfg, ok := compressionPersonas["default"]
+	return cfg, ok
+}
+
+// Turnos que não acrescentam contexto ao tutor
+var fillerTurns = map[string]bool{
+	"ok": true, "okay": true, "sim": true, "não": true, "nao": true, "yes": true, "no": true,
+	"obrigado": true, "obrigada": true, "valeu": true, "thanks": true, "thank you": true,
+	"entendi": true, "certo": true, "legal": true, "beleza": true, "got it": true, "cool": true,
+}
+
+func lowSalience(m provider.Message) bool {
+	if m.Role == "system" {
+		return false
+	}
+	content := strings.ToLower(strings.Trim(strings.TrimSpace(m.Content), ".!?,👍 "))
+	return fillerTurns[content] || utf8.RuneCountInString(content) < 3
+}
+
+// Apply reduz o histórico quando passa do limite da persona
+func Apply(in *provider.Request, persona string) {
+	cfg, ok := compressionFor(persona)
+	if !ok {
+		return
+	}
+
+	before := provider.HistoryTokens(in.History)
+	if before <= cfg.ThresholdTokens {
+		return
+	}
+
+	split := len(in.History) - cfg.KeepRecent
+	if split <= 0 {
+		return
+	}
+	older, recent := in.History[:split], in.History[split:]
+
+	kept := make([]provider.Message, 0, len(older))
+	for _, m := range older {
+		if !lowSalience(m) {
+			kept = append(kept, m)
+		}
+	}
+	older = kept
+
+	if provider.HistoryTokens(older)+provider.HistoryTokens(recent) > cfg.ThresholdTokens && cfg.Summarize {
+		if summary, err := summarizeTurns(older, cfg, in.Budget); err == nil {
+			older = []provider.Message{{Role: "system", Content: "Resumo da conversa até aqui: " + summary}}
+		} else {
+			log.Printf("⚠️  Falha ao resumir histórico: %v", err)
+		}
+	}
+
+	// último recurso: descarta os turnos mais antigos
+	for len(older) > 0 && provider.HistoryTokens(older)+provider.HistoryTokens(recent) > cfg.ThresholdTokens {
+		older = older[1:]
+	}
+
+	in.History = append(older, recent...)
+	log.Printf("🗜️  Histórico comprimido: %d → %d tokens", before, provider.HistoryTokens(in.History))
+}
+
+// summarizeTurns resume os turnos antigos com o modelo barato da persona;
+// a chamada conta no orçamento do turno
+func summarizeTurns(turns []provider.Message, cfg compressionConfig, budget *provider.Budget) (string, error) {
+	var transcript strings.Builder
+	for _, m := range turns {
+		transcript.WriteString(m.Role)
+		transcript.WriteString(": ")
+		transcript.WriteString(m.Content)
+		transcript.WriteString("\n")
+	}
+
+	prompt := "Resuma a conversa de tutoria abaixo em poucas frases, mantendo o nível do aluno, " +
+		"os erros recorrentes e os tópicos já estudados. Responda só com o resumo.\n\n" + transcript.String()
+
+	p, ok := provider.ByName(cfg.Provider)
+	if !ok {
+		return "", fmt.Errorf("unknown compression provider %q", cfg.Provider)
+	}
+
+	result, err := routing.Call(p, &provider.Request{Text: prompt, Model: cfg.Model, Budget: budget})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(result.Text), nil
+}
@@ -0,0 +1,137 @@
+// Package conversation guarda os turnos das conversas identificadas por
+// conversation_id, para contexto automático e exportação.
+package conversation
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+
+	"lingobot-ai-engine/kv"
+	"lingobot-ai-engine/provider"
+)
+
+const maxTurns = 200 // turnos mantidos por conversa
+
+// Turno gravado da conversa
+type Turn struct {
+	Role     string    `json:"role"`
+	Content  string    `json:"content"`
+	Provider string    `json:"provider,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// Conversa completa
+type Conversation struct {
+	ID      string    `json:"id"`
+	Turns   []Turn    `json:"turns"`
+	Started time.Time `json:"started"`
+	Updated time.Time `json:"updated"`
+}
+
+var (
+	// mu serializa Record nesta instância; entre instâncias vale a última gravação
+	mu    sync.Mutex
+	store = kv.Prefixed(kv.Default, "conversation")
+
+	// CONVERSATION_TTL: conversas paradas por mais tempo são descartadas
+	ttl = 24 * time.Hour
+)
+
+func init() {
+	if raw := os.Getenv("CONVERSATION_TTL"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			log.Printf("⚠️  CONVERSATION_TTL inválido, usando %s", ttl)
+		} else {
+			ttl = d
+		}
+	}
+}
+
+// Get devolve a conversa gravada
+func Get(id string) (*Conversation, bool) {
+	raw, ok, err := store.Get(id)
+	if err != nil {
+		log.Printf("⚠️  Conversa %s indisponível: %v", id, err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+
+	var c Conversation
+	if err := sonic.Unmarshal(raw, &c); err != nil {
+		return nil, false
+	}
+	return &c, true
+}
+
+// History devolve os turnos no formato dos provedores
+func History(id string) []provider.Message {
+	c, ok := Get(id)
+	if !ok {
+		return nil
+	}
+
+	history := make([]provider.Message, 0, len(c.Turns))
+	for _, t := range c.Turns {
+		history = append(history, provider.Message{Role: t.Role, Content: t.Content})
+	}
+	return history
+}
+
+// Record grava a pergunta do aluno e a resposta do tutor
+func Record(id, text string, result *provider.Result) {
+	now := time.Now()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	c, ok := Get(id)
+	if !ok {
+		c = &Conversation{ID: id, Started: now}
+	}
+
+	c.Turns = append(c.Turns,
+		Turn{Role: "user", Content: text, At: now},
+		Turn{Role: "assistant", Content: result.Text, Provider: result.Provider, At: now},
+	)
+	if len(c.Turns) > maxTurns {
+		c.Turns = c.Turns[len(c.Turns)-maxTurns:]
+	}
+	c.Updated = now
+
+	// o ttl conta da última atividade
+	raw, _ := sonic.Marshal(c)
+	if err := store.Set(id, raw, ttl); err != nil {
+		log.Printf("⚠️  Conversa %s não gravada: %v", id, err)
+	}
+}
+
+// Nível CEFR padrão de cada sessão, gravado ao lado das conversas e com o
+// mesmo ttl: o aluno informa o nível uma vez e os pedidos seguintes herdam
+var levels = kv.Prefixed(kv.Default, "level")
+
+// Level devolve o nível padrão da sessão; vazio quando ainda não foi informado
+func Level(session string) string {
+	raw, ok, err := levels.Get(session)
+	if err != nil {
+		log.Printf("⚠️  Nível da sessão %s indisponível: %v", session, err)
+		return ""
+	}
+	if !ok {
+		return ""
+	}
+	return string(raw)
+}
+
+// SetLevel grava o nível padrão da sessão
+func SetLevel(session, level string) {
+	if err := levels.Set(session, []byte(level), ttl); err != nil {
+		log.Printf("⚠️  Nível da sessão %s não gravado: %v", session, err)
+	}
+}
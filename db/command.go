@@ -0,0 +1,89 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+const usage = `usage: lingobot-ai-engine migrate <command>
+
+  up [N]       aplica as N próximas migrações (todas sem N)
+  down N|all   desfaz as últimas N migrações
+  version      mostra a versão atual do esquema
+  force V      grava a versão V sem rodar SQL (sai do estado sujo)`
+
+// Command executa o subcomando "migrate" contra o DATABASE_URL
+func Command(args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	d, err := Open()
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	count := func() (int, error) {
+		if len(args) < 2 {
+			return 0, nil
+		}
+		if args[1] == "all" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid count %q", args[1])
+		}
+		return n, nil
+	}
+
+	switch args[0] {
+	case "up":
+		n, err := count()
+		if err != nil {
+			return err
+		}
+		applied, err := d.Up(n)
+		fmt.Printf("%d migrations applied\n", applied)
+		return err
+
+	case "down":
+		// desfazer tudo precisa ser pedido explicitamente
+		if len(args) < 2 {
+			return errors.New("down needs a count or \"all\"")
+		}
+		n, err := count()
+		if err != nil {
+			return err
+		}
+		reverted, err := d.Down(n)
+		fmt.Printf("%d migrations reverted\n", reverted)
+		return err
+
+	case "version":
+		version, dirty, err := d.Version()
+		if err != nil {
+			return err
+		}
+		if dirty {
+			fmt.Printf("%d (dirty)\n", version)
+		} else {
+			fmt.Println(version)
+		}
+		return nil
+
+	case "force":
+		if len(args) < 2 {
+			return errors.New("force needs a version")
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		return d.Force(version)
+	}
+
+	return errors.New(usage)
+}
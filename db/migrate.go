@@ -0,0 +1,334 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migrações no formato do golang-migrate, por dialeto:
+// migrations/<dialeto>/0001_nome.up.sql e 0001_nome.down.sql
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Migration é uma versão do esquema
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// ErrDirty indica uma migração interrompida no meio; exige "migrate force"
+var ErrDirty = errors.New("database is dirty: a migration failed halfway, fix it and run migrate force <version>")
+
+// ErrAhead indica esquema mais novo que o binário, como depois de um rollback de deploy
+var ErrAhead = errors.New("database schema is newer than this binary")
+
+// Controle de versão compatível com o golang-migrate
+const versionTable = "schema_migrations"
+
+// Migrations lê as migrações embutidas do dialeto, em ordem de versão
+func Migrations(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+
+		base := strings.TrimSuffix(name, ".sql")
+		base, direction, ok := cutLast(base, ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>.up.sql or .down.sql", name)
+		}
+		rawVersion, title, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(rawVersion)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version", name)
+		}
+
+		body, err := fs.ReadFile(migrationFiles, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	i := strings.LastIndex(s, sep)
+	if i == -1 {
+		return s, "", false
+	}
+	return s[:i], s[i+len(sep):], true
+}
+
+func (d *DB) ensureVersionTable() error {
+	_, err := d.Exec(`CREATE TABLE IF NOT EXISTS ` + versionTable + ` (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`)
+	return err
+}
+
+// Version devolve a versão atual do esquema (0 = nenhuma migração)
+func (d *DB) Version() (version int, dirty bool, err error) {
+	if err := d.ensureVersionTable(); err != nil {
+		return 0, false, err
+	}
+	err = d.QueryRow(`SELECT version, dirty FROM `+versionTable+` LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+func (d *DB) setVersion(tx *sql.Tx, version int, dirty bool) error {
+	if _, err := tx.Exec(`DELETE FROM ` + versionTable); err != nil {
+		return err
+	}
+	if version == 0 && !dirty {
+		return nil // sem linha é a versão zero, como no golang-migrate
+	}
+	_, err := tx.Exec(d.Rebind(`INSERT INTO `+versionTable+` (version, dirty) VALUES (?, ?)`), version, dirty)
+	return err
+}
+
+// markVersion grava a versão fora da transação da migração, como o golang-migrate
+func (d *DB) markVersion(version int, dirty bool) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	if err := d.setVersion(tx, version, dirty); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// apply roda uma migração: marca a versão como suja, executa o SQL numa
+// transação e só então grava a versão limpa
+func (d *DB) apply(target int, body string) error {
+	if err := d.markVersion(target, true); err != nil {
+		return err
+	}
+
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(body); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := d.setVersion(tx, target, false); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Up aplica até n migrações pendentes (n <= 0 aplica todas); devolve quantas rodaram
+func (d *DB) Up(n int) (int, error) {
+	unlock, err := d.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	migrations, err := Migrations(d.Dialect)
+	if err != nil {
+		return 0, err
+	}
+	current, dirty, err := d.Version()
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, ErrDirty
+	}
+
+	applied := 0
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if n > 0 && applied == n {
+			break
+		}
+		log.Printf("🗃️  Migração %d_%s", m.Version, m.Name)
+		if err := d.apply(m.Version, m.Up); err != nil {
+			return applied, fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// Down desfaz as últimas n migrações (n <= 0 desfaz todas)
+func (d *DB) Down(n int) (int, error) {
+	unlock, err := d.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	migrations, err := Migrations(d.Dialect)
+	if err != nil {
+		return 0, err
+	}
+	current, dirty, err := d.Version()
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, ErrDirty
+	}
+
+	reverted := 0
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version > current {
+			continue
+		}
+		if n > 0 && reverted == n {
+			break
+		}
+		if m.Down == "" {
+			return reverted, fmt.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+		}
+
+		previous := 0
+		if i > 0 {
+			previous = migrations[i-1].Version
+		}
+		log.Printf("🗃️  Desfazendo migração %d_%s", m.Version, m.Name)
+		if err := d.apply(previous, m.Down); err != nil {
+			return reverted, fmt.Errorf("migration %d_%s down: %w", m.Version, m.Name, err)
+		}
+		reverted++
+	}
+	return reverted, nil
+}
+
+// Force grava a versão sem rodar SQL, para sair do estado sujo depois de corrigir à mão
+func (d *DB) Force(version int) error {
+	if err := d.ensureVersionTable(); err != nil {
+		return err
+	}
+	return d.markVersion(version, false)
+}
+
+// Pending devolve quantas migrações embutidas ainda não foram aplicadas
+func (d *DB) Pending() (int, error) {
+	migrations, err := Migrations(d.Dialect)
+	if err != nil {
+		return 0, err
+	}
+	current, _, err := d.Version()
+	if err != nil {
+		return 0, err
+	}
+
+	pending := 0
+	for _, m := range migrations {
+		if m.Version > current {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+// SafeUp é a migração na inicialização: só aplica migrações novas e recusa
+// banco sujo ou com esquema à frente do binário, em vez de tentar consertar
+func (d *DB) SafeUp() error {
+	migrations, err := Migrations(d.Dialect)
+	if err != nil {
+		return err
+	}
+	current, dirty, err := d.Version()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return ErrDirty
+	}
+
+	latest := 0
+	if len(migrations) > 0 {
+		latest = migrations[len(migrations)-1].Version
+	}
+	if current > latest {
+		return fmt.Errorf("%w (database %d, binary %d)", ErrAhead, current, latest)
+	}
+
+	applied, err := d.Up(0)
+	if err != nil {
+		return err
+	}
+	if applied > 0 {
+		log.Printf("🗃️  Esquema na versão %d (%d migrações aplicadas)", latest, applied)
+	}
+	return nil
+}
+
+// Trava entre instâncias subindo juntas; o SQLite já serializa pelo arquivo
+const migrationLockID = 7_246_917_301
+
+func (d *DB) lock() (func(), error) {
+	if d.Dialect != Postgres {
+		return func() {}, nil
+	}
+
+	// a trava é da sessão: lock e unlock precisam da mesma conexão do pool
+	ctx := context.Background()
+	conn, err := d.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return func() {
+		conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockID)
+		conn.Close()
+	}, nil
+}
@@ -0,0 +1 @@
+DROP TABLE request_log;
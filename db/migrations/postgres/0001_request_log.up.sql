@@ -0,0 +1,21 @@
+CREATE TABLE request_log (
+    id                BIGSERIAL PRIMARY KEY,
+    created_at        TIMESTAMPTZ NOT NULL,
+    route             TEXT NOT NULL,
+    client            TEXT NOT NULL DEFAULT '',
+    conversation_id   TEXT NOT NULL DEFAULT '',
+    provider          TEXT NOT NULL DEFAULT '',
+    model             TEXT NOT NULL DEFAULT '',
+    prompt_hash       TEXT NOT NULL,
+    prompt_tokens     INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    upstream_calls    INTEGER NOT NULL DEFAULT 0,
+    latency_ms        BIGINT NOT NULL,
+    provider_ms       BIGINT NOT NULL,
+    cached            BOOLEAN NOT NULL DEFAULT FALSE,
+    stream            BOOLEAN NOT NULL DEFAULT FALSE,
+    error             TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX request_log_client ON request_log (client, id);
+CREATE INDEX request_log_conversation ON request_log (conversation_id, id);
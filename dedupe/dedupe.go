@@ -0,0 +1,141 @@
+// Package dedupe protege as cotas contra o "regenerar" repetido: o mesmo cliente
+// mandando o mesmo prompt várias vezes em poucos segundos.
+package dedupe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+
+	"lingobot-ai-engine/kv"
+	"lingobot-ai-engine/provider"
+)
+
+// ErrTooManyRegenerations indica que o cliente deve esperar antes de repetir o prompt
+var ErrTooManyRegenerations = errors.New("too many regenerations, please wait a few seconds")
+
+var (
+	// REGENERATE_WINDOW: intervalo em que prompts idênticos contam como repetição
+	window = 30 * time.Second
+
+	// REGENERATE_LIMIT: gerações novas permitidas por janela; depois disso o
+	// cliente recebe as respostas já geradas em rodízio (0 desliga)
+	limit = 3
+)
+
+// Key identifica o prompt de um cliente; a zero não é controlada
+type Key [32]byte
+
+func (k Key) String() string { return hex.EncodeToString(k[:]) }
+
+// Contagem de repetições e respostas guardadas, por prompt
+var store = kv.Prefixed(kv.Default, "regenerate")
+
+func init() {
+	if raw := os.Getenv("REGENERATE_WINDOW"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			log.Printf("⚠️  REGENERATE_WINDOW inválido, usando %s", window)
+		} else {
+			window = d
+		}
+	}
+	if raw := os.Getenv("REGENERATE_LIMIT"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			log.Printf("⚠️  REGENERATE_LIMIT inválido, usando %d", limit)
+		} else {
+			limit = n
+		}
+	}
+}
+
+// key inclui o histórico: a mesma pergunta em outro ponto da conversa é outro prompt
+func key(client string, in *provider.Request) Key {
+	h := sha256.New()
+	h.Write([]byte(client))
+	h.Write([]byte{0})
+	h.Write([]byte(in.System)) // outro nível é outro pedido
+	for _, m := range in.History {
+		h.Write([]byte{0})
+		h.Write([]byte(m.Role))
+		h.Write([]byte{0})
+		h.Write([]byte(m.Content))
+	}
+	h.Write([]byte{0})
+	h.Write([]byte(in.Text))
+	for _, s := range in.Stop {
+		h.Write([]byte{0})
+		h.Write([]byte(s))
+	}
+
+	var k Key
+	copy(k[:], h.Sum(nil))
+	return k
+}
+
+// Check conta a repetição e, passado o limite, devolve uma resposta já gerada
+// para o mesmo prompt. Sem alternativas guardadas, devolve ErrTooManyRegenerations.
+// Deve ser chamado antes da compressão, que altera o histórico.
+// Clientes sem identificação não são controlados, nem ninguém com o store fora do ar.
+func Check(client string, in *provider.Request) (Key, *provider.Result, error) {
+	if client == "" || limit == 0 {
+		return Key{}, nil, nil
+	}
+
+	k := key(client, in)
+	count, err := store.Incr(k.String()+":count", window)
+	if err != nil {
+		log.Printf("⚠️  Controle de repetição indisponível: %v", err)
+		return Key{}, nil, nil
+	}
+	if count <= int64(limit) {
+		return k, nil, nil
+	}
+
+	// só uma resposta não é alternativa para quem pediu outra
+	results := stored(k)
+	if len(results) < 2 {
+		return Key{}, nil, ErrTooManyRegenerations
+	}
+	return Key{}, results[count%int64(len(results))], nil
+}
+
+func stored(k Key) []*provider.Result {
+	raw, ok, err := store.Get(k.String() + ":results")
+	if err != nil || !ok {
+		return nil
+	}
+	var results []*provider.Result
+	sonic.Unmarshal(raw, &results)
+	return results
+}
+
+// serializa a leitura e regravação da lista nesta instância
+var mu sync.Mutex
+
+// Store guarda a resposta gerada para servir nas próximas repetições
+func Store(k Key, result *provider.Result) {
+	if k == (Key{}) {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	results := stored(k)
+	if len(results) >= limit {
+		return
+	}
+	raw, _ := sonic.Marshal(append(results, result))
+	if err := store.Set(k.String()+":results", raw, window); err != nil {
+		log.Printf("⚠️  Resposta não guardada para repetição: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package documents
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tamanho dos trechos em caracteres; a sobreposição evita cortar a resposta
+// entre dois trechos
+const (
+	chunkRunes   = 1000
+	overlapRunes = 150
+)
+
+// Split normaliza os espaços e divide o texto em trechos de até chunkRunes,
+// cortando de preferência em parágrafo, depois em frase, depois em palavra
+func Split(text string) []string {
+	text = normalize(text)
+	if text == "" {
+		return nil
+	}
+
+	var chunks []string
+	runes := []rune(text)
+	for start := 0; start < len(runes); {
+		end := min(start+chunkRunes, len(runes))
+		if end < len(runes) {
+			end = cut(runes, start, end)
+		}
+
+		if c := strings.TrimSpace(string(runes[start:end])); c != "" {
+			chunks = append(chunks, c)
+		}
+		if end == len(runes) {
+			break
+		}
+
+		// recua a sobreposição até o começo de uma palavra
+		next := max(end-overlapRunes, start+1)
+		for next < end && !unicode.IsSpace(runes[next-1]) {
+			next++
+		}
+		start = next
+	}
+	return chunks
+}
+
+// cut acha o melhor ponto de corte na segunda metade do trecho
+func cut(runes []rune, start, end int) int {
+	half := start + (end-start)/2
+	for _, boundary := range []func(i int) bool{
+		func(i int) bool { return runes[i] == '\n' && runes[i-1] == '\n' },
+		func(i int) bool { return unicode.IsSpace(runes[i]) && strings.ContainsRune(".!?…", runes[i-1]) },
+		func(i int) bool { return unicode.IsSpace(runes[i]) },
+	} {
+		for i := end - 1; i > half; i-- {
+			if boundary(i) {
+				return i + 1
+			}
+		}
+	}
+	return end
+}
+
+// normalize junta linhas quebradas no meio do parágrafo e limpa caracteres de controle
+func normalize(text string) string {
+	text = strings.ToValidUTF8(text, "")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	var paragraphs []string
+	for _, p := range strings.Split(text, "\n\n") {
+		words := strings.FieldsFunc(p, func(r rune) bool {
+			return unicode.IsSpace(r) || (unicode.IsControl(r) && r != utf8.RuneError)
+		})
+		if len(words) > 0 {
+			paragraphs = append(paragraphs, strings.Join(words, " "))
+		}
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
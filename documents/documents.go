@@ -0,0 +1,178 @@
+// Package documents guarda os textos enviados pelos professores, divididos em
+// trechos com embeddings, e acha os trechos mais próximos de uma pergunta.
+package documents
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/bytedance/sonic"
+
+	"lingobot-ai-engine/kv"
+	"lingobot-ai-engine/provider"
+)
+
+// ErrNotFound indica documento inexistente ou expirado
+var ErrNotFound = errors.New("document not found")
+
+// ErrEmpty indica documento sem texto aproveitável
+var ErrEmpty = errors.New("document has no text")
+
+// Chunk é um trecho do documento com seu vetor
+type Chunk struct {
+	Text   string    `json:"text"`
+	Vector []float32 `json:"vector"`
+}
+
+// Document é o texto indexado; fica inteiro numa chave do kv, em memória ou no Redis
+type Document struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title,omitempty"`
+	Embedder  string    `json:"embedder"`
+	Chars     int       `json:"chars"`
+	Chunks    []Chunk   `json:"chunks"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Match é um trecho achado para a pergunta
+type Match struct {
+	Index int     `json:"index"`
+	Score float64 `json:"score"`
+	Text  string  `json:"text"`
+}
+
+var (
+	// DOCUMENT_TTL: por quanto tempo o documento fica disponível para perguntas
+	documentTTL = loadTTL("DOCUMENT_TTL", 7*24*time.Hour)
+
+	// DOCUMENT_MAX_CHARS: tamanho máximo do texto extraído
+	MaxChars = loadInt("DOCUMENT_MAX_CHARS", 200_000)
+
+	store = kv.Prefixed(kv.Default, "documents")
+)
+
+func loadTTL(name string, fallback time.Duration) time.Duration {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		log.Printf("⚠️  %s inválido, usando %s", name, fallback)
+		return fallback
+	}
+	return d
+}
+
+func loadInt(name string, fallback int) int {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		log.Printf("⚠️  %s inválido, usando %d", name, fallback)
+		return fallback
+	}
+	return n
+}
+
+// Index divide o texto, gera os embeddings e grava o documento
+func Index(title, text string) (*Document, error) {
+	chunks := Split(text)
+	if len(chunks) == 0 {
+		return nil, ErrEmpty
+	}
+
+	embedder := provider.DefaultEmbedder()
+	vectors, err := embedder.Embed(chunks)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		ID:        newID(),
+		Title:     title,
+		Embedder:  embedder.Name,
+		Chars:     len([]rune(text)),
+		Chunks:    make([]Chunk, len(chunks)),
+		CreatedAt: time.Now(),
+	}
+	for i, c := range chunks {
+		doc.Chunks[i] = Chunk{Text: c, Vector: vectors[i]}
+	}
+
+	body, _ := sonic.Marshal(doc)
+	if err := store.Set(doc.ID, body, documentTTL); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "doc_" + hex.EncodeToString(b)
+}
+
+// Get carrega o documento gravado
+func Get(id string) (*Document, error) {
+	raw, ok, err := store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var doc Document
+	if err := sonic.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Search devolve os k trechos mais próximos da pergunta, em ordem de relevância
+func (d *Document) Search(question string, k int) ([]Match, error) {
+	embedder, ok := provider.EmbedderByName(d.Embedder)
+	if !ok {
+		return nil, errors.New("document was indexed with an unknown embedder: " + d.Embedder)
+	}
+	vectors, err := embedder.Embed([]string{question})
+	if err != nil {
+		return nil, err
+	}
+	query := vectors[0]
+
+	matches := make([]Match, len(d.Chunks))
+	for i, c := range d.Chunks {
+		matches[i] = Match{Index: i, Score: cosine(query, c.Vector), Text: c.Text}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
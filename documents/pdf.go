@@ -0,0 +1,283 @@
+package documents
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrPDFNoText indica PDF sem texto que dê para extrair: digitalizado ou com
+// fontes de codificação própria. O professor pode colar o texto no lugar.
+var ErrPDFNoText = errors.New("no extractable text in PDF (scanned or custom font encoding): upload the text instead")
+
+// Limite do conteúdo descomprimido de um stream, contra PDF-bomba
+const maxStreamBytes = 20 << 20
+
+var streamKeyword = regexp.MustCompile(`>>\s*stream\r?\n`)
+
+// nextStream acha o próximo stream a partir de pos: o dicionário do objeto e os
+// dados até o endstream
+func nextStream(data []byte, pos int) (dict, raw []byte, next int, ok bool) {
+	loc := streamKeyword.FindIndex(data[pos:])
+	if loc == nil {
+		return nil, nil, 0, false
+	}
+	start := pos + loc[1]
+	end := bytes.Index(data[start:], []byte("endstream"))
+	if end == -1 {
+		return nil, nil, 0, false
+	}
+
+	head := data[pos : pos+loc[0]]
+	if obj := bytes.LastIndex(head, []byte("obj")); obj != -1 {
+		head = head[obj:]
+	}
+	return head, data[start : start+end], start + end + len("endstream"), true
+}
+
+// ExtractPDF tira o texto dos operadores Tj/TJ dos streams de conteúdo. Cobre
+// os PDFs gerados por editores de texto com fontes padrão; não faz OCR.
+func ExtractPDF(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("%PDF-")) {
+		return "", errors.New("not a PDF file")
+	}
+
+	var text strings.Builder
+	for pos := 0; text.Len() <= 4*MaxChars; {
+		dict, raw, next, ok := nextStream(data, pos)
+		if !ok {
+			break
+		}
+		pos = next
+
+		content := raw
+		switch {
+		case bytes.Contains(dict, []byte("/FlateDecode")):
+			inflated, err := inflate(raw)
+			if err != nil {
+				continue
+			}
+			content = inflated
+		case bytes.Contains(dict, []byte("/Filter")):
+			// imagens (DCT, JBIG2...) e outros filtros não têm texto
+			continue
+		}
+
+		if bytes.Contains(content, []byte("BT")) {
+			contentText(content, &text)
+		}
+	}
+
+	out := text.String()
+	if !readable(out) {
+		return "", ErrPDFNoText
+	}
+	return out, nil
+}
+
+func inflate(raw []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	out, err := io.ReadAll(io.LimitReader(r, maxStreamBytes))
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	return out, nil
+}
+
+// contentText percorre os tokens do stream guardando os operandos até o
+// operador: strings viram texto, movimentos de linha viram quebra
+func contentText(content []byte, out *strings.Builder) {
+	var operands []string
+	var inArray bool
+	var array strings.Builder
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '(':
+			s, next := literalString(content, i)
+			if inArray {
+				array.WriteString(s)
+			} else {
+				operands = append(operands, s)
+			}
+			i = next
+		case c == '<' && i+1 < len(content) && content[i+1] != '<':
+			s, next := hexString(content, i)
+			if inArray {
+				array.WriteString(s)
+			} else {
+				operands = append(operands, s)
+			}
+			i = next
+		case c == '[':
+			inArray = true
+			array.Reset()
+			i++
+		case c == ']':
+			inArray = false
+			operands = append(operands, array.String())
+			i++
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case isSpace(c) || c == '{' || c == '}' || c == '<' || c == '>' || c == '/':
+			if c == '/' {
+				// nome: pula até o próximo delimitador
+				i++
+				for i < len(content) && !isSpace(content[i]) && !strings.ContainsRune("/[]()<>{}%", rune(content[i])) {
+					i++
+				}
+				continue
+			}
+			i++
+		default:
+			start := i
+			for i < len(content) && !isSpace(content[i]) && !strings.ContainsRune("/[]()<>{}%", rune(content[i])) {
+				i++
+			}
+			word := string(content[start:i])
+
+			if inArray {
+				// deslocamento grande dentro do TJ separa palavras
+				if n, err := strconv.ParseFloat(word, 64); err == nil && n < -200 {
+					array.WriteByte(' ')
+				}
+				continue
+			}
+
+			switch word {
+			case "Tj", "TJ":
+				for _, s := range operands {
+					out.WriteString(s)
+				}
+			case "'", "\"":
+				out.WriteByte('\n')
+				if len(operands) > 0 {
+					out.WriteString(operands[len(operands)-1])
+				}
+			case "T*", "Td", "TD":
+				out.WriteByte('\n')
+			case "ET":
+				out.WriteString("\n\n")
+			}
+			if _, err := strconv.ParseFloat(word, 64); err != nil {
+				operands = operands[:0]
+			}
+		}
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+// literalString lê (texto) com escapes e parênteses aninhados
+func literalString(content []byte, i int) (string, int) {
+	var b strings.Builder
+	depth := 0
+	for i++; i < len(content); i++ {
+		c := content[i]
+		switch c {
+		case '\\':
+			i++
+			if i >= len(content) {
+				return b.String(), i
+			}
+			switch e := content[i]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r', 't', 'b', 'f':
+				b.WriteByte(' ')
+			case '\r', '\n':
+				// continuação de linha
+			default:
+				if e >= '0' && e <= '7' {
+					n := 0
+					for j := 0; j < 3 && i < len(content) && content[i] >= '0' && content[i] <= '7'; j++ {
+						n = n*8 + int(content[i]-'0')
+						i++
+					}
+					i--
+					writeLatin1(&b, byte(n))
+				} else {
+					b.WriteByte(e)
+				}
+			}
+		case '(':
+			depth++
+			b.WriteByte(c)
+		case ')':
+			if depth == 0 {
+				return b.String(), i + 1
+			}
+			depth--
+			b.WriteByte(c)
+		default:
+			writeLatin1(&b, c)
+		}
+	}
+	return b.String(), i
+}
+
+// hexString lê <48656C6C6F>
+func hexString(content []byte, i int) (string, int) {
+	end := bytes.IndexByte(content[i:], '>')
+	if end == -1 {
+		return "", len(content)
+	}
+	digits := strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.ASCII_Hex_Digit, r) {
+			return r
+		}
+		return -1
+	}, string(content[i+1:i+end]))
+	if len(digits)%2 == 1 {
+		digits += "0"
+	}
+
+	var b strings.Builder
+	for j := 0; j+1 < len(digits); j += 2 {
+		n, _ := strconv.ParseUint(digits[j:j+2], 16, 8)
+		writeLatin1(&b, byte(n))
+	}
+	return b.String(), i + end + 1
+}
+
+// writeLatin1 trata os bytes como WinAnsi/Latin-1, a codificação das fontes padrão
+func writeLatin1(b *strings.Builder, c byte) {
+	switch {
+	case c == '\n' || c == '\t':
+		b.WriteByte(' ')
+	case c < 0x20:
+	case c < 0x80:
+		b.WriteByte(c)
+	case c >= 0xa0:
+		b.WriteRune(rune(c))
+	}
+}
+
+// readable exige que a maior parte do texto seja letra, número ou pontuação comum
+func readable(s string) bool {
+	var letters, total int
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		total++
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsPunct(r) {
+			letters++
+		}
+	}
+	return total >= 20 && float64(letters)/float64(total) > 0.8
+}
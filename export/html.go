@@ -0,0 +1,31 @@
+package export
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"log"
+	"os"
+)
+
+//go:embed templates/transcript.html.tmpl
+var templates embed.FS
+
+// EXPORT_TEMPLATE troca o template embutido por um arquivo próprio da escola
+var transcript = loadTemplate(os.Getenv("EXPORT_TEMPLATE"))
+
+func loadTemplate(path string) *template.Template {
+	if path != "" {
+		t, err := template.ParseFiles(path)
+		if err == nil {
+			return t
+		}
+		log.Printf("⚠️  EXPORT_TEMPLATE inválido, usando o template embutido: %v", err)
+	}
+	return template.Must(template.ParseFS(templates, "templates/transcript.html.tmpl"))
+}
+
+// HTML renderiza o relatório com as correções destacadas
+func HTML(w io.Writer, report Report) error {
+	return transcript.Execute(w, report)
+}
@@ -0,0 +1,208 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Gerador de PDF mínimo, sem dependências: A4, Helvetica com WinAnsiEncoding.
+// Caracteres fora do Latin-1 (japonês, cirílico...) saem como "?"; para esses
+// idiomas o export em HTML é o recomendado.
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+	margin     = 50.0
+	fontSize   = 11.0
+	lineHeight = 15.0
+	charWidth  = 0.52 * fontSize // largura média aproximada da Helvetica
+)
+
+// Cores RGB dos destaques
+var colors = map[string]string{
+	Plain:   "0.13 0.13 0.13",
+	Wrong:   "0.78 0.16 0.16",
+	Correct: "0.18 0.49 0.20",
+	"label": "0.29 0.42 0.97",
+	"meta":  "0.45 0.45 0.45",
+}
+
+// Pedaço de linha já posicionado
+type run struct {
+	kind string
+	bold bool
+	text string
+	x    float64
+}
+
+type pdfLine []run
+
+// layout quebra os trechos em linhas que cabem na largura útil
+func layout(segments []Segment, bold bool) []pdfLine {
+	maxWidth := pageWidth - 2*margin
+	var lines []pdfLine
+	var current pdfLine
+	x := 0.0
+
+	flush := func() {
+		lines = append(lines, current)
+		current = nil
+		x = 0
+	}
+
+	for _, seg := range segments {
+		paragraphs := strings.Split(seg.Text, "\n")
+		for p, paragraph := range paragraphs {
+			if p > 0 {
+				flush()
+			}
+			for _, word := range strings.SplitAfter(paragraph, " ") {
+				if word == "" {
+					continue
+				}
+				width := float64(len([]rune(word))) * charWidth
+				if x+width > maxWidth && x > 0 {
+					flush()
+					word = strings.TrimLeft(word, " ")
+					width = float64(len([]rune(word))) * charWidth
+				}
+				current = append(current, run{kind: seg.Kind, bold: bold || seg.Kind == Correct, text: word, x: x})
+				x += width
+			}
+		}
+	}
+	if len(current) > 0 {
+		flush()
+	}
+	return lines
+}
+
+// PDF renderiza o relatório como um PDF de texto
+func PDF(report Report) []byte {
+	var pages []*bytes.Buffer
+	var page *bytes.Buffer
+	y := 0.0
+
+	newPage := func() {
+		page = &bytes.Buffer{}
+		pages = append(pages, page)
+		y = pageHeight - margin
+	}
+
+	writeLines := func(lines []pdfLine, color string) {
+		for _, line := range lines {
+			if y < margin {
+				newPage()
+			}
+			for _, r := range line {
+				kind := r.kind
+				if color != "" {
+					kind = color
+				}
+				font := "F1"
+				if r.bold {
+					font = "F2"
+				}
+				fmt.Fprintf(page, "BT /%s %.0f Tf %s rg %.2f %.2f Td (%s) Tj ET\n",
+					font, fontSize, colors[kind], margin+r.x, y, pdfString(r.text))
+
+				// riscado sobre a forma incorreta
+				if r.kind == Wrong && color == "" {
+					width := float64(len([]rune(strings.TrimRight(r.text, " ")))) * charWidth
+					fmt.Fprintf(page, "%s RG 0.8 w %.2f %.2f m %.2f %.2f l S\n",
+						colors[Wrong], margin+r.x, y+fontSize*0.3, margin+r.x+width, y+fontSize*0.3)
+				}
+			}
+			y -= lineHeight
+		}
+	}
+
+	newPage()
+	writeLines(layout([]Segment{{Kind: Plain, Text: "Relatório da sessão"}}, true), "label")
+	writeLines(layout([]Segment{{Kind: Plain, Text: fmt.Sprintf("Conversa %s · início %s · %d turnos · %d correções",
+		report.ID, report.Started, len(report.Entries), report.Corrections)}}, false), "meta")
+	y -= lineHeight
+
+	for _, entry := range report.Entries {
+		header := entry.Label + "  " + entry.Time
+		if entry.Provider != "" {
+			header += " · " + entry.Provider
+		}
+		if y < margin+2*lineHeight {
+			newPage()
+		}
+		writeLines(layout([]Segment{{Kind: Plain, Text: header}}, true), "label")
+		writeLines(layout(entry.Segments, false), "")
+		y -= lineHeight / 2
+	}
+
+	return assemble(pages)
+}
+
+// assemble monta objetos, xref e trailer
+func assemble(pages []*bytes.Buffer) []byte {
+	var out bytes.Buffer
+	var offsets []int
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1 catálogo, 2 árvore de páginas, 3-4 fontes, depois pares página/conteúdo
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = strconv.Itoa(5+2*i) + " 0 R"
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, content := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// Caracteres tipográficos comuns que o WinAnsi posiciona fora do Latin-1
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// pdfString converte para WinAnsi e escapa os delimitadores de string do PDF
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x80:
+			b.WriteRune(r)
+		case winAnsi[r] != 0:
+			fmt.Fprintf(&b, "\\%03o", winAnsi[r])
+		case r >= 0xA0 && r <= 0xFF:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
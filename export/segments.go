@@ -0,0 +1,125 @@
+// Package export gera relatórios imprimíveis (HTML e PDF) das conversas,
+// destacando as correções feitas pelo tutor.
+package export
+
+import (
+	"strings"
+
+	"lingobot-ai-engine/conversation"
+)
+
+// Tipos de trecho de um turno
+const (
+	Plain   = "plain"
+	Wrong   = "wrong"   // forma incorreta do aluno, ~~assim~~
+	Correct = "correct" // correção logo em seguida, **assim**
+)
+
+// Trecho de texto com destaque
+type Segment struct {
+	Kind string
+	Text string
+}
+
+// Turno pronto para renderizar
+type Entry struct {
+	Role     string
+	Label    string
+	Provider string
+	Time     string
+	Segments []Segment
+}
+
+// Relatório de uma conversa
+type Report struct {
+	ID          string
+	Started     string
+	Entries     []Entry
+	Corrections int
+}
+
+// NewReport converte a conversa em trechos destacados
+func NewReport(c *conversation.Conversation) Report {
+	report := Report{ID: c.ID, Started: c.Started.Format("02/01/2006 15:04")}
+
+	for _, t := range c.Turns {
+		entry := Entry{
+			Role:     t.Role,
+			Label:    "Aluno",
+			Provider: t.Provider,
+			Time:     t.At.Format("15:04"),
+			Segments: []Segment{{Kind: Plain, Text: t.Content}},
+		}
+
+		if t.Role == "assistant" {
+			entry.Label = "Tutor"
+			entry.Segments = Segments(t.Content)
+			for _, s := range entry.Segments {
+				if s.Kind == Wrong {
+					report.Corrections++
+				}
+			}
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+	return report
+}
+
+// Segments separa as correções marcadas pelo tutor em markdown:
+// "~~eu sou ir~~ **eu vou**" vira Wrong + Correct; outros negritos viram texto simples.
+func Segments(text string) []Segment {
+	var segments []Segment
+	plain := func(s string) {
+		if s == "" {
+			return
+		}
+		if n := len(segments); n > 0 && segments[n-1].Kind == Plain {
+			segments[n-1].Text += s
+			return
+		}
+		segments = append(segments, Segment{Kind: Plain, Text: s})
+	}
+
+	for text != "" {
+		start := strings.Index(text, "~~")
+		bold := strings.Index(text, "**")
+
+		if start == -1 && bold == -1 {
+			plain(text)
+			break
+		}
+
+		// negrito solto, fora de uma correção
+		if start == -1 || (bold != -1 && bold < start) {
+			end := strings.Index(text[bold+2:], "**")
+			if end == -1 {
+				plain(text)
+				break
+			}
+			plain(text[:bold] + text[bold+2:bold+2+end])
+			text = text[bold+2+end+2:]
+			continue
+		}
+
+		end := strings.Index(text[start+2:], "~~")
+		if end == -1 {
+			plain(text)
+			break
+		}
+
+		plain(text[:start])
+		segments = append(segments, Segment{Kind: Wrong, Text: text[start+2 : start+2+end]})
+		text = text[start+2+end+2:]
+
+		// a correção vem logo depois, às vezes separada por seta
+		rest := strings.TrimLeft(text, " →->:")
+		if strings.HasPrefix(rest, "**") {
+			if end := strings.Index(rest[2:], "**"); end != -1 {
+				plain(" ")
+				segments = append(segments, Segment{Kind: Correct, Text: rest[2 : 2+end]})
+				text = rest[2+end+2:]
+			}
+		}
+	}
+	return segments
+}
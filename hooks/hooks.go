@@ -0,0 +1,154 @@
+// Package hooks entrega cada turno concluído a componentes registrados
+// (analytics, cobrança, auditoria) sem que os handlers conheçam cada um.
+package hooks
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+
+	"lingobot-ai-engine/provider"
+	"lingobot-ai-engine/routing"
+	"lingobot-ai-engine/vocabulary"
+)
+
+// Tempos do turno
+type Timings struct {
+	Queue    time.Duration // da chegada até a chamada ao provedor
+	Provider time.Duration
+	Total    time.Duration
+}
+
+// Finish é o turno canônico: pedido como chegou, pedido enviado ao provedor e resultado
+type Finish struct {
+	Route          string // "/ai", "/translate", "grpc:Chat", "/ai/async"...
+	Client         string // mesmo identificador dos experimentos
+	Text           string // texto original do cliente
+	Persona        string
+	Language       string // idioma declarado pelo cliente
+	ConversationID string
+
+	// Idioma em que a resposta deveria vir e o detectado nela; vazios quando incertos
+	ExpectedLanguage string
+	ResponseLanguage string
+
+	Request    *provider.Request // após compressão e escolha de modelo
+	Result     *provider.Result  // nil quando Err != nil
+	Provider   string
+	Model      string
+	Experiment *routing.ExperimentTag
+	Err        error
+	Cached     bool // resposta reaproveitada, sem chamada ao provedor
+	Stream     bool
+
+	Level      string      // nível CEFR pedido pelo cliente
+	Vocabulary *Vocabulary // nil quando a resposta não foi conferida
+
+	Timings Timings
+	At      time.Time
+}
+
+// Vocabulary é a conferência da resposta contra o nível: a primeira versão e a
+// entregue, que é a reescrita quando Retried e ela ficou mais simples
+type Vocabulary struct {
+	First   vocabulary.Report
+	Final   vocabulary.Report
+	Retried bool
+}
+
+// Hook recebe os turnos concluídos. OnFinish roda fora do caminho da resposta,
+// então pode ser lento, mas não deve alterar o Finish.
+type Hook interface {
+	Name() string
+	OnFinish(f *Finish)
+}
+
+var (
+	mu         sync.RWMutex
+	registered []Hook
+)
+
+// Register adiciona um hook; chamar na inicialização
+func Register(h Hook) {
+	mu.Lock()
+	registered = append(registered, h)
+	mu.Unlock()
+}
+
+// Emit entrega o turno a todos os hooks, cada um na sua goroutine
+func Emit(f *Finish) {
+	mu.RLock()
+	hs := registered
+	mu.RUnlock()
+
+	for _, h := range hs {
+		go run(h, f)
+	}
+}
+
+func run(h Hook, f *Finish) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("⚠️  Hook %s falhou: %v", h.Name(), r)
+		}
+	}()
+	h.OnFinish(f)
+}
+
+// FINISH_LOG=1 registra um hook que escreve cada turno como JSON no log
+func init() {
+	if v := os.Getenv("FINISH_LOG"); v == "1" || v == "true" {
+		Register(logHook{})
+	}
+}
+
+type logHook struct{}
+
+func (logHook) Name() string { return "log" }
+
+// Linha do FINISH_LOG
+type logEntry struct {
+	Route      string                 `json:"route"`
+	Provider   string                 `json:"provider,omitempty"`
+	Model      string                 `json:"model,omitempty"`
+	Cached     bool                   `json:"cached,omitempty"`
+	Stream     bool                   `json:"stream,omitempty"`
+	Language   string                 `json:"response_language,omitempty"`
+	Calls      int                    `json:"upstream_calls"`
+	QueueMs    int64                  `json:"queue_ms"`
+	ProviderMs int64                  `json:"provider_ms"`
+	TotalMs    int64                  `json:"total_ms"`
+	Experiment *routing.ExperimentTag `json:"experiment,omitempty"`
+	Usage      *provider.Usage        `json:"usage,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+func (logHook) OnFinish(f *Finish) {
+	entry := logEntry{
+		Route:      f.Route,
+		Provider:   f.Provider,
+		Model:      f.Model,
+		Cached:     f.Cached,
+		Stream:     f.Stream,
+		QueueMs:    f.Timings.Queue.Milliseconds(),
+		ProviderMs: f.Timings.Provider.Milliseconds(),
+		TotalMs:    f.Timings.Total.Milliseconds(),
+		Experiment: f.Experiment,
+	}
+	if f.Result != nil {
+		entry.Usage = &f.Result.Usage
+	}
+	entry.Language = f.ResponseLanguage
+	if f.Request != nil {
+		entry.Calls = f.Request.Budget.Used()
+	}
+	if f.Err != nil {
+		entry.Error = f.Err.Error()
+	}
+
+	line, _ := sonic.Marshal(entry)
+	log.Printf("📊 %s", line)
+}
@@ -0,0 +1,159 @@
+// Package instance identifica esta instância do serviço e, com Redis, a
+// registra junto das outras para que um roteador na frente escolha entre as
+// que estão saudáveis.
+package instance
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"os"
+	"time"
+
+	"github.com/bytedance/sonic"
+
+	"lingobot-ai-engine/kv"
+)
+
+// ID identifica a instância nos logs, no /health e no registro:
+// INSTANCE_ID, RENDER_INSTANCE_ID ou o hostname com um sufixo aleatório
+var ID = loadID()
+
+// Started é a hora em que o processo subiu
+var Started = time.Now()
+
+func loadID() string {
+	for _, name := range []string{"INSTANCE_ID", "RENDER_INSTANCE_ID"} {
+		if id := os.Getenv(name); id != "" {
+			return id
+		}
+	}
+
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "lingobot"
+	}
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return host + "-" + hex.EncodeToString(suffix)
+}
+
+// Uptime é o tempo desde que o processo subiu
+func Uptime() time.Duration {
+	return time.Since(Started)
+}
+
+// URL é o endereço pelo qual as outras peças alcançam a instância:
+// INSTANCE_URL ou RENDER_EXTERNAL_URL; vazio quando nenhum está definido
+func URL() string {
+	if u := os.Getenv("INSTANCE_URL"); u != "" {
+		return u
+	}
+	return os.Getenv("RENDER_EXTERNAL_URL")
+}
+
+// Registro compartilhado, só com REDIS_URL e INSTANCE_REGISTRY=1:
+//
+//	instance:<id>     estado da instância, expira em 3 heartbeats sem renovação
+//	instance:~index   {"<id>":"<último heartbeat>"} das instâncias vistas
+//
+// O roteador lê o índice e depois cada instance:<id>; a que não renovou a
+// chave morreu ou travou. O índice é regravado a cada heartbeat, então uma
+// entrada perdida numa escrita simultânea volta no heartbeat seguinte.
+//
+//	INSTANCE_HEARTBEAT=10s  intervalo entre heartbeats
+var (
+	registry  = kv.Prefixed(kv.Default, "instance")
+	heartbeat = loadHeartbeat()
+)
+
+const indexKey = "~index"
+
+func loadHeartbeat() time.Duration {
+	raw := os.Getenv("INSTANCE_HEARTBEAT")
+	if raw == "" {
+		return 10 * time.Second
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		log.Printf("⚠️  INSTANCE_HEARTBEAT inválido, usando 10s")
+		return 10 * time.Second
+	}
+	return d
+}
+
+// Registered diz se o registro compartilhado está ligado
+func Registered() bool {
+	on := os.Getenv("INSTANCE_REGISTRY") == "1" || os.Getenv("INSTANCE_REGISTRY") == "true"
+	return on && os.Getenv("REDIS_URL") != ""
+}
+
+// Heartbeat grava state() no registro a cada INSTANCE_HEARTBEAT até o processo
+// terminar; sem o registro ligado, volta na hora
+func Heartbeat(state func() interface{}) {
+	if !Registered() {
+		if os.Getenv("INSTANCE_REGISTRY") != "" && os.Getenv("REDIS_URL") == "" {
+			log.Printf("⚠️  INSTANCE_REGISTRY ligado sem REDIS_URL, instância não registrada")
+		}
+		return
+	}
+
+	log.Printf("📡 Instância %s registrada, heartbeat a cada %s", ID, heartbeat)
+	beat(state())
+	for range time.Tick(heartbeat) {
+		beat(state())
+	}
+}
+
+func beat(state interface{}) {
+	raw, err := sonic.Marshal(state)
+	if err != nil {
+		log.Printf("⚠️  Estado da instância não serializado: %v", err)
+		return
+	}
+	if err := registry.Set(ID, raw, 3*heartbeat); err != nil {
+		log.Printf("⚠️  Heartbeat da instância %s falhou: %v", ID, err)
+		return
+	}
+
+	index := map[string]time.Time{}
+	if current, ok, err := registry.Get(indexKey); err == nil && ok {
+		sonic.Unmarshal(current, &index)
+	}
+	now := time.Now()
+	for id, seen := range index {
+		// instância parada há muito tempo sai do índice
+		if now.Sub(seen) > 10*heartbeat {
+			delete(index, id)
+		}
+	}
+	index[ID] = now
+	raw, _ = sonic.Marshal(index)
+	if err := registry.Set(indexKey, raw, 0); err != nil {
+		log.Printf("⚠️  Índice de instâncias não gravado: %v", err)
+	}
+}
+
+// List devolve o estado gravado de cada instância viva no registro
+func List() (map[string][]byte, error) {
+	out := map[string][]byte{}
+	raw, ok, err := registry.Get(indexKey)
+	if err != nil || !ok {
+		return out, err
+	}
+
+	var index map[string]time.Time
+	if err := sonic.Unmarshal(raw, &index); err != nil {
+		return out, err
+	}
+	for id := range index {
+		state, ok, err := registry.Get(id)
+		if err != nil {
+			return out, err
+		}
+		if ok {
+			out[id] = state
+		}
+	}
+	return out, nil
+}
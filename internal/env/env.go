@@ -0,0 +1,91 @@
+// Package env lê a configuração numérica e de listas das variáveis de
+// ambiente. Variável vazia usa o padrão; valor inválido também, com aviso no
+// log, para um erro de digitação não derrubar o processo.
+package env
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// String devolve a variável ou o padrão quando ela está vazia
+func String(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// List separa a variável por vírgulas, sem espaços nem itens vazios
+func List(name string) []string {
+	var out []string
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// Int aceita inteiros >= 0; 0 costuma desligar o recurso
+func Int(name string, fallback int) int {
+	return integer(name, fallback, 0)
+}
+
+// PositiveInt aceita só inteiros > 0
+func PositiveInt(name string, fallback int) int {
+	return integer(name, fallback, 1)
+}
+
+func integer(name string, fallback, min int) int {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < min {
+		log.Printf("⚠️  %s inválido, usando %d", name, fallback)
+		return fallback
+	}
+	return n
+}
+
+// Float aceita números >= 0
+func Float(name string, fallback float64) float64 {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 {
+		log.Printf("⚠️  %s inválido, usando %g", name, fallback)
+		return fallback
+	}
+	return v
+}
+
+// Duration aceita durações >= 0 no formato do time.ParseDuration (30s, 2m)
+func Duration(name string, fallback time.Duration) time.Duration {
+	return duration(name, fallback, 0)
+}
+
+// PositiveDuration aceita só durações > 0
+func PositiveDuration(name string, fallback time.Duration) time.Duration {
+	return duration(name, fallback, 1)
+}
+
+func duration(name string, fallback, min time.Duration) time.Duration {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < min {
+		log.Printf("⚠️  %s inválido, usando %s", name, fallback)
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,90 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestInt(t *testing.T) {
+	tests := []struct {
+		raw           string
+		want, wantPos int
+	}{
+		{"", 7, 7},
+		{"3", 3, 3},
+		{"0", 0, 7},
+		{"-1", 7, 7},
+		{"x", 7, 7},
+	}
+	for _, tt := range tests {
+		t.Setenv("TEST_ENV_INT", tt.raw)
+		if got := Int("TEST_ENV_INT", 7); got != tt.want {
+			t.Errorf("Int(%q) = %d, want %d", tt.raw, got, tt.want)
+		}
+		if got := PositiveInt("TEST_ENV_INT", 7); got != tt.wantPos {
+			t.Errorf("PositiveInt(%q) = %d, want %d", tt.raw, got, tt.wantPos)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		raw           string
+		want, wantPos time.Duration
+	}{
+		{"", time.Minute, time.Minute},
+		{"30s", 30 * time.Second, 30 * time.Second},
+		{"0s", 0, time.Minute},
+		{"-1s", time.Minute, time.Minute},
+		{"30", time.Minute, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Setenv("TEST_ENV_DURATION", tt.raw)
+		if got := Duration("TEST_ENV_DURATION", time.Minute); got != tt.want {
+			t.Errorf("Duration(%q) = %s, want %s", tt.raw, got, tt.want)
+		}
+		if got := PositiveDuration("TEST_ENV_DURATION", time.Minute); got != tt.wantPos {
+			t.Errorf("PositiveDuration(%q) = %s, want %s", tt.raw, got, tt.wantPos)
+		}
+	}
+}
+
+func TestFloat(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want float64
+	}{
+		{"", 0.5},
+		{"0", 0},
+		{"2.5", 2.5},
+		{"-0.1", 0.5},
+		{"abc", 0.5},
+	}
+	for _, tt := range tests {
+		t.Setenv("TEST_ENV_FLOAT", tt.raw)
+		if got := Float("TEST_ENV_FLOAT", 0.5); got != tt.want {
+			t.Errorf("Float(%q) = %g, want %g", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestStringAndList(t *testing.T) {
+	t.Setenv("TEST_ENV_STRING", "")
+	if got := String("TEST_ENV_STRING", "padrão"); got != "padrão" {
+		t.Errorf("String(empty) = %q", got)
+	}
+	t.Setenv("TEST_ENV_STRING", "valor")
+	if got := String("TEST_ENV_STRING", "padrão"); got != "valor" {
+		t.Errorf("String = %q", got)
+	}
+
+	t.Setenv("TEST_ENV_LIST", " a, b ,,c ")
+	if got := List("TEST_ENV_LIST"); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Errorf("List = %q", got)
+	}
+	t.Setenv("TEST_ENV_LIST", "")
+	if got := List("TEST_ENV_LIST"); got != nil {
+		t.Errorf("List(empty) = %q, want nil", got)
+	}
+}
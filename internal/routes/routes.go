@@ -0,0 +1,58 @@
+// Package routes lista os caminhos fixos do roteador HTTP que não são de
+// provedor. O server despacha por esta lista e o provider recusa provedores de
+// CUSTOM_PROVIDERS cujo /<nome> cairia numa rota fixa e nunca seria servido.
+package routes
+
+import "strings"
+
+// Fixed são os caminhos exatos
+var Fixed = []string{
+	"/ai",
+	"/ai/stream",
+	"/ai/async",
+	"/translate",
+	"/exercises",
+	"/conjugate",
+	"/define",
+	"/flashcards",
+	"/pronunciation",
+	"/documents",
+	"/tokenize",
+	"/models",
+	"/experiments",
+	"/experiments/feedback",
+	"/languages/metrics",
+	"/admin/blocklist",
+	"/admin/requests",
+	"/admin/keys",
+	"/admin/instances",
+	"/admin/pipeline",
+	"/scaling-hint",
+	"/openapi.json",
+	"/docs",
+	"/status",
+	"/version",
+	"/warmup",
+	"/health",
+}
+
+// Prefixes são os caminhos que levam um ID depois (/jobs/{id}...)
+var Prefixes = []string{
+	"/jobs/",
+	"/admin/dead-letters",
+	"/documents/",
+	"/conversations/",
+}
+
+// Taken diz se /<name>, ou algo abaixo dele, já é uma rota fixa
+func Taken(name string) bool {
+	for _, list := range [][]string{Fixed, Prefixes} {
+		for _, path := range list {
+			first, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+			if first == name {
+				return true
+			}
+		}
+	}
+	return false
+}
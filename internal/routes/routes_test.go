@@ -0,0 +1,26 @@
+package routes
+
+import "testing"
+
+func TestTaken(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"ai", true},
+		{"health", true},
+		{"admin", true},
+		{"languages", true},
+		{"scaling-hint", true},
+		{"jobs", true},
+		{"conversations", true},
+		{"lmstudio", false},
+		{"aid", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := Taken(tt.name); got != tt.want {
+			t.Errorf("Taken(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
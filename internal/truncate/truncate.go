@@ -0,0 +1,18 @@
+// Package truncate corta textos para logs e mensagens de erro sem partir
+// caracteres de vários bytes.
+package truncate
+
+import "unicode/utf8"
+
+// Bytes limita s a limit bytes, recuando até o começo de um caractere, e marca
+// o corte com "…"
+func Bytes(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "…"
+}
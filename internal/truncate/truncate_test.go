@@ -0,0 +1,33 @@
+package truncate
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		limit int
+		want  string
+	}{
+		{"curto", "erro", 10, "erro"},
+		{"no limite", "erro", 4, "erro"},
+		{"ascii", "upstream failed", 8, "upstream…"},
+		{"acento no corte", "conexão recusada", 7, "conexã…"},
+		{"emoji no corte", "falha 🔌 no provedor", 8, "falha …"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Bytes(tt.s, tt.limit)
+			if got != tt.want {
+				t.Errorf("Bytes(%q, %d) = %q, want %q", tt.s, tt.limit, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("Bytes(%q, %d) = %q is not valid UTF-8", tt.s, tt.limit, got)
+			}
+		})
+	}
+}
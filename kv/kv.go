@@ -0,0 +1,77 @@
+// Package kv é o armazenamento chave-valor com expiração usado pelo estado de
+// curta duração (repetições, conversas, jobs, limites por provedor). Em memória
+// por padrão; com REDIS_URL o estado fica compartilhado entre as instâncias.
+package kv
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// Store guarda valores com expiração; ttl <= 0 significa sem expiração
+type Store interface {
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte, ttl time.Duration) error
+
+	// Add grava só se a chave não existe; base para idempotência e single-flight
+	Add(key string, value []byte, ttl time.Duration) (bool, error)
+
+	// Incr soma 1 e devolve o novo valor; o ttl vale a partir da criação da chave,
+	// o que dá janelas fixas para contadores de limite
+	Incr(key string, ttl time.Duration) (int64, error)
+
+	// CompareAndSwap grava value só se a chave ainda vale old (nil: se não
+	// existe); base para estado lido, recalculado e regravado sem lock, como os
+	// baldes de fichas dos limites por provedor
+	CompareAndSwap(key string, old, value []byte, ttl time.Duration) (bool, error)
+
+	Delete(key string) error
+}
+
+// Default é o store da instância, escolhido na inicialização
+var Default = open(os.Getenv("REDIS_URL"))
+
+func open(redisURL string) Store {
+	if redisURL == "" {
+		return NewMemory()
+	}
+
+	r, err := NewRedis(redisURL)
+	if err != nil {
+		log.Printf("⚠️  REDIS_URL inválido, usando armazenamento em memória: %v", err)
+		return NewMemory()
+	}
+	log.Printf("🗄️  Estado compartilhado no Redis %s", r.addr)
+	return r
+}
+
+// Prefixed isola as chaves de um componente dentro do mesmo store
+func Prefixed(s Store, prefix string) Store {
+	return prefixed{s, prefix + ":"}
+}
+
+type prefixed struct {
+	s      Store
+	prefix string
+}
+
+func (p prefixed) Get(key string) ([]byte, bool, error) { return p.s.Get(p.prefix + key) }
+
+func (p prefixed) Set(key string, value []byte, ttl time.Duration) error {
+	return p.s.Set(p.prefix+key, value, ttl)
+}
+
+func (p prefixed) Add(key string, value []byte, ttl time.Duration) (bool, error) {
+	return p.s.Add(p.prefix+key, value, ttl)
+}
+
+func (p prefixed) Incr(key string, ttl time.Duration) (int64, error) {
+	return p.s.Incr(p.prefix+key, ttl)
+}
+
+func (p prefixed) CompareAndSwap(key string, old, value []byte, ttl time.Duration) (bool, error) {
+	return p.s.CompareAndSwap(p.prefix+key, old, value, ttl)
+}
+
+func (p prefixed) Delete(key string) error { return p.s.Delete(p.prefix + key) }
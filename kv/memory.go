@@ -0,0 +1,149 @@
+package kv
+
+import (
+	"bytes"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	shards        = 32
+	sweepInterval = time.Minute
+)
+
+type item struct {
+	value   []byte
+	expires time.Time // zero = sem expiração
+}
+
+func (it item) expired(now time.Time) bool {
+	return !it.expires.IsZero() && now.After(it.expires)
+}
+
+type shard struct {
+	mu    sync.Mutex
+	items map[string]item
+}
+
+// Memory é o Store local, dividido em shards para não serializar tudo num lock.
+// Chaves vencidas somem na leitura e numa varredura periódica única.
+type Memory struct {
+	shards [shards]shard
+}
+
+// NewMemory cria o store e inicia a varredura de chaves vencidas
+func NewMemory() *Memory {
+	m := &Memory{}
+	for i := range m.shards {
+		m.shards[i].items = map[string]item{}
+	}
+	go m.sweep()
+	return m
+}
+
+func (m *Memory) shard(key string) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &m.shards[h.Sum32()%shards]
+}
+
+func expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+func (m *Memory) Get(key string) ([]byte, bool, error) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it, ok := s.items[key]
+	if !ok || it.expired(time.Now()) {
+		delete(s.items, key)
+		return nil, false, nil
+	}
+	return append([]byte(nil), it.value...), true, nil
+}
+
+func (m *Memory) Set(key string, value []byte, ttl time.Duration) error {
+	s := m.shard(key)
+	s.mu.Lock()
+	s.items[key] = item{value: append([]byte(nil), value...), expires: expiry(ttl)}
+	s.mu.Unlock()
+	return nil
+}
+
+func (m *Memory) Add(key string, value []byte, ttl time.Duration) (bool, error) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if it, ok := s.items[key]; ok && !it.expired(time.Now()) {
+		return false, nil
+	}
+	s.items[key] = item{value: append([]byte(nil), value...), expires: expiry(ttl)}
+	return true, nil
+}
+
+func (m *Memory) Incr(key string, ttl time.Duration) (int64, error) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it, ok := s.items[key]
+	if !ok || it.expired(time.Now()) {
+		it = item{expires: expiry(ttl)}
+	}
+
+	n, _ := strconv.ParseInt(string(it.value), 10, 64)
+	n++
+	it.value = strconv.AppendInt(nil, n, 10)
+	s.items[key] = it
+	return n, nil
+}
+
+func (m *Memory) CompareAndSwap(key string, old, value []byte, ttl time.Duration) (bool, error) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it, ok := s.items[key]
+	if ok && it.expired(time.Now()) {
+		ok = false
+	}
+	if ok != (old != nil) || (ok && !bytes.Equal(it.value, old)) {
+		return false, nil
+	}
+	s.items[key] = item{value: append([]byte(nil), value...), expires: expiry(ttl)}
+	return true, nil
+}
+
+func (m *Memory) Delete(key string) error {
+	s := m.shard(key)
+	s.mu.Lock()
+	delete(s.items, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// sweep remove as chaves vencidas que ninguém voltou a ler, um shard por vez
+func (m *Memory) sweep() {
+	for range time.Tick(sweepInterval) {
+		for i := range m.shards {
+			s := &m.shards[i]
+			now := time.Now()
+
+			s.mu.Lock()
+			for k, it := range s.items {
+				if it.expired(now) {
+					delete(s.items, k)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package kv
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	redisPoolSize = 16
+	redisTimeout  = 2 * time.Second
+)
+
+// Incr com ttl na criação, atômico no servidor
+const incrScript = `local n = redis.call('INCR', KEYS[1])
+if n == 1 and tonumber(ARGV[1]) > 0 then redis.call('PEXPIRE', KEYS[1], ARGV[1]) end
+return n`
+
+// CompareAndSwap atômico no servidor: ARGV[1] "1" quando há valor esperado
+const casScript = `local cur = redis.call('GET', KEYS[1])
+if ARGV[1] == '1' then
+  if cur ~= ARGV[2] then return 0 end
+elseif cur then return 0 end
+if tonumber(ARGV[4]) > 0 then redis.call('SET', KEYS[1], ARGV[3], 'PX', ARGV[4]) else redis.call('SET', KEYS[1], ARGV[3]) end
+return 1`
+
+var errNil = errors.New("redis: nil")
+
+// Redis é o Store compartilhado entre instâncias. Fala RESP direto, sem
+// dependências, só com os comandos que o Store usa.
+type Redis struct {
+	addr     string
+	password string
+	db       int
+	tls      *tls.Config
+
+	pool chan *redisConn
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// NewRedis aceita redis://[:senha@]host:porta[/db] e rediss:// para TLS
+func NewRedis(raw string) (*Redis, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	r := &Redis{addr: u.Host, pool: make(chan *redisConn, redisPoolSize)}
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.Scheme == "rediss" {
+		r.tls = &tls.Config{ServerName: u.Hostname()}
+	}
+	if u.User != nil {
+		r.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if r.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid database %q", db)
+		}
+	}
+	return r, nil
+}
+
+func (r *Redis) dial() (*redisConn, error) {
+	var c net.Conn
+	var err error
+	if r.tls != nil {
+		c, err = tls.DialWithDialer(&net.Dialer{Timeout: redisTimeout}, "tcp", r.addr, r.tls)
+	} else {
+		c, err = net.DialTimeout("tcp", r.addr, redisTimeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &redisConn{Conn: c, r: bufio.NewReader(c)}
+	if r.password != "" {
+		if _, err := conn.do("AUTH", r.password); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	if r.db != 0 {
+		if _, err := conn.do("SELECT", strconv.Itoa(r.db)); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// do pega uma conexão do pool; conexão com erro de rede é descartada
+func (r *Redis) do(args ...string) (interface{}, error) {
+	var conn *redisConn
+	select {
+	case conn = <-r.pool:
+	default:
+		var err error
+		if conn, err = r.dial(); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := conn.do(args...)
+	var protocolErr redisError
+	if err != nil && err != errNil && !errors.As(err, &protocolErr) {
+		conn.Close()
+		return nil, err
+	}
+
+	select {
+	case r.pool <- conn:
+	default:
+		conn.Close()
+	}
+	return reply, err
+}
+
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	c.SetDeadline(time.Now().Add(redisTimeout))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := io.WriteString(c, b.String()); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+// Erro devolvido pelo servidor (-ERR ...); a conexão continua válida
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+func (c *redisConn) read() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, errNil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+func millis(ttl time.Duration) string {
+	return strconv.FormatInt(ttl.Milliseconds(), 10)
+}
+
+func (r *Redis) Get(key string) ([]byte, bool, error) {
+	reply, err := r.do("GET", key)
+	if err == errNil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	value, _ := reply.([]byte)
+	return value, true, nil
+}
+
+func (r *Redis) Set(key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", millis(ttl))
+	}
+	_, err := r.do(args...)
+	return err
+}
+
+func (r *Redis) Add(key string, value []byte, ttl time.Duration) (bool, error) {
+	args := []string{"SET", key, string(value), "NX"}
+	if ttl > 0 {
+		args = append(args, "PX", millis(ttl))
+	}
+	_, err := r.do(args...)
+	if err == errNil {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *Redis) Incr(key string, ttl time.Duration) (int64, error) {
+	reply, err := r.do("EVAL", incrScript, "1", key, millis(ttl))
+	if err != nil {
+		return 0, err
+	}
+	n, _ := reply.(int64)
+	return n, nil
+}
+
+func (r *Redis) CompareAndSwap(key string, old, value []byte, ttl time.Duration) (bool, error) {
+	expected := "0"
+	if old != nil {
+		expected = "1"
+	}
+	reply, err := r.do("EVAL", casScript, "1", key, expected, string(old), string(value), millis(ttl))
+	if err != nil {
+		return false, err
+	}
+	n, _ := reply.(int64)
+	return n == 1, nil
+}
+
+func (r *Redis) Delete(key string) error {
+	_, err := r.do("DEL", key)
+	return err
+}
@@ -0,0 +1,111 @@
+// Package language detecta o idioma de um texto sem dependências externas:
+// escrita (kana, hangul, cirílico...) e palavras funcionais para idiomas latinos.
+package language
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Palavras funcionais frequentes por idioma de escrita latina
+var stopwords = map[string][]string{
+	"pt": {"de", "que", "não", "uma", "para", "com", "você", "está", "isso", "como", "mais", "mas", "eu", "ele", "muito", "também", "é", "são", "do", "da", "em", "os", "as"},
+	"es": {"de", "que", "no", "una", "para", "con", "usted", "está", "eso", "como", "más", "pero", "yo", "él", "muy", "también", "es", "son", "del", "el", "los", "las", "y"},
+	"en": {"the", "and", "is", "are", "you", "that", "this", "with", "for", "not", "have", "what", "how", "it", "of", "to", "in", "my", "do", "can"},
+	"fr": {"le", "la", "les", "et", "est", "vous", "que", "pas", "une", "pour", "avec", "ce", "je", "il", "des", "du", "sont", "mais", "très", "aussi"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ich", "sie", "ein", "eine", "mit", "für", "auch", "aber", "sehr", "wie", "was", "zu", "den", "sind"},
+	"it": {"il", "che", "non", "una", "per", "con", "sono", "è", "questo", "come", "più", "ma", "io", "lui", "molto", "anche", "gli", "della", "di", "e"},
+}
+
+// Detect devolve o código ISO 639-1 provável do texto, ou "" se incerto
+func Detect(text string) string {
+	var kana, han, hangul, cyrillic, arabic, hebrew, greek, thai, devanagari, letters int
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		case unicode.Is(unicode.Hebrew, r):
+			hebrew++
+		case unicode.Is(unicode.Greek, r):
+			greek++
+		case unicode.Is(unicode.Thai, r):
+			thai++
+		case unicode.Is(unicode.Devanagari, r):
+			devanagari++
+		}
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+
+	if letters == 0 {
+		return ""
+	}
+
+	// kana é o que separa japonês de chinês
+	switch {
+	case kana > 0 && (kana+han)*2 >= letters:
+		return "ja"
+	case han*2 >= letters:
+		return "zh"
+	case hangul*2 >= letters:
+		return "ko"
+	case cyrillic*2 >= letters:
+		return "ru"
+	case arabic*2 >= letters:
+		return "ar"
+	case hebrew*2 >= letters:
+		return "he"
+	case greek*2 >= letters:
+		return "el"
+	case thai*2 >= letters:
+		return "th"
+	case devanagari*2 >= letters:
+		return "hi"
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	best, bestScore, secondScore := "", 0, 0
+	for lang, list := range stopwords {
+		score := 0
+		for _, w := range words {
+			for _, stop := range list {
+				if w == stop {
+					score++
+					break
+				}
+			}
+		}
+		if score > bestScore {
+			best, bestScore, secondScore = lang, score, bestScore
+		} else if score > secondScore {
+			secondScore = score
+		}
+	}
+
+	// empate entre idiomas próximos (pt/es) não é confiável
+	if bestScore == 0 || bestScore == secondScore {
+		return ""
+	}
+	return best
+}
+
+// Normalize reduz "pt-BR" ou "PT_br" a "pt"
+func Normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i != -1 {
+		lang = lang[:i]
+	}
+	return lang
+}
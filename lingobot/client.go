@@ -0,0 +1,509 @@
+// Package lingobot é o cliente Go do gateway de IA da Lingobot.
+//
+//	c := lingobot.NewClient("https://lingobot-api.onrender.com", os.Getenv("LINGOBOT_KEY"))
+//	resp, err := c.Chat(lingobot.ChatRequest{Text: "Como se diz 'bom dia' em japonês?"})
+package lingobot
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+)
+
+// Client fala com uma instância do gateway. É seguro para uso concorrente.
+type Client struct {
+	baseURL string
+	key     string
+	http    *fasthttp.Client
+
+	// Timeout de cada chamada sem streaming
+	Timeout time.Duration
+}
+
+// NewClient cria um cliente para baseURL autenticando com a API key
+func NewClient(baseURL, key string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		key:     key,
+		http: &fasthttp.Client{
+			MaxIdleConnDuration: 90 * time.Second,
+		},
+		Timeout: 60 * time.Second,
+	}
+}
+
+// APIError é devolvido quando o gateway responde com status de erro. Code é
+// estável (upstream_rate_limited, model_not_allowed, ...) e Retryable diz se
+// vale tentar de novo, depois de RetryAfter segundos quando informado.
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	Provider   string `json:"provider,omitempty"`
+	Retryable  bool   `json:"retryable"`
+	RetryAfter int    `json:"retry_after,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("lingobot: status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("lingobot: status %d: %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Turno anterior da conversa
+type Message struct {
+	Role    string `json:"role"` // system, user ou assistant
+	Content string `json:"content"`
+}
+
+// ChatRequest é o corpo do POST /ai
+type ChatRequest struct {
+	Text             string         `json:"text"`
+	History          []Message      `json:"history,omitempty"`
+	Persona          string         `json:"persona,omitempty"`
+	SessionID        string         `json:"session_id,omitempty"`
+	ConversationID   string         `json:"conversation_id,omitempty"` // histórico gravado no servidor
+	Language         string         `json:"language,omitempty"`
+	Strategy         string         `json:"strategy,omitempty"`
+	Model            string         `json:"model,omitempty"` // alias ("fast", "smart", "cheap") ou modelo permitido
+	Gemini           *GeminiOptions `json:"gemini,omitempty"`
+	MaxCostUSD       float64        `json:"max_cost_usd,omitempty"`   // teto de custo por chamada ao provedor
+	Level            string         `json:"level,omitempty"`          // nível CEFR do aluno (A1–C2)
+	Post             []string       `json:"post,omitempty"`           // strip_markdown, max_chars:N, normalize_quotes...; não vale no streaming
+	Stop             []string       `json:"stop,omitempty"`           // até 4 sequências que encerram a resposta
+	ReplyLanguage    string         `json:"reply_language,omitempty"` // idioma cobrado da resposta (ISO 639-1) ou "auto"
+	Reasoning        bool           `json:"reasoning,omitempty"`
+	IncludeReasoning bool           `json:"include_reasoning,omitempty"`
+	Debug            bool           `json:"debug,omitempty"`
+}
+
+// Uso de tokens informado pelo provedor
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Braço de experimento A/B que atendeu o turno
+type ExperimentTag struct {
+	Experiment string `json:"experiment"`
+	Arm        string `json:"arm"`
+}
+
+// GeminiOptions vai para o Gemini no formato da API; os limiares de segurança
+// do servidor são o piso, o pedido só pode apertá-los
+type GeminiOptions struct {
+	SafetySettings []struct {
+		Category  string `json:"category"`
+		Threshold string `json:"threshold"`
+	} `json:"safetySettings,omitempty"`
+	GenerationConfig map[string]interface{} `json:"generationConfig,omitempty"`
+}
+
+// ChatResponse é a resposta dos endpoints de chat e tradução
+type ChatResponse struct {
+	Response   string             `json:"response"`
+	Language   string             `json:"response_language,omitempty"` // idioma detectado na resposta
+	Reasoning  string             `json:"reasoning,omitempty"`
+	Usage      *Usage             `json:"usage,omitempty"`
+	Experiment *ExperimentTag     `json:"experiment,omitempty"`
+	Timings    map[string]float64 `json:"timings,omitempty"`
+}
+
+// Chat envia um turno para o /ai, com fallback entre provedores no servidor
+func (c *Client) Chat(req ChatRequest) (*ChatResponse, error) {
+	var out ChatResponse
+	if err := c.post("/ai", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ChatProvider envia o turno direto a um provedor ("gemini", "groq", "mock"...)
+func (c *Client) ChatProvider(provider string, req ChatRequest) (*ChatResponse, error) {
+	var out ChatResponse
+	if err := c.post("/"+provider, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Job é uma geração assíncrona do /ai/async
+type Job struct {
+	ID          string        `json:"id"`
+	Status      string        `json:"status"` // queued, running, done ou failed
+	Result      *ChatResponse `json:"result,omitempty"`
+	Error       *APIError     `json:"error,omitempty"`
+	Attempts    int           `json:"attempts,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	CompletedAt *time.Time    `json:"completed_at,omitempty"`
+}
+
+// ChatAsync enfileira o turno e devolve o ID do job. Com callbackURL, o
+// gateway posta o Job concluído nessa URL; sem ela, use Job para consultar.
+func (c *Client) ChatAsync(req ChatRequest, callbackURL string) (string, error) {
+	body := struct {
+		ChatRequest
+		CallbackURL string `json:"callback_url,omitempty"`
+	}{req, callbackURL}
+
+	var out struct {
+		JobID string `json:"job_id"`
+	}
+	if err := c.post("/ai/async", body, &out); err != nil {
+		return "", err
+	}
+	return out.JobID, nil
+}
+
+// Job consulta o estado de um job assíncrono
+func (c *Client) Job(id string) (*Job, error) {
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(c.baseURL + "/jobs/" + id)
+	if c.key != "" {
+		req.Header.Set("Authorization", "Bearer "+c.key)
+	}
+
+	if err := c.http.DoTimeout(req, resp, c.Timeout); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, apiError(resp.StatusCode(), resp.Body())
+	}
+
+	var out Job
+	if err := sonic.Unmarshal(resp.Body(), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StreamSummary chega no fim do stream
+type StreamSummary struct {
+	Language   string         `json:"response_language,omitempty"`
+	Usage      *Usage         `json:"usage,omitempty"`
+	Experiment *ExperimentTag `json:"experiment,omitempty"`
+}
+
+// ChatStream usa o /ai/stream e chama onDelta a cada trecho recebido.
+// Um erro devolvido por onDelta interrompe a leitura.
+func (c *Client) ChatStream(req ChatRequest, onDelta func(string) error) (*StreamSummary, error) {
+	body, err := sonic.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq := c.newRequest("/ai/stream", body)
+	defer fasthttp.ReleaseRequest(httpReq)
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+	resp.StreamBody = true
+
+	if err := c.http.Do(httpReq, resp); err != nil {
+		return nil, err
+	}
+	defer resp.CloseBodyStream()
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		var buf bytes.Buffer
+		buf.ReadFrom(resp.BodyStream())
+		return nil, apiError(resp.StatusCode(), buf.Bytes())
+	}
+
+	scanner := bufio.NewScanner(resp.BodyStream())
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	event := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			continue
+		case !strings.HasPrefix(line, "data:"):
+			continue
+		}
+
+		data := []byte(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+
+		switch event {
+		case "done":
+			var summary StreamSummary
+			if err := sonic.Unmarshal(data, &summary); err != nil {
+				return nil, err
+			}
+			return &summary, nil
+		case "error":
+			return nil, apiError(fasthttp.StatusBadGateway, data)
+		}
+
+		var delta struct {
+			Delta string `json:"delta"`
+		}
+		if err := sonic.Unmarshal(data, &delta); err != nil {
+			return nil, err
+		}
+		if err := onDelta(delta.Delta); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("lingobot: stream ended without done event")
+}
+
+// TranslateRequest é o corpo do POST /translate
+type TranslateRequest struct {
+	Text           string   `json:"text"`
+	SourceLanguage string   `json:"source_language,omitempty"`
+	TargetLanguage string   `json:"target_language"`
+	Level          string   `json:"level,omitempty"` // nível CEFR (A1–C2); vazio usa o da sessão
+	Post           []string `json:"post,omitempty"`  // limpeza da tradução, como em ChatRequest.Post
+	Stop           []string `json:"stop,omitempty"`  // como em ChatRequest.Stop
+	SessionID      string   `json:"session_id,omitempty"`
+}
+
+// Translate devolve o texto traduzido para TargetLanguage
+func (c *Client) Translate(req TranslateRequest) (*ChatResponse, error) {
+	var out ChatResponse
+	if err := c.post("/translate", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ExercisesRequest é o corpo do POST /exercises
+type ExercisesRequest struct {
+	Topic     string   `json:"topic"`
+	Language  string   `json:"language"`
+	Type      string   `json:"type,omitempty"` // multiple_choice (padrão), fill_in_the_blank...
+	Count     int      `json:"count,omitempty"`
+	Level     string   `json:"level,omitempty"` // nível CEFR (A1–C2); vazio usa o da sessão
+	Stop      []string `json:"stop,omitempty"`  // como em ChatRequest.Stop
+	SessionID string   `json:"session_id,omitempty"`
+}
+
+// Exercício gerado para o aluno
+type Exercise struct {
+	Question    string   `json:"question"`
+	Options     []string `json:"options,omitempty"`
+	Answer      string   `json:"answer"`
+	Explanation string   `json:"explanation,omitempty"`
+}
+
+type ExercisesResponse struct {
+	Exercises  []Exercise     `json:"exercises"`
+	Experiment *ExperimentTag `json:"experiment,omitempty"`
+}
+
+// Exercises gera exercícios estruturados sobre um tópico
+func (c *Client) Exercises(req ExercisesRequest) (*ExercisesResponse, error) {
+	var out ExercisesResponse
+	if err := c.post("/exercises", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConjugateRequest é o corpo do POST /conjugate
+type ConjugateRequest struct {
+	Verb      string   `json:"verb"`
+	Language  string   `json:"language"`
+	Tenses    []string `json:"tenses,omitempty"` // vazio usa os tempos mais usados do indicativo
+	Level     string   `json:"level,omitempty"`  // nível CEFR (A1–C2); vazio usa o da sessão
+	SessionID string   `json:"session_id,omitempty"`
+}
+
+// Tabela de conjugação de um tempo
+type ConjugationTense struct {
+	Tense string `json:"tense"`
+	Forms []struct {
+		Person string `json:"person"`
+		Form   string `json:"form"`
+	} `json:"forms"`
+	Example string `json:"example,omitempty"`
+}
+
+type ConjugateResponse struct {
+	Verb       string             `json:"verb"`
+	Infinitive string             `json:"infinitive"`
+	Language   string             `json:"language"`
+	Tenses     []ConjugationTense `json:"tenses"`
+	Experiment *ExperimentTag     `json:"experiment,omitempty"`
+}
+
+// Conjugate devolve as tabelas de conjugação de um verbo
+func (c *Client) Conjugate(req ConjugateRequest) (*ConjugateResponse, error) {
+	var out ConjugateResponse
+	if err := c.post("/conjugate", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DefineRequest é o corpo do POST /define
+type DefineRequest struct {
+	Word      string `json:"word"`
+	Language  string `json:"language"`
+	Level     string `json:"level,omitempty"` // nível CEFR (A1–C2) das definições
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// Sentido de uma palavra no dicionário
+type WordSense struct {
+	PartOfSpeech string   `json:"part_of_speech,omitempty"`
+	Definition   string   `json:"definition"`
+	Examples     []string `json:"examples,omitempty"`
+	Synonyms     []string `json:"synonyms,omitempty"`
+}
+
+type DefineResponse struct {
+	Word       string         `json:"word"`
+	Language   string         `json:"language"`
+	Level      string         `json:"level,omitempty"`
+	Senses     []WordSense    `json:"senses"`
+	Experiment *ExperimentTag `json:"experiment,omitempty"`
+}
+
+// Define devolve os sentidos de uma palavra
+func (c *Client) Define(req DefineRequest) (*DefineResponse, error) {
+	var out DefineResponse
+	if err := c.post("/define", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// FlashcardsRequest é o corpo do POST /flashcards; informe Topic ou Words
+type FlashcardsRequest struct {
+	Topic          string   `json:"topic,omitempty"`
+	Words          []string `json:"words,omitempty"`
+	Language       string   `json:"language"`
+	NativeLanguage string   `json:"native_language,omitempty"` // idioma do verso; vazio usa definições em Language
+	Level          string   `json:"level,omitempty"`
+	Count          int      `json:"count,omitempty"`
+	Deck           string   `json:"deck,omitempty"`
+	SessionID      string   `json:"session_id,omitempty"`
+}
+
+// Cartão com frente e verso
+type Flashcard struct {
+	Front   string   `json:"front"`
+	Back    string   `json:"back"`
+	Example string   `json:"example,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+type FlashcardsResponse struct {
+	Deck       string         `json:"deck"`
+	Language   string         `json:"language"`
+	Cards      []Flashcard    `json:"cards"`
+	Experiment *ExperimentTag `json:"experiment,omitempty"`
+}
+
+// Flashcards gera um baralho em JSON; CSV e o arquivo do Anki saem direto do
+// endpoint com ?format=csv|apkg-ready
+func (c *Client) Flashcards(req FlashcardsRequest) (*FlashcardsResponse, error) {
+	var out FlashcardsResponse
+	if err := c.post("/flashcards", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// TokenizeRequest é o corpo do POST /tokenize
+type TokenizeRequest struct {
+	Text           string    `json:"text"`
+	History        []Message `json:"history,omitempty"`
+	ConversationID string    `json:"conversation_id,omitempty"` // conta o histórico gravado
+	Level          string    `json:"level,omitempty"`           // inclui a instrução do nível CEFR
+	Provider       string    `json:"provider,omitempty"`        // vazio conta em todos
+	Model          string    `json:"model,omitempty"`
+	MaxTokens      int       `json:"max_tokens,omitempty"` // resposta reservada na janela; padrão 1000
+}
+
+// Contagem estimada num provedor e modelo
+type TokenCount struct {
+	Provider  string `json:"provider"`
+	Model     string `json:"model,omitempty"`
+	Reasoning bool   `json:"reasoning,omitempty"`
+	Tokenizer string `json:"tokenizer"`
+	Tokens    int    `json:"tokens"`
+	Window    int    `json:"context_window"` // 0 quando a janela não é conhecida
+	Fits      bool   `json:"fits"`
+}
+
+type TokenizeResponse struct {
+	Characters int          `json:"characters"`
+	Counts     []TokenCount `json:"counts"`
+}
+
+// Tokenize estima os tokens do pedido em cada provedor e modelo
+func (c *Client) Tokenize(req TokenizeRequest) (*TokenizeResponse, error) {
+	var out TokenizeResponse
+	if err := c.post("/tokenize", req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *Client) newRequest(path string, body []byte) *fasthttp.Request {
+	req := fasthttp.AcquireRequest()
+	req.SetRequestURI(c.baseURL + path)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.SetContentType("application/json")
+	if c.key != "" {
+		req.Header.Set("Authorization", "Bearer "+c.key)
+	}
+	req.SetBody(body)
+	return req
+}
+
+// post envia in como JSON e decodifica a resposta em out
+func (c *Client) post(path string, in, out interface{}) error {
+	body, err := sonic.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	req := c.newRequest(path, body)
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	if err := c.http.DoTimeout(req, resp, c.Timeout); err != nil {
+		return err
+	}
+
+	if code := resp.StatusCode(); code != fasthttp.StatusOK && code != fasthttp.StatusAccepted {
+		return apiError(code, resp.Body())
+	}
+
+	return sonic.Unmarshal(resp.Body(), out)
+}
+
+func apiError(status int, body []byte) error {
+	var payload struct {
+		Error *APIError `json:"error"`
+	}
+	if sonic.Unmarshal(body, &payload) != nil || payload.Error == nil {
+		return &APIError{StatusCode: status, Message: string(body)}
+	}
+	payload.Error.StatusCode = status
+	return payload.Error
+}
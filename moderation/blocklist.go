@@ -0,0 +1,192 @@
+// Package moderation aplica a blocklist distribuída pelo serviço central de
+// políticas da Lingobot, atualizável em tempo de execução.
+package moderation
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+)
+
+var client = &fasthttp.Client{
+	ReadTimeout:  30 * time.Second,
+	WriteTimeout: 30 * time.Second,
+}
+
+// Regras de moderação distribuídas pelo serviço central de políticas da Lingobot
+type Blocklist struct {
+	Version  string   `json:"version"`
+	Terms    []string `json:"terms"`    // comparação sem diferenciar maiúsculas
+	Patterns []string `json:"patterns"` // expressões regulares
+
+	compiled []*regexp.Regexp
+	longest  int // runas do maior termo
+}
+
+// ErrContentBlocked indica violação da blocklist
+var ErrContentBlocked = errors.New("content blocked by moderation policy")
+
+// Conjunto ativo, trocado atomicamente a cada atualização
+var blocklist atomic.Pointer[Blocklist]
+
+func init() {
+	blocklist.Store(&Blocklist{})
+
+	if path := os.Getenv("BLOCKLIST_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			err = Apply(data)
+		}
+		if err != nil {
+			log.Printf("⚠️  Falha ao carregar BLOCKLIST_FILE: %v", err)
+		}
+	}
+}
+
+// Apply valida e ativa um novo conjunto de regras
+func Apply(data []byte) error {
+	var next Blocklist
+	if err := sonic.Unmarshal(data, &next); err != nil {
+		return err
+	}
+
+	for i, term := range next.Terms {
+		next.Terms[i] = strings.ToLower(term)
+		next.longest = max(next.longest, utf8.RuneCountInString(term))
+	}
+
+	for _, pattern := range next.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		next.compiled = append(next.compiled, re)
+	}
+
+	previous := blocklist.Swap(&next)
+	if previous.Version != next.Version {
+		log.Printf("🛡️  Blocklist atualizada: %q → %q (%d termos, %d padrões)",
+			previous.Version, next.Version, len(next.Terms), len(next.compiled))
+	}
+	return nil
+}
+
+// Check devolve ErrContentBlocked quando o texto viola alguma regra
+func Check(text string) error {
+	rules := blocklist.Load()
+
+	lower := strings.ToLower(text)
+	for _, term := range rules.Terms {
+		if term != "" && strings.Contains(lower, term) {
+			return ErrContentBlocked
+		}
+	}
+
+	for _, re := range rules.compiled {
+		if re.MatchString(text) {
+			return ErrContentBlocked
+		}
+	}
+	return nil
+}
+
+// Overlap devolve o fim do texto em que um termo ainda pode estar começando:
+// até o tamanho do maior termo menos uma runa. Quem confere o texto em pedaços
+// junta isso ao próximo pedaço, em vez de conferir de novo o texto inteiro. Os
+// padrões veem só essa janela, então um padrão mais longo que ela e partido
+// entre pedaços passa.
+func Overlap(text string) string {
+	keep := blocklist.Load().longest - 1
+	cut := len(text)
+	for ; keep > 0 && cut > 0; keep-- {
+		_, size := utf8.DecodeLastRuneInString(text[:cut])
+		cut -= size
+	}
+	return text[cut:]
+}
+
+// VerifySignature confere o HMAC-SHA256 de X-Lingobot-Signature ("sha256=<hex>")
+func VerifySignature(body, signature []byte) bool {
+	secret := os.Getenv("ADMIN_WEBHOOK_SECRET")
+	if secret == "" {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), signature)
+}
+
+// Version devolve a versão das regras ativas
+func Version() string {
+	return blocklist.Load().Version
+}
+
+// Sync busca periodicamente as regras em BLOCKLIST_URL
+func Sync() {
+	url := os.Getenv("BLOCKLIST_URL")
+	if url == "" {
+		return
+	}
+
+	interval := 5 * time.Minute
+	if raw := os.Getenv("BLOCKLIST_REFRESH"); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+			interval = d
+		}
+	}
+
+	var etag string
+	for {
+		etag = fetchBlocklist(url, etag)
+		time.Sleep(interval)
+	}
+}
+
+func fetchBlocklist(url, etag string) string {
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(url)
+	req.Header.SetMethod(fasthttp.MethodGet)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if token := os.Getenv("BLOCKLIST_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	if err := client.Do(req, resp); err != nil {
+		log.Printf("⚠️  Falha ao buscar blocklist: %v", err)
+		return etag
+	}
+
+	switch resp.StatusCode() {
+	case fasthttp.StatusNotModified:
+		return etag
+	case fasthttp.StatusOK:
+		if err := Apply(resp.Body()); err != nil {
+			log.Printf("⚠️  Blocklist remota inválida: %v", err)
+			return etag
+		}
+		return string(resp.Header.Peek("ETag"))
+	default:
+		log.Printf("⚠️  Serviço de políticas devolveu status %d", resp.StatusCode())
+		return etag
+	}
+}
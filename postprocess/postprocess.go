@@ -0,0 +1,197 @@
+// Package postprocess limpa a resposta do modelo antes de ela ir ao cliente.
+// Cada pedido escolhe a cadeia no campo post, por exemplo
+// ["strip_markdown", "normalize_whitespace", "max_chars:280"], e as etapas
+// rodam na ordem pedida.
+package postprocess
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Etapas aceitas em post
+const (
+	StripMarkdown       = "strip_markdown"
+	MaxChars            = "max_chars" // max_chars:N
+	NormalizeQuotes     = "normalize_quotes"
+	NormalizeWhitespace = "normalize_whitespace"
+
+	// ForceLanguage não mexe no texto: pede ao mesmo provedor que reescreva a
+	// resposta vinda em outro idioma, antes das demais etapas
+	ForceLanguage = "force_language"
+)
+
+// Limites da cadeia pedida
+const (
+	maxSteps = 10
+	maxLimit = 100000
+)
+
+var (
+	errTooManySteps = fmt.Errorf("post accepts at most %d steps", maxSteps)
+	errMaxChars     = fmt.Errorf("max_chars needs a limit between 1 and %d, e.g. max_chars:280", maxLimit)
+)
+
+// Step é uma etapa da cadeia; Limit só vale para max_chars
+type Step struct {
+	Name  string
+	Limit int
+}
+
+// Chain é a cadeia de etapas de um pedido
+type Chain []Step
+
+// Parse valida as etapas pedidas em post
+func Parse(steps []string) (Chain, error) {
+	if len(steps) > maxSteps {
+		return nil, errTooManySteps
+	}
+
+	chain := make(Chain, 0, len(steps))
+	for _, raw := range steps {
+		name, arg, hasArg := strings.Cut(strings.TrimSpace(raw), ":")
+		switch name {
+		case MaxChars:
+			limit, err := strconv.Atoi(arg)
+			if err != nil || limit < 1 || limit > maxLimit {
+				return nil, errMaxChars
+			}
+			chain = append(chain, Step{Name: name, Limit: limit})
+		case StripMarkdown, NormalizeQuotes, NormalizeWhitespace, ForceLanguage:
+			if hasArg {
+				return nil, fmt.Errorf("post step %s takes no argument", name)
+			}
+			chain = append(chain, Step{Name: name})
+		default:
+			return nil, errors.New("unknown post step " + strconv.Quote(raw) +
+				": use strip_markdown, max_chars:N, normalize_quotes, normalize_whitespace or force_language")
+		}
+	}
+	return chain, nil
+}
+
+// Has diz se a etapa foi pedida
+func (c Chain) Has(name string) bool {
+	for _, s := range c {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply roda as etapas de texto na ordem pedida
+func (c Chain) Apply(text string) string {
+	for _, s := range c {
+		switch s.Name {
+		case StripMarkdown:
+			text = stripMarkdown(text)
+		case MaxChars:
+			text = truncate(text, s.Limit)
+		case NormalizeQuotes:
+			text = quotes.Replace(text)
+		case NormalizeWhitespace:
+			text = normalizeWhitespace(text)
+		}
+	}
+	return text
+}
+
+// Markdown que os modelos mais usam; o conteúdo fica, só a marcação sai
+var (
+	fenceLine  = regexp.MustCompile("(?m)^[ \t]*(```|~~~).*\n?")
+	heading    = regexp.MustCompile(`(?m)^[ \t]{0,3}#{1,6}[ \t]+`)
+	blockquote = regexp.MustCompile(`(?m)^[ \t]{0,3}>[ \t]?`)
+	bullet     = regexp.MustCompile(`(?m)^([ \t]*)[-*+][ \t]+`)
+	rule       = regexp.MustCompile(`(?m)^[ \t]*([-*_][ \t]*){3,}$\n?`)
+	tableRule  = regexp.MustCompile(`(?m)^[ \t]*\|?[ \t]*:?-{3,}:?[ \t]*(\|[ \t]*:?-{3,}:?[ \t]*)*\|?[ \t]*$\n?`)
+	tableEdge  = regexp.MustCompile(`(?m)^[ \t]*\|[ \t]*|[ \t]*\|[ \t]*$`)
+	image      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	link       = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	bold       = regexp.MustCompile(`\*\*([^*\n]+)\*\*|__([^_\n]+)__`)
+	italic     = regexp.MustCompile(`\*([^*\n]+)\*`)
+	underscore = regexp.MustCompile(`(^|[^\pL\pN_])_([^_\n]+)_([^\pL\pN_]|$)`) // não pega snake_case
+	strike     = regexp.MustCompile(`~~([^~\n]+)~~`)
+	inlineCode = regexp.MustCompile("`([^`\n]+)`")
+)
+
+func stripMarkdown(text string) string {
+	text = fenceLine.ReplaceAllString(text, "")
+	text = rule.ReplaceAllString(text, "")
+	text = tableRule.ReplaceAllString(text, "")
+	text = tableEdge.ReplaceAllString(text, "")
+	text = heading.ReplaceAllString(text, "")
+	text = blockquote.ReplaceAllString(text, "")
+	text = bullet.ReplaceAllString(text, "${1}• ")
+	text = image.ReplaceAllString(text, "$1")
+	text = link.ReplaceAllString(text, "$1")
+	text = inlineCode.ReplaceAllString(text, "$1")
+	text = bold.ReplaceAllString(text, "$1$2")
+	text = italic.ReplaceAllString(text, "$1")
+	text = underscore.ReplaceAllString(text, "$1$2$3")
+	text = strike.ReplaceAllString(text, "$1")
+	return strings.TrimSpace(text)
+}
+
+// Aspas tipográficas viram retas
+var quotes = strings.NewReplacer(
+	"“", `"`, "”", `"`, "„", `"`, "‟", `"`, "«", `"`, "»", `"`, "＂", `"`,
+	"‘", "'", "’", "'", "‚", "'", "‛", "'", "‹", "'", "›", "'", "´", "'",
+)
+
+// normalizeWhitespace junta espaços repetidos, tira os das pontas das linhas
+// e deixa no máximo uma linha em branco entre parágrafos
+func normalizeWhitespace(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	blank := false
+	for _, line := range lines {
+		line = strings.Join(strings.FieldsFunc(line, func(r rune) bool {
+			return unicode.IsSpace(r) || r == '\u200b'
+		}), " ")
+		if line == "" {
+			if !blank && len(out) > 0 {
+				out = append(out, "")
+			}
+			blank = true
+			continue
+		}
+		out = append(out, line)
+		blank = false
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// truncate corta em limit caracteres, de preferência no fim de uma frase e,
+// sem frase que aproveite ao menos metade, numa palavra com reticências
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+
+	for i := limit - 1; i >= limit/2; i-- {
+		switch runes[i] {
+		case '.', '!', '?', '…', '。', '！', '？':
+			if unicode.IsSpace(runes[i+1]) {
+				return strings.TrimSpace(string(runes[:i+1]))
+			}
+		}
+	}
+
+	cut := runes[:max(limit-1, 0)] // espaço para as reticências
+	for i := len(cut) - 1; i >= limit/2; i-- {
+		if unicode.IsSpace(cut[i]) {
+			cut = cut[:i]
+			break
+		}
+	}
+	return strings.TrimRightFunc(string(cut), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + "…"
+}
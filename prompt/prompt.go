@@ -0,0 +1,141 @@
+// Package prompt monta prompts a partir de templates com {{variáveis}},
+// tratando o que vem do aluno como dado e não como instrução.
+package prompt
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// InvalidError indica variável fora do tipo, tamanho ou valores aceitos
+type InvalidError struct {
+	Name   string
+	Reason string
+}
+
+func (e *InvalidError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Name, e.Reason)
+}
+
+// Value é uma variável já validada para interpolação
+type Value struct {
+	text string
+	err  string
+}
+
+// Vars associa os nomes do template aos valores
+type Vars map[string]Value
+
+// Marcadores de papel e de template que o texto do aluno não pode carregar
+var forbidden = regexp.MustCompile(`(?i)\{\{|\}\}|<\|[a-z_]+\|>|\[/?INST\]|<</?SYS>>|</?s>|</?think>`)
+
+// Delimitador do texto livre; ocorrências dentro do valor são neutralizadas
+const fence = `"""`
+
+// Text é texto livre do aluno: limita o tamanho, remove caracteres de controle e
+// marcadores de papel, e fica entre aspas triplas para o modelo ler como conteúdo
+func Text(s string, maxRunes int) Value {
+	s = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) || r == utf8.RuneError || unicode.Is(unicode.Cf, r) {
+			return -1
+		}
+		return r
+	}, s)
+
+	// repete até estabilizar: remover um marcador pode formar outro
+	for prev := ""; prev != s; {
+		prev = s
+		s = forbidden.ReplaceAllString(s, "")
+		s = strings.ReplaceAll(s, fence, `"`)
+	}
+
+	if utf8.RuneCountInString(s) > maxRunes {
+		return Value{err: fmt.Sprintf("longer than %d characters", maxRunes)}
+	}
+	if strings.TrimSpace(s) == "" {
+		return Value{err: "empty"}
+	}
+	return Value{text: fence + "\n" + s + "\n" + fence}
+}
+
+// Name é um valor curto de uma linha (idioma, tipo de exercício): só letras,
+// números, espaço, hífen, sublinhado, ponto, parênteses e apóstrofo
+func Name(s string, maxRunes int) Value {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "":
+		return Value{err: "empty"}
+	case utf8.RuneCountInString(s) > maxRunes:
+		return Value{err: fmt.Sprintf("longer than %d characters", maxRunes)}
+	}
+
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) {
+			continue
+		}
+		if !strings.ContainsRune(" -_.()'", r) {
+			return Value{err: fmt.Sprintf("character %q not allowed", r)}
+		}
+	}
+	return Value{text: s}
+}
+
+// OneOf aceita só os valores listados
+func OneOf(s string, allowed ...string) Value {
+	for _, a := range allowed {
+		if s == a {
+			return Value{text: s}
+		}
+	}
+	return Value{err: "must be one of " + strings.Join(allowed, ", ")}
+}
+
+// Int aceita inteiros no intervalo fechado
+func Int(n, min, max int) Value {
+	if n < min || n > max {
+		return Value{err: fmt.Sprintf("must be between %d and %d", min, max)}
+	}
+	return Value{text: strconv.Itoa(n)}
+}
+
+// Render substitui cada {{nome}} numa única passada, então valores nunca são
+// reinterpretados como template. Placeholder sem variável é erro de template.
+func Render(template string, vars Vars) (string, error) {
+	for name, v := range vars {
+		if v.err != "" {
+			return "", &InvalidError{Name: name, Reason: v.err}
+		}
+	}
+
+	var b strings.Builder
+	rest := template
+	for {
+		start := strings.Index(rest, "{{")
+		if start == -1 {
+			b.WriteString(rest)
+			return b.String(), nil
+		}
+		end := strings.Index(rest[start:], "}}")
+		if end == -1 {
+			return "", fmt.Errorf("prompt template: unclosed placeholder")
+		}
+		end += start
+
+		name := strings.TrimSpace(rest[start+2 : end])
+		v, ok := vars[name]
+		if !ok {
+			return "", fmt.Errorf("prompt template: missing variable %q", name)
+		}
+
+		b.WriteString(rest[:start])
+		b.WriteString(v.text)
+		rest = rest[end+2:]
+	}
+}
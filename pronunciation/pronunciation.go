@@ -0,0 +1,198 @@
+// Package pronunciation alinha a transcrição da fala do aluno com a frase alvo
+// e pontua cada palavra pela distância entre o que devia ser dito e o que o
+// reconhecimento de fala ouviu.
+package pronunciation
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Situação de cada palavra depois do alinhamento
+const (
+	Correct       = "correct"
+	Mispronounced = "mispronounced"
+	Missing       = "missing" // da frase alvo, não foi ouvida
+	Extra         = "extra"   // ouvida, fora da frase alvo
+)
+
+// Nota mínima (0–100) para a palavra contar como correta
+const correctScore = 90
+
+// Heard é uma palavra reconhecida, com o tempo em segundos
+type Heard struct {
+	Word  string
+	Start float64
+	End   float64
+}
+
+// WordScore é a nota de uma palavra da frase alvo (ou de uma palavra extra)
+type WordScore struct {
+	Word   string   `json:"word,omitempty"` // vazia nas palavras extras
+	Heard  string   `json:"heard,omitempty"`
+	Score  int      `json:"score"`
+	Status string   `json:"status"`
+	Start  *float64 `json:"start,omitempty"`
+	End    *float64 `json:"end,omitempty"`
+}
+
+// Result é o alinhamento inteiro. Score é a média das palavras da frase alvo
+// (faltantes valem 0); Completeness é a proporção das que foram ouvidas.
+type Result struct {
+	Words        []WordScore `json:"words"`
+	Score        int         `json:"score"`
+	Completeness int         `json:"completeness"`
+}
+
+// Weak devolve as palavras da frase alvo abaixo da nota de correta
+func (r Result) Weak() []WordScore {
+	var weak []WordScore
+	for _, w := range r.Words {
+		if w.Status == Mispronounced || w.Status == Missing {
+			weak = append(weak, w)
+		}
+	}
+	return weak
+}
+
+// Tokens separa as palavras, sem pontuação e mantendo o apóstrofo interno
+func Tokens(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r) && r != '\'' && r != '’' && r != '-'
+	})
+	out := fields[:0]
+	for _, f := range fields {
+		if f = strings.Trim(strings.ReplaceAll(f, "’", "'"), "'-"); f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Score alinha as palavras ouvidas com a frase alvo por distância de edição
+// entre palavras: trocar uma palavra custa o quanto ela difere da alvo, e
+// pular ou acrescentar uma custa 1
+func Score(target string, heard []Heard) Result {
+	want := Tokens(target)
+
+	// o Whisper devolve a pontuação grudada nas palavras
+	var got []Heard
+	for _, h := range heard {
+		for _, w := range Tokens(h.Word) {
+			got = append(got, Heard{Word: w, Start: h.Start, End: h.End})
+		}
+	}
+
+	n, m := len(want), len(got)
+	cost := make([][]float64, n+1)
+	for i := range cost {
+		cost[i] = make([]float64, m+1)
+		cost[i][0] = float64(i)
+	}
+	for j := 0; j <= m; j++ {
+		cost[0][j] = float64(j)
+	}
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			sub := cost[i-1][j-1] + 1 - similarity(want[i-1], got[j-1].Word)
+			cost[i][j] = math.Min(sub, math.Min(cost[i-1][j]+1, cost[i][j-1]+1))
+		}
+	}
+
+	// volta do fim ao começo montando o alinhamento
+	var words []WordScore
+	for i, j := n, m; i > 0 || j > 0; {
+		switch {
+		case i > 0 && j > 0 && nearly(cost[i][j], cost[i-1][j-1]+1-similarity(want[i-1], got[j-1].Word)):
+			h := got[j-1]
+			score := int(math.Round(similarity(want[i-1], h.Word) * 100))
+			status := Mispronounced
+			if score >= correctScore {
+				status = Correct
+			}
+			words = append(words, WordScore{Word: want[i-1], Heard: h.Word, Score: score, Status: status, Start: &h.Start, End: &h.End})
+			i, j = i-1, j-1
+		case i > 0 && (j == 0 || nearly(cost[i][j], cost[i-1][j]+1)):
+			words = append(words, WordScore{Word: want[i-1], Status: Missing})
+			i--
+		default:
+			h := got[j-1]
+			words = append(words, WordScore{Heard: h.Word, Status: Extra, Start: &h.Start, End: &h.End})
+			j--
+		}
+	}
+	for l, r := 0, len(words)-1; l < r; l, r = l+1, r-1 {
+		words[l], words[r] = words[r], words[l]
+	}
+
+	result := Result{Words: words}
+	if n == 0 {
+		return result
+	}
+	total, heardWords := 0, 0
+	for _, w := range words {
+		if w.Status == Extra {
+			continue
+		}
+		total += w.Score
+		if w.Status != Missing {
+			heardWords++
+		}
+	}
+	result.Score = int(math.Round(float64(total) / float64(n)))
+	result.Completeness = int(math.Round(float64(heardWords) * 100 / float64(n)))
+	return result
+}
+
+func nearly(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// similarity vai de 0 (nada em comum) a 1 (mesma palavra), ignorando
+// maiúsculas; letra que difere só no acento custa meia troca
+func similarity(a, b string) float64 {
+	x, y := []rune(strings.ToLower(a)), []rune(strings.ToLower(b))
+	if len(x) == 0 && len(y) == 0 {
+		return 1
+	}
+
+	prev := make([]float64, len(y)+1)
+	curr := make([]float64, len(y)+1)
+	for j := range prev {
+		prev[j] = float64(j)
+	}
+	for i := 1; i <= len(x); i++ {
+		curr[0] = float64(i)
+		for j := 1; j <= len(y); j++ {
+			sub := 0.0
+			switch {
+			case x[i-1] == y[j-1]:
+			case base(x[i-1]) == base(y[j-1]):
+				sub = 0.5
+			default:
+				sub = 1
+			}
+			curr[j] = math.Min(prev[j-1]+sub, math.Min(prev[j]+1, curr[j-1]+1))
+		}
+		prev, curr = curr, prev
+	}
+	return 1 - prev[len(y)]/float64(max(len(x), len(y)))
+}
+
+// Letras acentuadas mais comuns nos idiomas do LingoBot
+var accents = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n', 'ß': 's',
+}
+
+func base(r rune) rune {
+	if b, ok := accents[r]; ok {
+		return b
+	}
+	return r
+}
@@ -0,0 +1,123 @@
+package provider
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/internal/env"
+)
+
+// Azure OpenAI: o modelo é escolhido pelo deployment, não pelo corpo.
+// AZURE_OPENAI_ENDPOINT=https://<recurso>.openai.azure.com
+// AZURE_OPENAI_DEPLOYMENT: deployment padrão; Request.Model vira o nome do deployment
+// AZURE_OPENAI_REASONING_DEPLOYMENT: deployment usado com pedido de raciocínio (opcional)
+// AZURE_OPENAI_API_VERSION: padrão 2024-10-21
+func azureURL(in *Request) (string, error) {
+	endpoint := strings.TrimRight(os.Getenv("AZURE_OPENAI_ENDPOINT"), "/")
+	if endpoint == "" {
+		return "", notConfigured("azure", "azure OpenAI endpoint not configured")
+	}
+
+	deployment := os.Getenv("AZURE_OPENAI_DEPLOYMENT")
+	if in.Model != "" {
+		deployment = in.Model
+	}
+	if in.Reasoning && os.Getenv("AZURE_OPENAI_REASONING_DEPLOYMENT") != "" {
+		deployment = os.Getenv("AZURE_OPENAI_REASONING_DEPLOYMENT")
+	}
+	if deployment == "" {
+		return "", notConfigured("azure", "azure OpenAI deployment not configured")
+	}
+
+	return fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+		endpoint, url.PathEscape(deployment), url.QueryEscape(azureAPIVersion())), nil
+}
+
+func azureAPIVersion() string {
+	return env.String("AZURE_OPENAI_API_VERSION", "2024-10-21")
+}
+
+func azurePayload(in *Request) map[string]interface{} {
+	payload := map[string]interface{}{
+		"messages":    chatMessages(in),
+		"max_tokens":  1000,
+		"temperature": 0.7,
+	}
+
+	if in.Reasoning && os.Getenv("AZURE_OPENAI_REASONING_DEPLOYMENT") != "" {
+		// modelos o1/o3 não aceitam temperature nem max_tokens
+		delete(payload, "temperature")
+		delete(payload, "max_tokens")
+		payload["max_completion_tokens"] = 4000
+		capTokens(payload, "max_completion_tokens", in)
+		return payload
+	}
+	capTokens(payload, "max_tokens", in)
+	setStop(payload, "stop", in) // os modelos o1/o3 também não aceitam stop
+	return payload
+}
+
+// CallAzureOpenAI chama o deployment configurado no recurso Azure OpenAI
+func CallAzureOpenAI(in *Request) (*Result, error) {
+	apiKey, err := in.apiKey("azure", "AZURE_OPENAI_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := azureURL(in)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonData, _ := sonic.Marshal(azurePayload(in))
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(endpoint)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.Set("api-key", apiKey)
+	req.Header.SetContentType("application/json")
+	req.SetBody(jsonData)
+
+	if err := in.do("azure", req, resp); err != nil {
+		return nil, networkError("azure", err)
+	}
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, statusError("azure", resp)
+	}
+
+	result, err := parseChatCompletion(resp.Body())
+	if err != nil {
+		return nil, badResponse("azure", err)
+	}
+	return result, nil
+}
+
+// StreamAzureOpenAI faz streaming do deployment Azure
+func StreamAzureOpenAI(in *Request, onChunk func(string) error) (*Result, error) {
+	apiKey, err := in.apiKey("azure", "AZURE_OPENAI_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, err := azureURL(in)
+	if err != nil {
+		return nil, err
+	}
+
+	req := newChatRequest(endpoint, "", azurePayload(in))
+	defer fasthttp.ReleaseRequest(req)
+	req.Header.Del("Authorization")
+	req.Header.Set("api-key", apiKey)
+
+	return streamChatCompletion(req, "azure", onChunk)
+}
@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"sync/atomic"
+)
+
+// ErrBudgetExhausted indica que o turno já fez todas as chamadas permitidas
+var ErrBudgetExhausted = errors.New("upstream call budget exhausted for this turn")
+
+// TURN_CALL_BUDGET: máximo de chamadas aos provedores por turno do aluno,
+// somando retries, fallback, continuação de stream, compressão e sombra.
+// Limita o custo no pior caso das estratégias com vários provedores; 0 desliga.
+var turnCallBudget = loadTurnCallBudget(os.Getenv("TURN_CALL_BUDGET"))
+
+func loadTurnCallBudget(raw string) int {
+	const fallback = 6
+	if raw == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		log.Printf("⚠️  TURN_CALL_BUDGET inválido, usando %d", fallback)
+		return fallback
+	}
+	return n
+}
+
+// Budget conta as chamadas de um turno; as cópias do Request dividem o mesmo
+type Budget struct {
+	max  int64
+	used atomic.Int64
+}
+
+// NewBudget cria o orçamento de um turno com o limite de TURN_CALL_BUDGET
+func NewBudget() *Budget {
+	return &Budget{max: int64(turnCallBudget)}
+}
+
+// Take reserva uma chamada; Budget nil ou limite 0 não restringe
+func (b *Budget) Take() error {
+	if b == nil {
+		return nil
+	}
+	if n := b.used.Add(1); b.max > 0 && n > b.max {
+		b.used.Add(-1)
+		return ErrBudgetExhausted
+	}
+	return nil
+}
+
+// Used devolve quantas chamadas o turno já fez
+func (b *Budget) Used() int {
+	if b == nil {
+		return 0
+	}
+	return int(b.used.Load())
+}
+
+// Refund devolve a chamada reservada que não chegou ao provedor
+func (b *Budget) Refund() {
+	if b != nil {
+		b.used.Add(-1)
+	}
+}
@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"errors"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+)
+
+// CallCohere otimizado
+func CallCohere(in *Request) (*Result, error) {
+	apiKey, err := in.apiKey("cohere", "COHERE_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	url := "https://api.cohere.ai/v1/chat"
+
+	model := "command-r"
+	if in.Model != "" {
+		model = in.Model
+	}
+
+	payload := map[string]interface{}{
+		"message":     in.Text,
+		"model":       model,
+		"temperature": 0.7,
+		"max_tokens":  1000,
+	}
+	capTokens(payload, "max_tokens", in)
+	setStop(payload, "stop_sequences", in)
+	if in.System != "" {
+		payload["preamble"] = in.System
+	}
+
+	if len(in.History) > 0 {
+		roles := map[string]string{"system": "SYSTEM", "user": "USER", "assistant": "CHATBOT"}
+		history := make([]map[string]string, 0, len(in.History))
+		for _, m := range in.History {
+			history = append(history, map[string]string{"role": roles[m.Role], "message": m.Content})
+		}
+		payload["chat_history"] = history
+	}
+
+	jsonData, _ := sonic.Marshal(payload)
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(url)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.SetContentType("application/json")
+	req.SetBody(jsonData)
+
+	if err := in.do("cohere", req, resp); err != nil {
+		return nil, networkError("cohere", err)
+	}
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, statusError("cohere", resp)
+	}
+
+	var result cohereResponse
+	if err := sonic.Unmarshal(resp.Body(), &result); err != nil {
+		return nil, badResponse("cohere", err)
+	}
+	if result.Text == nil {
+		return nil, badResponse("cohere", errors.New("no text in response"))
+	}
+
+	prompt, completion := int(result.Meta.BilledUnits.InputTokens), int(result.Meta.BilledUnits.OutputTokens)
+	return &Result{
+		Text:  *result.Text,
+		Usage: Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion},
+	}, nil
+}
+
+// Resposta do /v1/chat; o uso cobrado vem em meta.billed_units
+type cohereResponse struct {
+	Text *string `json:"text"`
+	Meta struct {
+		BilledUnits struct {
+			InputTokens  float64 `json:"input_tokens"` // documentados como double
+			OutputTokens float64 `json:"output_tokens"`
+		} `json:"billed_units"`
+	} `json:"meta"`
+}
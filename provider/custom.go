@@ -0,0 +1,195 @@
+package provider
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/internal/routes"
+)
+
+// Provedores compatíveis com OpenAI declarados só em configuração (vLLM,
+// LM Studio, um fornecedor novo), em CUSTOM_PROVIDERS ou no arquivo de
+// CUSTOM_PROVIDERS_FILE:
+//
+//	[{"name":"lmstudio","base_url":"http://10.0.0.5:1234/v1","models":["qwen2.5-7b-instruct"]},
+//	 {"name":"fireworks","base_url":"https://api.fireworks.ai/inference/v1","key_env":"FIREWORKS_KEY",
+//	  "models":["accounts/fireworks/models/llama-v3p1-70b-instruct"],"headers":{"X-Title":"Lingobot"},"fallback":true}]
+//
+// Cada um ganha a rota /<name>, por isso o nome não pode ser o de outro
+// provedor nem o de uma rota fixa (ai, health, admin...). Entra em GET
+// /status e /version e tem os modelos aceitos no campo model. O primeiro
+// modelo é o padrão. Sem key_env o provedor conta como configurado e vai sem
+// Authorization; com fallback ele entra no fim do DefaultChain.
+type CustomProvider struct {
+	Name     string            `json:"name"`
+	BaseURL  string            `json:"base_url"`
+	KeyEnv   string            `json:"key_env,omitempty"`
+	Models   []string          `json:"models"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Fallback bool              `json:"fallback,omitempty"`
+}
+
+var customName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+var customProviders = loadCustomProviders()
+
+func init() {
+	for _, c := range customProviders {
+		p := Provider{Name: c.Name, Call: c.call, Stream: c.stream, Key: c.KeyEnv, API: "openai-chat/v1", Custom: true}
+		registry = append(registry, p)
+		if u, err := url.Parse(c.BaseURL); err == nil {
+			warmHosts[c.Name] = u.Scheme + "://" + u.Host + "/"
+		}
+	}
+}
+
+func loadCustomProviders() []CustomProvider {
+	raw, source := os.Getenv("CUSTOM_PROVIDERS"), "CUSTOM_PROVIDERS"
+	if path := os.Getenv("CUSTOM_PROVIDERS_FILE"); path != "" && raw == "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("⚠️  CUSTOM_PROVIDERS_FILE não lido: %v", err)
+			return nil
+		}
+		raw, source = string(data), path
+	}
+	if raw == "" {
+		return nil
+	}
+
+	var list []CustomProvider
+	if err := sonic.UnmarshalString(raw, &list); err != nil {
+		log.Printf("⚠️  %s inválido, nenhum provedor extra registrado: %v", source, err)
+		return nil
+	}
+
+	taken := map[string]bool{Mock.Name: true}
+	for _, p := range registry {
+		taken[p.Name] = true
+	}
+	valid := make([]CustomProvider, 0, len(list))
+	for _, c := range list {
+		if err := c.validate(taken); err != nil {
+			log.Printf("⚠️  Provedor ignorado em %s: %v", source, err)
+			continue
+		}
+		c.BaseURL = strings.TrimRight(c.BaseURL, "/")
+		taken[c.Name] = true
+		valid = append(valid, c)
+		log.Printf("🔌 Provedor %s registrado por configuração (%s)", c.Name, c.BaseURL)
+	}
+	return valid
+}
+
+func (c CustomProvider) validate(taken map[string]bool) error {
+	if !customName.MatchString(c.Name) {
+		return fmt.Errorf("invalid name %q: use lowercase letters, digits and dashes", c.Name)
+	}
+	if taken[c.Name] {
+		return fmt.Errorf("provider %q already exists", c.Name)
+	}
+	if routes.Taken(c.Name) {
+		return fmt.Errorf("name %q collides with the fixed route /%s", c.Name, c.Name)
+	}
+	u, err := url.Parse(c.BaseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s: invalid base_url %q", c.Name, c.BaseURL)
+	}
+	if len(c.Models) == 0 || c.Models[0] == "" {
+		return fmt.Errorf("%s: at least one model is required", c.Name)
+	}
+	return nil
+}
+
+// Custom lista os provedores de CUSTOM_PROVIDERS, na ordem declarada
+func Custom() []CustomProvider {
+	return customProviders
+}
+
+func (c CustomProvider) payload(in *Request) map[string]interface{} {
+	model := c.Models[0]
+	if in.Model != "" {
+		model = in.Model
+	}
+	payload := map[string]interface{}{
+		"model":       model,
+		"messages":    chatMessages(in),
+		"max_tokens":  1000,
+		"temperature": 0.7,
+	}
+	capTokens(payload, "max_tokens", in)
+	setStop(payload, "stop", in)
+	return payload
+}
+
+func (c CustomProvider) key(in *Request) (string, error) {
+	if c.KeyEnv == "" {
+		return "", nil
+	}
+	return in.apiKey(c.Name, c.KeyEnv)
+}
+
+// setHeaders troca o Authorization pelos cabeçalhos do provedor
+func (c CustomProvider) setHeaders(req *fasthttp.Request, apiKey string) {
+	if apiKey == "" {
+		req.Header.Del("Authorization")
+	}
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
+}
+
+func (c CustomProvider) call(in *Request) (*Result, error) {
+	apiKey, err := c.key(in)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonData, _ := sonic.Marshal(c.payload(in))
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(c.BaseURL + "/chat/completions")
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.SetContentType("application/json")
+	c.setHeaders(req, apiKey)
+	req.SetBody(jsonData)
+
+	if err := in.do(c.Name, req, resp); err != nil {
+		return nil, networkError(c.Name, err)
+	}
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, statusError(c.Name, resp)
+	}
+
+	result, err := parseChatCompletion(resp.Body())
+	if err != nil {
+		return nil, badResponse(c.Name, err)
+	}
+	return result, nil
+}
+
+func (c CustomProvider) stream(in *Request, onChunk func(string) error) (*Result, error) {
+	apiKey, err := c.key(in)
+	if err != nil {
+		return nil, err
+	}
+
+	req := newChatRequest(c.BaseURL+"/chat/completions", apiKey, c.payload(in))
+	defer fasthttp.ReleaseRequest(req)
+	c.setHeaders(req, apiKey)
+
+	return streamChatCompletion(req, c.Name, onChunk)
+}
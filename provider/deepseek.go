@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+)
+
+const deepSeekURL = "https://api.deepseek.com/chat/completions"
+
+// deepSeekPayload usa o deepseek-reasoner (R1) quando há pedido de raciocínio
+func deepSeekPayload(in *Request) map[string]interface{} {
+	payload := map[string]interface{}{
+		"model":       "deepseek-chat",
+		"messages":    chatMessages(in),
+		"temperature": 0.7,
+	}
+
+	if in.Model != "" {
+		payload["model"] = in.Model
+	}
+
+	if in.Reasoning {
+		// o reasoner ignora temperature
+		payload["model"] = deepSeekReasoningModel
+		delete(payload, "temperature")
+	}
+	capTokens(payload, "max_tokens", in)
+	setStop(payload, "stop", in)
+	return payload
+}
+
+// CallDeepSeek chama a API oficial da DeepSeek (formato OpenAI)
+func CallDeepSeek(in *Request) (*Result, error) {
+	apiKey, err := in.apiKey("deepseek", "DEEPSEEK_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	jsonData, _ := sonic.Marshal(deepSeekPayload(in))
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(deepSeekURL)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.SetContentType("application/json")
+	req.SetBody(jsonData)
+
+	if err := in.do("deepseek", req, resp); err != nil {
+		return nil, networkError("deepseek", err)
+	}
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, statusError("deepseek", resp)
+	}
+
+	result, err := parseChatCompletion(resp.Body())
+	if err != nil {
+		return nil, badResponse("deepseek", err)
+	}
+	return result, nil
+}
+
+// StreamDeepSeek faz streaming do chat/completions da DeepSeek
+func StreamDeepSeek(in *Request, onChunk func(string) error) (*Result, error) {
+	apiKey, err := in.apiKey("deepseek", "DEEPSEEK_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	req := newChatRequest(deepSeekURL, apiKey, deepSeekPayload(in))
+	defer fasthttp.ReleaseRequest(req)
+
+	return streamChatCompletion(req, "deepseek", onChunk)
+}
@@ -0,0 +1,221 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+)
+
+// Embedder gera os vetores da busca nos documentos. Vetores de embedders
+// diferentes não se comparam, então o documento guarda o Name de quem o indexou.
+type Embedder struct {
+	Name  string // "gemini:text-embedding-004", "mistral:mistral-embed", "local:hash-512"
+	Embed func(texts []string) ([][]float32, error)
+}
+
+// EMBEDDINGS_PROVIDER: gemini, mistral ou local. Sem ele, o primeiro com API
+// key configurada; local (hash das palavras, sem rede) no MOCK_MODE ou sem chaves.
+func DefaultEmbedder() Embedder {
+	name := os.Getenv("EMBEDDINGS_PROVIDER")
+	switch {
+	case name != "":
+	case MockMode():
+		name = "local"
+	case os.Getenv(keyEnv["gemini"]) != "":
+		name = "gemini"
+	case os.Getenv(keyEnv["mistral"]) != "":
+		name = "mistral"
+	default:
+		name = "local"
+	}
+
+	for _, e := range embedders {
+		if strings.HasPrefix(e.Name, name+":") {
+			return e
+		}
+	}
+	log.Printf("⚠️  EMBEDDINGS_PROVIDER desconhecido %q, usando local", name)
+	return localEmbedder
+}
+
+// EmbedderByName devolve o embedder que indexou um documento
+func EmbedderByName(name string) (Embedder, bool) {
+	for _, e := range embedders {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return Embedder{}, false
+}
+
+var (
+	geminiEmbedder  = Embedder{Name: "gemini:text-embedding-004", Embed: embedGemini}
+	mistralEmbedder = Embedder{Name: "mistral:mistral-embed", Embed: embedMistral}
+	localEmbedder   = Embedder{Name: "local:hash-512", Embed: embedLocal}
+
+	embedders = []Embedder{geminiEmbedder, mistralEmbedder, localEmbedder}
+)
+
+// Lote máximo por chamada nas duas APIs
+const embedBatch = 100
+
+func inBatches(texts []string, embed func([]string) ([][]float32, error)) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += embedBatch {
+		batch, err := embed(texts[start:min(start+embedBatch, len(texts))])
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+func postJSON(name, url string, headers map[string]string, payload interface{}, out interface{}) error {
+	jsonData, _ := sonic.Marshal(payload)
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(url)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.SetContentType("application/json")
+	req.SetBody(jsonData)
+
+	if err := do(name, "", req, resp); err != nil {
+		return networkError(name, err)
+	}
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return statusError(name, resp)
+	}
+	if err := sonic.Unmarshal(resp.Body(), out); err != nil {
+		return badResponse(name, err)
+	}
+	return nil
+}
+
+func embedGemini(texts []string) ([][]float32, error) {
+	return inBatches(texts, func(batch []string) ([][]float32, error) {
+		key, err := pickKey("gemini", keyEnv["gemini"])
+		if err != nil {
+			return nil, err
+		}
+
+		requests := make([]map[string]interface{}, len(batch))
+		for i, t := range batch {
+			requests[i] = map[string]interface{}{
+				"model":   "models/text-embedding-004",
+				"content": map[string]interface{}{"parts": []map[string]string{{"text": t}}},
+			}
+		}
+
+		var out struct {
+			Embeddings []struct {
+				Values []float32 `json:"values"`
+			} `json:"embeddings"`
+		}
+		url := "https://generativelanguage.googleapis.com/v1beta/models/text-embedding-004:batchEmbedContents?key=" + key.value
+		err = postJSON("gemini", url, nil, map[string]interface{}{"requests": requests}, &out)
+		key.report(err)
+		if err != nil {
+			return nil, err
+		}
+		if len(out.Embeddings) != len(batch) {
+			return nil, badResponse("gemini", fmt.Errorf("got %d embeddings for %d texts", len(out.Embeddings), len(batch)))
+		}
+
+		vectors := make([][]float32, len(batch))
+		for i, e := range out.Embeddings {
+			vectors[i] = e.Values
+		}
+		return vectors, nil
+	})
+}
+
+func embedMistral(texts []string) ([][]float32, error) {
+	return inBatches(texts, func(batch []string) ([][]float32, error) {
+		key, err := pickKey("mistral", keyEnv["mistral"])
+		if err != nil {
+			return nil, err
+		}
+
+		var out struct {
+			Data []struct {
+				Index     int       `json:"index"`
+				Embedding []float32 `json:"embedding"`
+			} `json:"data"`
+		}
+		payload := map[string]interface{}{"model": "mistral-embed", "input": batch}
+		headers := map[string]string{"Authorization": "Bearer " + key.value}
+		err = postJSON("mistral", "https://api.mistral.ai/v1/embeddings", headers, payload, &out)
+		key.report(err)
+		if err != nil {
+			return nil, err
+		}
+
+		vectors := make([][]float32, len(batch))
+		for _, d := range out.Data {
+			if d.Index < 0 || d.Index >= len(batch) {
+				return nil, badResponse("mistral", errors.New("embedding index out of range"))
+			}
+			vectors[d.Index] = d.Embedding
+		}
+		for _, v := range vectors {
+			if v == nil {
+				return nil, badResponse("mistral", errors.New("missing embeddings in response"))
+			}
+		}
+		return vectors, nil
+	})
+}
+
+// embedLocal espalha palavras e pares de palavras em 512 posições pelo hash:
+// acha trechos com as mesmas palavras da pergunta, sem sinônimos nem outros idiomas
+func embedLocal(texts []string) ([][]float32, error) {
+	const dims = 512
+
+	vectors := make([][]float32, len(texts))
+	for i, t := range texts {
+		v := make([]float32, dims)
+		words := strings.FieldsFunc(strings.ToLower(t), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		add := func(term string) {
+			h := fnv.New32a()
+			h.Write([]byte(term))
+			v[h.Sum32()%dims]++
+		}
+		for j, w := range words {
+			add(w)
+			if j > 0 {
+				add(words[j-1] + " " + w)
+			}
+		}
+
+		var norm float64
+		for _, x := range v {
+			norm += float64(x * x)
+		}
+		if norm > 0 {
+			scale := float32(1 / math.Sqrt(norm))
+			for j := range v {
+				v[j] *= scale
+			}
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
@@ -0,0 +1,186 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/internal/truncate"
+)
+
+// Códigos estáveis das falhas de provedor, repassados ao cliente no envelope de erro
+const (
+	CodeNotConfigured = "provider_not_configured"
+	CodeAuthFailed    = "upstream_auth_failed"
+	CodeRateLimited   = "upstream_rate_limited"
+	CodeQuotaExceeded = "upstream_quota_exceeded"
+	CodeTimeout       = "upstream_timeout"
+	CodeUnavailable   = "upstream_unavailable"
+	CodeBadRequest    = "upstream_bad_request"
+	CodeContextLength = "context_length_exceeded"
+	CodeModelNotFound = "upstream_model_not_found"
+	CodeBadResponse   = "upstream_bad_response"
+	CodeUpstream      = "upstream_error"
+)
+
+// UpstreamError é a falha de um provedor já classificada. Detail guarda o que
+// o provedor respondeu e vai só para o log; o cliente recebe o código.
+type UpstreamError struct {
+	Provider   string
+	Status     int // status HTTP do provedor; 0 quando a falha foi de rede
+	Code       string
+	Retryable  bool
+	RetryAfter time.Duration // do header Retry-After, quando o provedor manda
+	Detail     string
+
+	err error
+}
+
+func (e *UpstreamError) Error() string {
+	msg := e.Provider + ": " + e.Code
+	if e.Status != 0 {
+		msg += " (status " + strconv.Itoa(e.Status) + ")"
+	}
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	return msg
+}
+
+func (e *UpstreamError) Unwrap() error { return e.err }
+
+// notConfigured é a falta de chave ou endpoint no ambiente
+func notConfigured(name, detail string) *UpstreamError {
+	return &UpstreamError{Provider: name, Code: CodeNotConfigured, Detail: detail}
+}
+
+// networkError classifica a falha antes de qualquer resposta do provedor
+func networkError(name string, err error) *UpstreamError {
+	code := CodeUnavailable
+	if errors.Is(err, fasthttp.ErrTimeout) || errors.Is(err, fasthttp.ErrDialTimeout) || errors.Is(err, os.ErrDeadlineExceeded) {
+		code = CodeTimeout
+	}
+	return &UpstreamError{Provider: name, Code: code, Retryable: true, Detail: err.Error(), err: err}
+}
+
+// badResponse é a resposta 200 que não deu para interpretar
+func badResponse(name string, err error) *UpstreamError {
+	return &UpstreamError{Provider: name, Code: CodeBadResponse, Retryable: true, Detail: err.Error(), err: err}
+}
+
+// Formatos de erro dos provedores: OpenAI, Groq, Mistral, Together, DeepSeek e
+// Azure usam {"error":{"message","type","code"}}; o Gemini acrescenta "status";
+// Cohere manda {"message"} e o HuggingFace {"error":"..."}
+type upstreamBody struct {
+	Message string      `json:"message"`
+	Error   interface{} `json:"error"`
+}
+
+func upstreamDetail(body []byte) string {
+	var parsed upstreamBody
+	if sonic.Unmarshal(body, &parsed) != nil {
+		return truncateDetail(string(body))
+	}
+
+	parts := []string{}
+	switch e := parsed.Error.(type) {
+	case string:
+		parts = append(parts, e)
+	case map[string]interface{}:
+		for _, key := range []string{"status", "type", "code", "message"} {
+			if v, ok := e[key]; ok && v != nil && fmt.Sprint(v) != "" {
+				parts = append(parts, fmt.Sprint(v))
+			}
+		}
+	}
+	if parsed.Message != "" {
+		parts = append(parts, parsed.Message)
+	}
+	if len(parts) == 0 {
+		return truncateDetail(string(body))
+	}
+	return truncateDetail(strings.Join(parts, ": "))
+}
+
+func truncateDetail(s string) string {
+	return truncate.Bytes(strings.TrimSpace(s), 300)
+}
+
+// Trechos das mensagens dos provedores que mudam a classificação do status
+var (
+	contextLengthHints = []string{"context_length_exceeded", "maximum context length", "context window", "too many tokens", "input is too long", "exceeds the maximum"}
+	quotaHints         = []string{"insufficient_quota", "billing", "credit balance", "out of credits"}
+	authHints          = []string{"api key not valid", "invalid api key", "invalid_api_key", "unauthorized"}
+)
+
+func hasHint(detail string, hints []string) bool {
+	lower := strings.ToLower(detail)
+	for _, h := range hints {
+		if strings.Contains(lower, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// statusError classifica a resposta de erro do provedor pelo status e pelo corpo
+func statusError(name string, resp *fasthttp.Response) *UpstreamError {
+	e := classifyStatus(name, resp.StatusCode(), resp.Body())
+	if seconds, err := strconv.Atoi(string(resp.Header.Peek("Retry-After"))); err == nil && seconds > 0 {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return e
+}
+
+func classifyStatus(name string, status int, body []byte) *UpstreamError {
+	e := &UpstreamError{Provider: name, Status: status, Detail: upstreamDetail(body)}
+
+	switch {
+	case status == fasthttp.StatusUnauthorized, status == fasthttp.StatusForbidden:
+		e.Code = CodeAuthFailed
+	case status == fasthttp.StatusPaymentRequired:
+		e.Code = CodeQuotaExceeded
+	case status == fasthttp.StatusTooManyRequests && hasHint(e.Detail, quotaHints):
+		// cota mensal estourada não volta esperando alguns segundos
+		e.Code = CodeQuotaExceeded
+	case status == fasthttp.StatusTooManyRequests:
+		e.Code, e.Retryable = CodeRateLimited, true
+	case status == fasthttp.StatusNotFound:
+		e.Code = CodeModelNotFound
+	case status == fasthttp.StatusRequestTimeout, status == fasthttp.StatusGatewayTimeout, status == 524:
+		e.Code, e.Retryable = CodeTimeout, true
+	case status >= 500:
+		e.Code, e.Retryable = CodeUnavailable, true
+	case hasHint(e.Detail, contextLengthHints):
+		e.Code = CodeContextLength
+	case hasHint(e.Detail, authHints):
+		// o Gemini responde 400 para chave inválida
+		e.Code = CodeAuthFailed
+	case status >= 400:
+		e.Code = CodeBadRequest
+	default:
+		e.Code = CodeUpstream
+	}
+	return e
+}
+
+// Classify marca como falha do provedor o erro que ainda não foi classificado,
+// como uma resposta 200 que não deu para interpretar
+func Classify(name string, err error) error {
+	var upstream *UpstreamError
+	switch {
+	case err == nil, errors.As(err, &upstream):
+		return err
+	case errors.Is(err, ErrSafetyBlocked), errors.Is(err, ErrBudgetExhausted):
+		return err
+	case errors.Is(err, ErrStreamStalled):
+		return &UpstreamError{Provider: name, Code: CodeTimeout, Retryable: true, Detail: err.Error(), err: err}
+	}
+	return &UpstreamError{Provider: name, Code: CodeUpstream, Detail: err.Error(), err: err}
+}
@@ -0,0 +1,399 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+)
+
+// Opções próprias do Gemini repassadas no pedido (campo "gemini" do /ai e /gemini)
+type GeminiOptions struct {
+	SafetySettings   []GeminiSafetySetting   `json:"safetySettings,omitempty"`
+	GenerationConfig *GeminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type GeminiSafetySetting struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
+}
+
+// Ponteiros distinguem "não informado" de zero
+type GeminiGenerationConfig struct {
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopK            *int     `json:"topK,omitempty"`
+	TopP            *float64 `json:"topP,omitempty"`
+	StopSequences   []string `json:"stopSequences,omitempty"`
+	MaxOutputTokens *int     `json:"maxOutputTokens,omitempty"`
+}
+
+// Máximo de stopSequences que o Gemini aceita
+const geminiMaxStops = 5
+
+// ErrSafetyBlocked indica que o Gemini recusou o prompt ou a resposta pelos filtros de segurança
+var ErrSafetyBlocked = errors.New("response blocked by provider safety filters")
+
+var geminiCategories = map[string]bool{
+	"HARM_CATEGORY_HARASSMENT":        true,
+	"HARM_CATEGORY_HATE_SPEECH":       true,
+	"HARM_CATEGORY_SEXUALLY_EXPLICIT": true,
+	"HARM_CATEGORY_DANGEROUS_CONTENT": true,
+	"HARM_CATEGORY_CIVIC_INTEGRITY":   true,
+}
+
+// Limiares do mais permissivo ao mais restrito
+var geminiThresholds = map[string]int{
+	"OFF":                    0,
+	"BLOCK_NONE":             1,
+	"BLOCK_ONLY_HIGH":        2,
+	"BLOCK_MEDIUM_AND_ABOVE": 3,
+	"BLOCK_LOW_AND_ABOVE":    4,
+}
+
+// GEMINI_SAFETY_SETTINGS e GEMINI_GENERATION_CONFIG: padrões da instância, no
+// formato da API. Os limiares daqui são o piso: o pedido só pode apertá-los.
+var geminiDefaults = loadGeminiDefaults()
+
+func loadGeminiDefaults() GeminiOptions {
+	var opts GeminiOptions
+	if raw := os.Getenv("GEMINI_SAFETY_SETTINGS"); raw != "" {
+		if err := sonic.UnmarshalString(raw, &opts.SafetySettings); err != nil {
+			log.Printf("⚠️  GEMINI_SAFETY_SETTINGS inválido, ignorado: %v", err)
+			opts.SafetySettings = nil
+		}
+	}
+	if raw := os.Getenv("GEMINI_GENERATION_CONFIG"); raw != "" {
+		if err := sonic.UnmarshalString(raw, &opts.GenerationConfig); err != nil {
+			log.Printf("⚠️  GEMINI_GENERATION_CONFIG inválido, ignorado: %v", err)
+			opts.GenerationConfig = nil
+		}
+	}
+	if err := opts.Validate(); err != nil {
+		log.Printf("⚠️  Padrões do Gemini inválidos, ignorados: %v", err)
+		return GeminiOptions{}
+	}
+	return opts
+}
+
+// Validate confere as opções antes de chegarem à API
+func (o *GeminiOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+	for _, s := range o.SafetySettings {
+		if !geminiCategories[s.Category] {
+			return fmt.Errorf("invalid gemini safety category %q", s.Category)
+		}
+		if _, ok := geminiThresholds[s.Threshold]; !ok {
+			return fmt.Errorf("invalid gemini safety threshold %q", s.Threshold)
+		}
+	}
+
+	c := o.GenerationConfig
+	if c == nil {
+		return nil
+	}
+	switch {
+	case c.Temperature != nil && (*c.Temperature < 0 || *c.Temperature > 2):
+		return errors.New("gemini temperature must be between 0 and 2")
+	case c.TopK != nil && *c.TopK < 1:
+		return errors.New("gemini topK must be positive")
+	case c.TopP != nil && (*c.TopP < 0 || *c.TopP > 1):
+		return errors.New("gemini topP must be between 0 and 1")
+	case len(c.StopSequences) > geminiMaxStops:
+		return fmt.Errorf("gemini accepts at most %d stopSequences", geminiMaxStops)
+	case c.MaxOutputTokens != nil && *c.MaxOutputTokens < 1:
+		return errors.New("gemini maxOutputTokens must be positive")
+	}
+	return nil
+}
+
+// geminiSafety junta os padrões e o pedido, ficando com o limiar mais restrito
+func geminiSafety(requested []GeminiSafetySetting) []GeminiSafetySetting {
+	merged := append([]GeminiSafetySetting(nil), geminiDefaults.SafetySettings...)
+	for _, r := range requested {
+		found := false
+		for i, d := range merged {
+			if d.Category != r.Category {
+				continue
+			}
+			found = true
+			if geminiThresholds[r.Threshold] > geminiThresholds[d.Threshold] {
+				merged[i].Threshold = r.Threshold
+			}
+		}
+		if !found {
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
+
+// geminiGeneration aplica os campos do pedido sobre os padrões
+func geminiGeneration(requested *GeminiGenerationConfig) *GeminiGenerationConfig {
+	if geminiDefaults.GenerationConfig == nil {
+		return requested
+	}
+	merged := *geminiDefaults.GenerationConfig
+	if requested == nil {
+		return &merged
+	}
+	if requested.Temperature != nil {
+		merged.Temperature = requested.Temperature
+	}
+	if requested.TopK != nil {
+		merged.TopK = requested.TopK
+	}
+	if requested.TopP != nil {
+		merged.TopP = requested.TopP
+	}
+	if requested.StopSequences != nil {
+		merged.StopSequences = requested.StopSequences
+	}
+	if requested.MaxOutputTokens != nil {
+		merged.MaxOutputTokens = requested.MaxOutputTokens
+	}
+	return &merged
+}
+
+func geminiModel(in *Request) string {
+	if in.Model != "" {
+		return in.Model
+	}
+	return "gemini-2.0-flash"
+}
+
+// geminiPayload converte o histórico: Gemini só conhece os papéis user e model
+func geminiPayload(in *Request) map[string]interface{} {
+	contents := make([]map[string]interface{}, 0, len(in.History)+1)
+	for _, m := range in.History {
+		role := "user"
+		if m.Role == "assistant" {
+			role = "model"
+		}
+		contents = append(contents, map[string]interface{}{
+			"role":  role,
+			"parts": []map[string]string{{"text": m.Content}},
+		})
+	}
+	contents = append(contents, map[string]interface{}{
+		"role":  "user",
+		"parts": []map[string]string{{"text": in.Text}},
+	})
+
+	payload := map[string]interface{}{
+		"contents": contents,
+	}
+	if in.System != "" {
+		payload["systemInstruction"] = map[string]interface{}{
+			"parts": []map[string]string{{"text": in.System}},
+		}
+	}
+
+	var requested GeminiOptions
+	if in.Gemini != nil {
+		requested = *in.Gemini
+	}
+	if safety := geminiSafety(requested.SafetySettings); len(safety) > 0 {
+		payload["safetySettings"] = safety
+	}
+	generation := geminiGeneration(requested.GenerationConfig)
+	if in.MaxTokens > 0 && (generation == nil || generation.MaxOutputTokens == nil || *generation.MaxOutputTokens > in.MaxTokens) {
+		capped := GeminiGenerationConfig{}
+		if generation != nil {
+			capped = *generation
+		}
+		capped.MaxOutputTokens = &in.MaxTokens
+		generation = &capped
+	}
+	if len(in.Stop) > 0 {
+		stopped := GeminiGenerationConfig{}
+		if generation != nil {
+			stopped = *generation
+		}
+		stopped.StopSequences = mergeStops(stopped.StopSequences, in.Stop)
+		generation = &stopped
+	}
+	if generation != nil {
+		payload["generationConfig"] = generation
+	}
+	return payload
+}
+
+// mergeStops junta às stopSequences do gemini as do stop, sem repetir e até o
+// limite do Gemini. As do gemini vêm primeiro porque só o Gemini as aplica; as
+// do stop que não couberem ainda são cortadas por CutAtStop e pelo filtro do
+// streaming.
+func mergeStops(requested, stop []string) []string {
+	merged := make([]string, 0, geminiMaxStops)
+	for _, s := range append(slices.Clone(requested), stop...) {
+		if len(merged) == geminiMaxStops {
+			break
+		}
+		if !slices.Contains(merged, s) {
+			merged = append(merged, s)
+		}
+	}
+	return merged
+}
+
+// Motivos de bloqueio que são dos filtros de segurança, não falha do provedor
+var geminiSafetyReasons = map[string]bool{
+	"SAFETY":             true,
+	"PROHIBITED_CONTENT": true,
+	"BLOCKLIST":          true,
+	"SPII":               true,
+}
+
+// CallGemini otimizado
+func CallGemini(in *Request) (*Result, error) {
+	apiKey, err := in.apiKey("gemini", "GOOGLE_GEMINI_API_KEY1")
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", geminiModel(in), apiKey)
+
+	jsonData, err := sonic.Marshal(geminiPayload(in))
+	if err != nil {
+		return nil, err
+	}
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(url)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.SetContentType("application/json")
+	req.SetBody(jsonData)
+
+	if err := in.do("gemini", req, resp); err != nil {
+		return nil, networkError("gemini", err)
+	}
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, statusError("gemini", resp)
+	}
+
+	var result geminiChunk
+	if err := sonic.Unmarshal(resp.Body(), &result); err != nil {
+		return nil, badResponse("gemini", err)
+	}
+	if err := result.blocked(); err != nil {
+		return nil, err
+	}
+
+	if len(result.Candidates) == 0 || len(result.Candidates[0].Content.Parts) == 0 {
+		return nil, badResponse("gemini", errors.New("no candidates in response"))
+	}
+
+	var text strings.Builder
+	for _, part := range result.Candidates[0].Content.Parts {
+		text.WriteString(part.Text)
+	}
+
+	usage := Usage{
+		PromptTokens:     result.UsageMetadata.PromptTokenCount,
+		CompletionTokens: result.UsageMetadata.CandidatesTokenCount,
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+
+	return &Result{Text: text.String(), Usage: usage}, nil
+}
+
+// Resposta do generateContent, e cada pedaço do streamGenerateContent
+type geminiChunk struct {
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"content"`
+		FinishReason string `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+// blocked devolve ErrSafetyBlocked quando o prompt ou a resposta caiu nos filtros
+func (c *geminiChunk) blocked() error {
+	if reason := c.PromptFeedback.BlockReason; reason != "" {
+		return fmt.Errorf("%w (prompt: %s)", ErrSafetyBlocked, reason)
+	}
+	if len(c.Candidates) > 0 && geminiSafetyReasons[c.Candidates[0].FinishReason] {
+		return fmt.Errorf("%w (%s)", ErrSafetyBlocked, c.Candidates[0].FinishReason)
+	}
+	return nil
+}
+
+// StreamGemini usa o streamGenerateContent em modo SSE
+func StreamGemini(in *Request, onChunk func(string) error) (*Result, error) {
+	apiKey, err := in.apiKey("gemini", "GOOGLE_GEMINI_API_KEY1")
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:streamGenerateContent?alt=sse&key=%s", geminiModel(in), apiKey)
+
+	jsonData, err := sonic.Marshal(geminiPayload(in))
+	if err != nil {
+		return nil, err
+	}
+
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+
+	req.SetRequestURI(url)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.SetContentType("application/json")
+	req.SetBody(jsonData)
+
+	var text strings.Builder
+	var usage Usage
+
+	err = doStream(req, "gemini", func(data []byte) error {
+		var chunk geminiChunk
+		if err := sonic.Unmarshal(data, &chunk); err != nil {
+			return err
+		}
+
+		usage.PromptTokens = chunk.UsageMetadata.PromptTokenCount
+		usage.CompletionTokens = chunk.UsageMetadata.CandidatesTokenCount
+
+		if err := chunk.blocked(); err != nil {
+			return err
+		}
+		if len(chunk.Candidates) == 0 {
+			return nil
+		}
+
+		for _, part := range chunk.Candidates[0].Content.Parts {
+			if part.Text == "" {
+				continue
+			}
+			text.WriteString(part.Text)
+			if err := onChunk(part.Text); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	return &Result{Text: text.String(), Usage: usage}, nil
+}
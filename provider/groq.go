@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+)
+
+const groqURL = "https://api.groq.com/openai/v1/chat/completions"
+
+// groqPayload usa DeepSeek-R1 quando há pedido de raciocínio
+func groqPayload(in *Request) map[string]interface{} {
+	payload := map[string]interface{}{
+		"model":       "meta-llama/llama-4-scout-17b-16e-instruct",
+		"messages":    chatMessages(in),
+		"temperature": 0.7,
+	}
+
+	if in.Model != "" {
+		payload["model"] = in.Model
+	}
+
+	if in.Reasoning {
+		payload["model"] = groqReasoningModel
+		payload["reasoning_format"] = "parsed"
+		payload["temperature"] = 0.6
+	}
+	capTokens(payload, "max_tokens", in)
+	setStop(payload, "stop", in)
+	return payload
+}
+
+// CallGroq otimizado, com DeepSeek-R1 quando há pedido de raciocínio
+func CallGroq(in *Request) (*Result, error) {
+	apiKey, err := in.apiKey("groq", "GROQ_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	jsonData, _ := sonic.Marshal(groqPayload(in))
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(groqURL)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.SetContentType("application/json")
+	req.SetBody(jsonData)
+
+	if err := in.do("groq", req, resp); err != nil {
+		return nil, networkError("groq", err)
+	}
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, statusError("groq", resp)
+	}
+
+	result, err := parseChatCompletion(resp.Body())
+	if err != nil {
+		return nil, badResponse("groq", err)
+	}
+	return result, nil
+}
+
+// StreamGroq faz streaming do chat/completions da Groq
+func StreamGroq(in *Request, onChunk func(string) error) (*Result, error) {
+	apiKey, err := in.apiKey("groq", "GROQ_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	req := newChatRequest(groqURL, apiKey, groqPayload(in))
+	defer fasthttp.ReleaseRequest(req)
+
+	return streamChatCompletion(req, "groq", onChunk)
+}
@@ -0,0 +1,195 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/internal/env"
+)
+
+const huggingFaceURL = "https://api-inference.huggingface.co/models/"
+
+var (
+	// HF_MODEL: modelo padrão; Request.Model (ROUTING_RULES, experimentos) tem prioridade
+	huggingFaceModel = env.String("HF_MODEL", "mistralai/Mistral-7B-Instruct-v0.3")
+
+	// HF_TASK=translation manda só o texto, para modelos de tradução (opus-mt, nllb...)
+	huggingFaceTranslation = os.Getenv("HF_TASK") == "translation"
+
+	// HF_MAX_WAIT: espera total pelo carregamento do modelo (503) antes de desistir
+	huggingFaceMaxWait = env.PositiveDuration("HF_MAX_WAIT", 60*time.Second)
+)
+
+// Resposta de erro da Inference API; estimated_time vem no 503 de carregamento
+type huggingFaceError struct {
+	Error         string  `json:"error"`
+	EstimatedTime float64 `json:"estimated_time"`
+}
+
+// Saída de text-generation, translation ou summarization
+type huggingFaceOutput struct {
+	GeneratedText   string `json:"generated_text"`
+	TranslationText string `json:"translation_text"`
+	SummaryText     string `json:"summary_text"`
+}
+
+// huggingFacePrompt achata histórico e texto em um prompt de texto simples
+func huggingFacePrompt(in *Request) string {
+	if huggingFaceTranslation {
+		return in.Text
+	}
+
+	var b strings.Builder
+	if in.System != "" {
+		b.WriteString(in.System + "\n\n")
+	}
+	for _, m := range in.History {
+		switch m.Role {
+		case "system":
+			b.WriteString(m.Content + "\n\n")
+		case "assistant":
+			b.WriteString("Assistant: " + m.Content + "\n")
+		default:
+			b.WriteString("User: " + m.Content + "\n")
+		}
+	}
+	b.WriteString("User: " + in.Text + "\nAssistant:")
+	return b.String()
+}
+
+func huggingFacePayload(in *Request) map[string]interface{} {
+	payload := map[string]interface{}{
+		"inputs":  huggingFacePrompt(in),
+		"options": map[string]interface{}{"wait_for_model": false},
+	}
+	if !huggingFaceTranslation {
+		parameters := map[string]interface{}{
+			"max_new_tokens":   1000,
+			"temperature":      0.7,
+			"return_full_text": false,
+		}
+		capTokens(parameters, "max_new_tokens", in)
+		setStop(parameters, "stop", in)
+		payload["parameters"] = parameters
+	}
+	return payload
+}
+
+// CallHuggingFace chama a Inference API, esperando o modelo carregar quando preciso
+func CallHuggingFace(in *Request) (*Result, error) {
+	apiKey, err := in.apiKey("huggingface", "HF_TOKEN")
+	if err != nil {
+		return nil, err
+	}
+
+	model := huggingFaceModel
+	if in.Model != "" {
+		model = in.Model
+	}
+
+	jsonData, _ := sonic.Marshal(huggingFacePayload(in))
+	deadline := time.Now().Add(huggingFaceMaxWait)
+
+	for {
+		req := fasthttp.AcquireRequest()
+		resp := fasthttp.AcquireResponse()
+
+		req.SetRequestURI(huggingFaceURL + model)
+		req.Header.SetMethod(fasthttp.MethodPost)
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		req.Header.SetContentType("application/json")
+		req.SetBody(jsonData)
+
+		err := in.do("huggingface", req, resp)
+		status := resp.StatusCode()
+		body := append([]byte(nil), resp.Body()...)
+		fasthttp.ReleaseRequest(req)
+		fasthttp.ReleaseResponse(resp)
+
+		if err != nil {
+			return nil, networkError("huggingface", err)
+		}
+
+		if status == fasthttp.StatusOK {
+			result, err := parseHuggingFace(body, in)
+			if err != nil {
+				return nil, badResponse("huggingface", err)
+			}
+			return result, nil
+		}
+
+		if status != fasthttp.StatusServiceUnavailable {
+			return nil, classifyStatus("huggingface", status, body)
+		}
+
+		// modelo frio: a API informa quanto falta para carregar
+		var loading huggingFaceError
+		sonic.Unmarshal(body, &loading)
+
+		wait := time.Duration(loading.EstimatedTime * float64(time.Second))
+		if wait <= 0 {
+			wait = 5 * time.Second
+		}
+		wait = min(wait, 20*time.Second)
+
+		stillLoading := &UpstreamError{
+			Provider:   "huggingface",
+			Status:     status,
+			Code:       CodeUnavailable,
+			Retryable:  true,
+			RetryAfter: wait,
+			Detail:     "model " + model + " is still loading",
+		}
+		if time.Now().Add(wait).After(deadline) {
+			return nil, stillLoading
+		}
+
+		// cada nova tentativa conta no orçamento do turno
+		if err := in.Budget.Take(); err != nil {
+			return nil, fmt.Errorf("%w (last error: %w)", err, stillLoading)
+		}
+
+		log.Printf("⏳ HuggingFace carregando %s, nova tentativa em %s", model, wait.Round(time.Second))
+		time.Sleep(wait)
+	}
+}
+
+func parseHuggingFace(body []byte, in *Request) (*Result, error) {
+	var outputs []huggingFaceOutput
+	if err := sonic.Unmarshal(body, &outputs); err != nil {
+		return nil, err
+	}
+	if len(outputs) == 0 {
+		return nil, errors.New("no outputs in response")
+	}
+
+	out := outputs[0]
+	text := out.GeneratedText
+	if out.TranslationText != "" {
+		text = out.TranslationText
+	}
+	if out.SummaryText != "" {
+		text = out.SummaryText
+	}
+	text = strings.TrimSpace(text)
+
+	// a Inference API não informa uso de tokens
+	prompt := in.PromptTokens()
+	completion := EstimateTokens(text)
+
+	return &Result{
+		Text: text,
+		Usage: Usage{
+			PromptTokens:     prompt,
+			CompletionTokens: completion,
+			TotalTokens:      prompt + completion,
+		},
+	}, nil
+}
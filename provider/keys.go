@@ -0,0 +1,238 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+// Cada provedor aceita várias chaves: GROQ_KEY, GROQ_KEY_2 ... GROQ_KEY_9, ou
+// GOOGLE_GEMINI_API_KEY1 ... GOOGLE_GEMINI_API_KEY9 quando a primeira termina em 1.
+// A chave que estoura a cota sai de uso até a virada da janela do provedor e
+// volta sozinha, sem reiniciar o processo.
+const maxKeys = 9
+
+// Janela de cota: daily vira à meia-noite, monthly no dia 1, no fuso indicado
+type quotaWindow struct {
+	Window   string `json:"window"`
+	Timezone string `json:"timezone"`
+
+	loc *time.Location
+}
+
+// QUOTA_RESET='{"gemini":{"window":"daily","timezone":"America/Los_Angeles"},"MISTRAL_KEY_2":{"window":"monthly"},"*":{...}}'
+// Vale a entrada da variável da chave, depois a do provedor, depois "*".
+// Sem configuração, o Gemini vira à meia-noite do Pacífico e os demais à meia-noite UTC.
+var quotaWindows = loadQuotaWindows(os.Getenv("QUOTA_RESET"))
+
+func loadQuotaWindows(raw string) map[string]quotaWindow {
+	windows := map[string]quotaWindow{
+		"gemini": {Window: "daily", Timezone: "America/Los_Angeles"},
+		"*":      {Window: "daily", Timezone: "UTC"},
+	}
+	if raw != "" {
+		var parsed map[string]quotaWindow
+		if err := sonic.UnmarshalString(raw, &parsed); err != nil {
+			log.Printf("⚠️  QUOTA_RESET inválido: %v", err)
+		}
+		for name, w := range parsed {
+			windows[name] = w
+		}
+	}
+
+	for name, w := range windows {
+		if w.Window == "" {
+			w.Window = "daily"
+		}
+		if w.Window != "daily" && w.Window != "monthly" {
+			log.Printf("⚠️  Janela %q inválida para %s em QUOTA_RESET, usando daily", w.Window, name)
+			w.Window = "daily"
+		}
+		loc, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			log.Printf("⚠️  Fuso %q inválido para %s em QUOTA_RESET, usando UTC", w.Timezone, name)
+			loc = time.UTC
+		}
+		w.loc = loc
+		windows[name] = w
+	}
+	return windows
+}
+
+// next é o próximo início de janela depois de now
+func (w quotaWindow) next(now time.Time) time.Time {
+	t := now.In(w.loc)
+	if w.Window == "monthly" {
+		return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, w.loc)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, w.loc)
+}
+
+func windowFor(name, env string) quotaWindow {
+	for _, k := range []string{env, name, "*"} {
+		if w, ok := quotaWindows[k]; ok {
+			return w
+		}
+	}
+	return quotaWindow{Window: "daily", loc: time.UTC}
+}
+
+// Estado de uma chave; value nunca vai para log nem resposta
+type keySlot struct {
+	provider string
+	env      string
+	value    string
+}
+
+var (
+	keysMu    sync.Mutex
+	exhausted = map[string]time.Time{} // variável da chave → fim da janela estourada
+	rejected  = map[string]time.Time{} // variável da chave → última recusa como inválida
+)
+
+// keyVars lista a variável principal e as numeradas
+func keyVars(env string) []string {
+	vars := []string{env}
+	base, suffix := env+"_", 2
+	if strings.HasSuffix(env, "1") {
+		base = strings.TrimSuffix(env, "1")
+	}
+	for ; suffix <= maxKeys; suffix++ {
+		vars = append(vars, base+strconv.Itoa(suffix))
+	}
+	return vars
+}
+
+// pickKey devolve a primeira chave definida fora de janela estourada. Chave
+// cuja janela virou volta ao uso aqui mesmo.
+func pickKey(name, env string) (*keySlot, error) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+
+	now := time.Now()
+	var defined int
+	var earliest time.Time
+	for _, v := range keyVars(env) {
+		value := os.Getenv(v)
+		if value == "" {
+			continue
+		}
+		defined++
+
+		if until, ok := exhausted[v]; ok {
+			if now.Before(until) {
+				if earliest.IsZero() || until.Before(earliest) {
+					earliest = until
+				}
+				continue
+			}
+			delete(exhausted, v)
+			log.Printf("🔄 Cota renovada: %s (%s) de volta ao uso", v, name)
+		}
+		return &keySlot{provider: name, env: v, value: value}, nil
+	}
+
+	if defined == 0 {
+		return nil, notConfigured(name, name+" API key not configured")
+	}
+	return nil, &UpstreamError{
+		Provider:   name,
+		Code:       CodeQuotaExceeded,
+		RetryAfter: time.Until(earliest).Round(time.Second),
+		Detail:     fmt.Sprintf("all %d keys out of quota until %s", defined, earliest.Format(time.RFC3339)),
+	}
+}
+
+// report tira a chave de uso até a próxima janela quando a cota estourou e
+// anota a chave recusada como inválida, que continua em uso
+func (s *keySlot) report(err error) {
+	var upstream *UpstreamError
+	if s == nil || !errors.As(err, &upstream) {
+		return
+	}
+	if upstream.Code == CodeAuthFailed {
+		keysMu.Lock()
+		rejected[s.env] = time.Now()
+		keysMu.Unlock()
+		log.Printf("⚠️  Chave recusada: %s (%s)", s.env, s.provider)
+		return
+	}
+	if upstream.Code != CodeQuotaExceeded {
+		return
+	}
+
+	until := windowFor(s.provider, s.env).next(time.Now())
+	keysMu.Lock()
+	exhausted[s.env] = until
+	keysMu.Unlock()
+	log.Printf("⚠️  Cota esgotada: %s (%s) fora de uso até %s", s.env, s.provider, until.Format(time.RFC3339))
+}
+
+// apiKey escolhe a chave da chamada e a guarda no pedido, para Generate
+// marcar a cota estourada
+func (in *Request) apiKey(name, env string) (string, error) {
+	slot, err := pickKey(name, env)
+	if err != nil {
+		return "", err
+	}
+	in.key = slot
+	return slot.value, nil
+}
+
+// KeyStatus é o estado de uma chave em GET /admin/keys
+type KeyStatus struct {
+	Provider       string     `json:"provider"`
+	Env            string     `json:"env"`
+	ExhaustedUntil *time.Time `json:"exhausted_until,omitempty"`
+	RejectedAt     *time.Time `json:"rejected_at,omitempty"` // última recusa do provedor como chave inválida
+}
+
+// Keys lista as chaves definidas dos provedores registrados, sem os valores
+func Keys() []KeyStatus {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+
+	now := time.Now()
+	var out []KeyStatus
+	for _, p := range registry {
+		env := p.Key
+		if e, ok := keyEnv[p.Name]; ok {
+			env = e
+		}
+		if env == "" {
+			continue
+		}
+		for _, v := range keyVars(env) {
+			if os.Getenv(v) == "" {
+				continue
+			}
+			s := KeyStatus{Provider: p.Name, Env: v}
+			if until, ok := exhausted[v]; ok && now.Before(until) {
+				s.ExhaustedUntil = &until
+			}
+			if at, ok := rejected[v]; ok {
+				s.RejectedAt = &at
+			}
+			out = append(out, s)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Provider < out[j].Provider })
+	return out
+}
+
+// Variável da chave principal dos provedores sem Key no registro
+var keyEnv = map[string]string{
+	"gemini":     "GOOGLE_GEMINI_API_KEY1",
+	"mistral":    "MISTRAL_KEY",
+	"groq":       "GROQ_KEY",
+	"cohere":     "COHERE_KEY",
+	"openrouter": "OPENROUTER_KEY",
+}
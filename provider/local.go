@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"os"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/internal/env"
+)
+
+// Inferência self-hosted (Ollama ou vLLM) pelo endpoint compatível com OpenAI.
+// LOCAL_LLM_URL=http://localhost:11434 (Ollama) ou http://localhost:8000 (vLLM)
+// LOCAL_LLM_BACKEND: ollama (padrão) ou vllm
+// LOCAL_LLM_MODEL: modelo padrão; Request.Model tem prioridade
+// LOCAL_LLM_KEY: token do vLLM iniciado com --api-key (opcional)
+var (
+	localURL     = strings.TrimRight(os.Getenv("LOCAL_LLM_URL"), "/")
+	localBackend = env.String("LOCAL_LLM_BACKEND", "ollama")
+	localModel   = os.Getenv("LOCAL_LLM_MODEL")
+)
+
+func localPayload(in *Request, model string) map[string]interface{} {
+	payload := map[string]interface{}{
+		"model":       model,
+		"messages":    chatMessages(in),
+		"max_tokens":  1000,
+		"temperature": 0.7,
+	}
+	capTokens(payload, "max_tokens", in)
+	setStop(payload, "stop", in)
+	return payload
+}
+
+// localTarget resolve o modelo da chamada e recusa na hora quando o backend
+// está fora ou o modelo está frio, em vez de esperar o carregamento
+func localTarget(in *Request) (string, error) {
+	if localURL == "" {
+		return "", notConfigured("local", "local LLM endpoint not configured")
+	}
+
+	model := localModel
+	if in.Model != "" {
+		model = in.Model
+	}
+	model, err := pool.ready(model)
+	if err != nil {
+		return "", err
+	}
+	return model, nil
+}
+
+// CallLocal chama o Ollama ou vLLM self-hosted
+func CallLocal(in *Request) (*Result, error) {
+	model, err := localTarget(in)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonData, _ := sonic.Marshal(localPayload(in, model))
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(localURL + "/v1/chat/completions")
+	req.Header.SetMethod(fasthttp.MethodPost)
+	if key := os.Getenv("LOCAL_LLM_KEY"); key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	req.Header.SetContentType("application/json")
+	req.SetBody(jsonData)
+
+	if err := in.do("local", req, resp); err != nil {
+		pool.markDown(err)
+		return nil, networkError("local", err)
+	}
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, statusError("local", resp)
+	}
+
+	result, err := parseChatCompletion(resp.Body())
+	if err != nil {
+		return nil, badResponse("local", err)
+	}
+	return result, nil
+}
+
+// StreamLocal faz streaming do chat/completions self-hosted
+func StreamLocal(in *Request, onChunk func(string) error) (*Result, error) {
+	model, err := localTarget(in)
+	if err != nil {
+		return nil, err
+	}
+
+	req := newChatRequest(localURL+"/v1/chat/completions", os.Getenv("LOCAL_LLM_KEY"), localPayload(in, model))
+	defer fasthttp.ReleaseRequest(req)
+
+	return streamChatCompletion(req, "local", onChunk)
+}
@@ -0,0 +1,109 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+)
+
+const mistralURL = "https://api.mistral.ai/v1/chat/completions"
+
+func mistralPayload(in *Request) map[string]interface{} {
+	model := "mistral-tiny"
+	if in.Model != "" {
+		model = in.Model
+	}
+
+	payload := map[string]interface{}{
+		"model":       model,
+		"messages":    chatMessages(in),
+		"temperature": 0.7,
+		"max_tokens":  2000,
+	}
+	capTokens(payload, "max_tokens", in)
+	setStop(payload, "stop", in)
+	return payload
+}
+
+// CallMistral otimizado com retry
+func CallMistral(in *Request) (*Result, error) {
+	apiKey, err := in.apiKey("mistral", "MISTRAL_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	maxRetries := 3
+
+	jsonData, _ := sonic.Marshal(mistralPayload(in))
+
+	var last error
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		// a primeira tentativa já foi contada pelo roteamento; os retries também gastam o orçamento do turno
+		if attempt > 0 {
+			if err := in.Budget.Take(); err != nil {
+				return nil, fmt.Errorf("%w (last error: %w)", err, last)
+			}
+		}
+
+		req := fasthttp.AcquireRequest()
+		resp := fasthttp.AcquireResponse()
+
+		req.SetRequestURI(mistralURL)
+		req.Header.SetMethod(fasthttp.MethodPost)
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		req.Header.SetContentType("application/json")
+		req.SetBody(jsonData)
+
+		err := in.do("mistral", req, resp)
+		statusCode := resp.StatusCode()
+		body := resp.Body()
+
+		fasthttp.ReleaseRequest(req)
+
+		if err != nil {
+			fasthttp.ReleaseResponse(resp)
+			last = networkError("mistral", err)
+			if attempt < maxRetries-1 {
+				time.Sleep(time.Duration(1<<uint(attempt)) * time.Second)
+				continue
+			}
+			return nil, last
+		}
+
+		if statusCode != fasthttp.StatusOK {
+			last = statusError("mistral", resp)
+			fasthttp.ReleaseResponse(resp)
+			if statusCode == 429 && attempt < maxRetries-1 {
+				time.Sleep(time.Duration(1<<uint(attempt)) * time.Second)
+				continue
+			}
+			return nil, last
+		}
+
+		// formato da OpenAI, com usage
+		result, err := parseChatCompletion(body)
+		fasthttp.ReleaseResponse(resp)
+		if err != nil {
+			return nil, badResponse("mistral", err)
+		}
+		return result, nil
+	}
+
+	return nil, errors.New("mistral request failed after retries")
+}
+
+// StreamMistral faz streaming sem retry: o 429 cai no fallback do roteamento
+func StreamMistral(in *Request, onChunk func(string) error) (*Result, error) {
+	apiKey, err := in.apiKey("mistral", "MISTRAL_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	req := newChatRequest(mistralURL, apiKey, mistralPayload(in))
+	defer fasthttp.ReleaseRequest(req)
+
+	return streamChatCompletion(req, "mistral", onChunk)
+}
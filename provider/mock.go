@@ -0,0 +1,142 @@
+package provider
+
+import (
+	"errors"
+	"log"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Provedor falso para o frontend e a CI exercitarem as rotas sem chaves reais.
+//
+//	MOCK_MODE=1          todos os provedores passam a responder pelo mock
+//	MOCK_LATENCY=300ms   latência simulada de cada chamada
+//	MOCK_ERROR_RATE=0.1  fração das chamadas que falham
+//	MOCK_RESPONSE=...    resposta fixa; vazio devolve o próprio texto (eco)
+var mock = loadMockConfig()
+
+// Mock é servido em /mock e substitui todos os provedores em MOCK_MODE
+var Mock = Provider{Name: "mock", Call: CallMock, Stream: StreamMock}
+
+type mockConfig struct {
+	enabled   bool
+	latency   time.Duration
+	errorRate float64
+	response  string
+}
+
+func loadMockConfig() mockConfig {
+	cfg := mockConfig{
+		enabled:  os.Getenv("MOCK_MODE") == "1" || os.Getenv("MOCK_MODE") == "true",
+		response: os.Getenv("MOCK_RESPONSE"),
+	}
+
+	if raw := os.Getenv("MOCK_LATENCY"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			log.Printf("⚠️  MOCK_LATENCY inválido: %v", err)
+		}
+		cfg.latency = d
+	}
+
+	if raw := os.Getenv("MOCK_ERROR_RATE"); raw != "" {
+		rate, err := strconv.ParseFloat(raw, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			log.Printf("⚠️  MOCK_ERROR_RATE deve estar entre 0 e 1")
+		} else {
+			cfg.errorRate = rate
+		}
+	}
+
+	return cfg
+}
+
+// MockMode indica se MOCK_MODE está ativo
+func MockMode() bool {
+	return mock.enabled
+}
+
+// CallMock simula um provedor com latência e falhas configuráveis
+func CallMock(in *Request) (*Result, error) {
+	if mock.latency > 0 {
+		time.Sleep(mock.latency)
+	}
+
+	if mock.errorRate > 0 && rand.Float64() < mock.errorRate {
+		return nil, errors.New("mock provider simulated failure")
+	}
+
+	text := mock.response
+	if text == "" {
+		text = "[mock] " + in.Text
+	}
+	text = in.CutAtStop(text)
+
+	result := &Result{Text: text}
+	if in.Reasoning {
+		result.Reasoning = "[mock] raciocínio simulado"
+		result.ReasoningTokens = EstimateTokens(result.Reasoning)
+	}
+
+	prompt := in.PromptTokens()
+	completion := EstimateTokens(text)
+	result.Usage = Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion}
+	return result, nil
+}
+
+// StreamMock entrega a resposta simulada palavra por palavra
+func StreamMock(in *Request, onChunk func(string) error) (*Result, error) {
+	result, err := CallMock(in)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, word := range strings.SplitAfter(result.Text, " ") {
+		if err := onChunk(word); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Generate chama o provedor, respeitando o MOCK_MODE
+func (p Provider) Generate(in *Request) (*Result, error) {
+	if mock.enabled {
+		return CallMock(in)
+	}
+	in.key = nil
+	if recordDir != "" {
+		in.tape = &tape{}
+		defer func() { in.tape = nil }()
+	}
+	result, err := p.Call(in)
+	in.key.report(err)
+	if in.tape != nil {
+		in.tape.save(p.Name, in, result, err)
+	}
+	return result, err
+}
+
+// GenerateStream faz streaming quando o provedor suporta; senão entrega a resposta inteira de uma vez
+func (p Provider) GenerateStream(in *Request, onChunk func(string) error) (*Result, error) {
+	if mock.enabled {
+		return StreamMock(in, onChunk)
+	}
+
+	in.key = nil
+	if p.Stream != nil {
+		result, err := p.Stream(in, onChunk)
+		in.key.report(err)
+		return result, err
+	}
+
+	result, err := p.Call(in)
+	in.key.report(err)
+	if err != nil {
+		return nil, err
+	}
+	return result, onChunk(result.Text)
+}
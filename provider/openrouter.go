@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+)
+
+const openRouterURL = "https://openrouter.ai/api/v1/chat/completions"
+
+// openRouterModels devolve a ordem de modelos gratuitos a tentar, do
+// catálogo ranqueado em openroutercatalog.go
+func openRouterModels(in *Request) []string {
+	if in.Model != "" {
+		return []string{in.Model}
+	}
+	return openRouter.ranked(in.Reasoning)
+}
+
+func openRouterPayload(in *Request, model string) map[string]interface{} {
+	payload := map[string]interface{}{
+		"model":       model,
+		"messages":    chatMessages(in),
+		"max_tokens":  1000,
+		"temperature": 0.7,
+	}
+
+	if in.Reasoning {
+		payload["include_reasoning"] = true
+		// o raciocínio consome tokens antes da resposta
+		payload["max_tokens"] = 4000
+	}
+	capTokens(payload, "max_tokens", in)
+	setStop(payload, "stop", in)
+	return payload
+}
+
+func setOpenRouterHeaders(req *fasthttp.Request) {
+	req.Header.Set("HTTP-Referer", "https://lingobot-api.onrender.com")
+	req.Header.Set("X-Title", "Go FastHTTP OpenRouter App")
+}
+
+// Nenhum modelo da lista chegou a responder
+var errOpenRouterModelsDown = &UpstreamError{Provider: "openrouter", Code: CodeUnavailable, Retryable: true, Detail: "todos os modelos estão indisponíveis no momento"}
+
+// CallOpenRouter otimizado com fallback de modelos
+func CallOpenRouter(in *Request) (*Result, error) {
+	apiKey, err := in.apiKey("openrouter", "OPENROUTER_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	var last error
+	for i, model := range openRouterModels(in) {
+		// a primeira tentativa já foi contada pelo roteamento; cada modelo seguinte também gasta o orçamento do turno
+		if i > 0 {
+			if err := in.Budget.Take(); err != nil {
+				return nil, fmt.Errorf("%w (last error: %w)", err, last)
+			}
+		}
+
+		jsonData, _ := sonic.Marshal(openRouterPayload(in, model))
+
+		req := fasthttp.AcquireRequest()
+		resp := fasthttp.AcquireResponse()
+
+		req.SetRequestURI(openRouterURL)
+		req.Header.SetMethod(fasthttp.MethodPost)
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		req.Header.SetContentType("application/json")
+		setOpenRouterHeaders(req)
+		req.SetBody(jsonData)
+
+		start := time.Now()
+		err := in.do("openrouter", req, resp)
+		statusCode := resp.StatusCode()
+
+		if err != nil {
+			fasthttp.ReleaseRequest(req)
+			fasthttp.ReleaseResponse(resp)
+			last = networkError("openrouter", err)
+			openRouter.record(model, time.Since(start), last)
+			continue
+		}
+
+		if statusCode == fasthttp.StatusOK {
+			result, err := parseChatCompletion(resp.Body())
+			fasthttp.ReleaseRequest(req)
+			fasthttp.ReleaseResponse(resp)
+
+			if err != nil {
+				last = badResponse("openrouter", err)
+				openRouter.record(model, time.Since(start), last)
+				continue
+			}
+
+			openRouter.record(model, time.Since(start), nil)
+			return result, nil
+		}
+
+		last = statusError("openrouter", resp)
+		openRouter.record(model, time.Since(start), last)
+		fasthttp.ReleaseRequest(req)
+		fasthttp.ReleaseResponse(resp)
+
+		if statusCode == 503 {
+			continue
+		}
+	}
+
+	if last == nil {
+		last = errOpenRouterModelsDown
+	}
+	return nil, last
+}
+
+// StreamOpenRouter troca de modelo enquanto nenhum pedaço foi enviado ao cliente
+func StreamOpenRouter(in *Request, onChunk func(string) error) (*Result, error) {
+	apiKey, err := in.apiKey("openrouter", "OPENROUTER_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	var last error
+	for i, model := range openRouterModels(in) {
+		// a primeira tentativa já foi contada pelo roteamento; cada modelo seguinte também gasta o orçamento do turno
+		if i > 0 {
+			if err := in.Budget.Take(); err != nil {
+				return nil, fmt.Errorf("%w (last error: %w)", err, last)
+			}
+		}
+
+		started := false
+		req := newChatRequest(openRouterURL, apiKey, openRouterPayload(in, model))
+		setOpenRouterHeaders(req)
+
+		start := time.Now()
+		result, err := streamChatCompletion(req, "openrouter", func(chunk string) error {
+			if !started {
+				// o ranking mede o tempo até o primeiro pedaço, não o stream inteiro
+				openRouter.record(model, time.Since(start), nil)
+			}
+			started = true
+			return onChunk(chunk)
+		})
+		fasthttp.ReleaseRequest(req)
+		if !started {
+			openRouter.record(model, time.Since(start), err)
+		}
+
+		if err == nil || started {
+			return result, err
+		}
+		last = err
+	}
+
+	if last == nil {
+		last = errOpenRouterModelsDown
+	}
+	return nil, last
+}
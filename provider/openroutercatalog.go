@@ -0,0 +1,296 @@
+package provider
+
+import (
+	"errors"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/internal/env"
+)
+
+// Catálogo dos modelos gratuitos do OpenRouter. A lista de modelos :free
+// muda toda semana; em vez de uma lista fixa, o catálogo é buscado em /models
+// e cada chamada tenta os modelos na ordem do ranking por sucesso e latência
+// observados nesta instância.
+//
+//	OPENROUTER_MODELS_REFRESH=1h    intervalo entre buscas do catálogo
+//	OPENROUTER_PIN=a:free,b:free    modelos tentados antes do ranking, nesta ordem
+//	OPENROUTER_REASONING_PIN=...    idem para pedidos de raciocínio
+//	OPENROUTER_EXCLUDE=c:free       modelos que nunca são tentados
+//	OPENROUTER_MAX_TRIES=4          modelos tentados por chamada
+//
+// Até a primeira busca dar certo valem as listas padrão abaixo.
+const openRouterModelsURL = "https://openrouter.ai/api/v1/models"
+
+var (
+	openRouterRefresh  = env.PositiveDuration("OPENROUTER_MODELS_REFRESH", time.Hour)
+	openRouterPin      = env.List("OPENROUTER_PIN")
+	openRouterPinR     = env.List("OPENROUTER_REASONING_PIN")
+	openRouterExclude  = env.List("OPENROUTER_EXCLUDE")
+	openRouterMaxTries = env.PositiveInt("OPENROUTER_MAX_TRIES", 4)
+)
+
+var defaultOpenRouterModels = []string{
+	"qwen/qwen3-235b-a22b-07-25:free",
+	"meta-llama/llama-3.1-8b-instruct:free",
+	"microsoft/phi-3-mini-128k-instruct:free",
+	"google/gemma-2-9b-it:free",
+}
+
+// Sem amostras, o modelo entra com sucesso e latência presumidos: novos
+// modelos do catálogo ficam no meio da fila até provarem o contrário
+const (
+	openRouterAlpha        = 0.2
+	openRouterPriorSuccess = 0.8
+	openRouterPriorLatency = 3 * time.Second
+	minOpenRouterSuccess   = 0.01 // modelo que sempre falha ainda fica no fim da fila
+)
+
+// Estatísticas móveis de um modelo do OpenRouter
+type openRouterStats struct {
+	success float64 // EWMA de 0 a 1
+	latency float64 // EWMA em segundos, só das chamadas com sucesso
+	calls   int
+}
+
+type openRouterCatalog struct {
+	mu        sync.Mutex
+	models    []string // gratuitos e de texto, na ordem do catálogo
+	reasoning []string // subconjunto que aceita include_reasoning
+	fetched   time.Time
+	err       string
+	stats     map[string]*openRouterStats
+}
+
+var openRouter = &openRouterCatalog{
+	models:    defaultOpenRouterModels,
+	reasoning: openRouterReasoningModels,
+	stats:     map[string]*openRouterStats{},
+}
+
+// record alimenta o ranking com o resultado de uma tentativa. Chave inválida
+// ou cota esgotada valem para a conta inteira e não pesam contra o modelo.
+func (c *openRouterCatalog) record(model string, latency time.Duration, err error) {
+	var upstream *UpstreamError
+	if errors.As(err, &upstream) && (upstream.Code == CodeAuthFailed || upstream.Code == CodeQuotaExceeded) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.stats[model]
+	if !ok {
+		s = &openRouterStats{success: openRouterPriorSuccess, latency: openRouterPriorLatency.Seconds()}
+		c.stats[model] = s
+	}
+	s.calls++
+
+	outcome := 0.0
+	if err == nil {
+		outcome = 1.0
+		s.latency = openRouterAlpha*latency.Seconds() + (1-openRouterAlpha)*s.latency
+	}
+	s.success = openRouterAlpha*outcome + (1-openRouterAlpha)*s.success
+}
+
+// score combina sucesso e latência: maior é melhor
+func (c *openRouterCatalog) score(model string) float64 {
+	s, ok := c.stats[model]
+	if !ok {
+		return openRouterPriorSuccess / openRouterPriorLatency.Seconds()
+	}
+	return math.Max(s.success, minOpenRouterSuccess) / math.Max(s.latency, 0.05)
+}
+
+// ranked devolve os modelos a tentar: fixados primeiro, depois o catálogo
+// pelo score, sem os excluídos e limitado a OPENROUTER_MAX_TRIES
+func (c *openRouterCatalog) ranked(reasoning bool) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pinned, pool := openRouterPin, c.models
+	if reasoning {
+		pinned, pool = openRouterPinR, c.reasoning
+	}
+
+	skip := map[string]bool{}
+	for _, m := range openRouterExclude {
+		skip[m] = true
+	}
+
+	out := make([]string, 0, openRouterMaxTries)
+	for _, m := range pinned {
+		if !skip[m] && len(out) < openRouterMaxTries {
+			out = append(out, m)
+			skip[m] = true
+		}
+	}
+
+	rest := make([]string, 0, len(pool))
+	for _, m := range pool {
+		if !skip[m] {
+			rest = append(rest, m)
+		}
+	}
+	sort.SliceStable(rest, func(i, j int) bool { return c.score(rest[i]) > c.score(rest[j]) })
+	for _, m := range rest {
+		if len(out) == openRouterMaxTries {
+			break
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// Formato de GET /api/v1/models
+type openRouterModelList struct {
+	Data []struct {
+		ID      string `json:"id"`
+		Pricing struct {
+			Prompt     string `json:"prompt"`
+			Completion string `json:"completion"`
+		} `json:"pricing"`
+		Architecture struct {
+			Modality string `json:"modality"` // "text->text", "text+image->text"...
+		} `json:"architecture"`
+		SupportedParameters []string `json:"supported_parameters"`
+	} `json:"data"`
+}
+
+// fetch busca o catálogo e fica com os modelos gratuitos que respondem texto
+func (c *openRouterCatalog) fetch() error {
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(openRouterModelsURL)
+	req.Header.SetMethod(fasthttp.MethodGet)
+	setOpenRouterHeaders(req)
+	if key := os.Getenv("OPENROUTER_KEY"); key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	if err := do("openrouter", "", req, resp); err != nil {
+		return networkError("openrouter", err)
+	}
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return statusError("openrouter", resp)
+	}
+
+	var list openRouterModelList
+	if err := sonic.Unmarshal(resp.Body(), &list); err != nil {
+		return badResponse("openrouter", err)
+	}
+
+	var models, reasoning []string
+	for _, m := range list.Data {
+		free := strings.HasSuffix(m.ID, ":free") || (m.Pricing.Prompt == "0" && m.Pricing.Completion == "0")
+		if !free || !strings.HasSuffix(m.Architecture.Modality, "->text") {
+			continue
+		}
+		models = append(models, m.ID)
+		for _, p := range m.SupportedParameters {
+			if p == "include_reasoning" || p == "reasoning" {
+				reasoning = append(reasoning, m.ID)
+				break
+			}
+		}
+	}
+	if len(models) == 0 {
+		return badResponse("openrouter", errEmptyCatalog)
+	}
+
+	c.mu.Lock()
+	c.models = models
+	if len(reasoning) > 0 {
+		c.reasoning = reasoning
+	}
+	c.fetched = time.Now()
+	c.mu.Unlock()
+	log.Printf("🔀 Catálogo do OpenRouter: %d modelos gratuitos, %d com raciocínio", len(models), len(reasoning))
+	return nil
+}
+
+var errEmptyCatalog = errors.New("catalog has no free text models")
+
+// RefreshOpenRouterModels busca o catálogo a cada OPENROUTER_MODELS_REFRESH;
+// só roda com OPENROUTER_KEY e fora do MOCK_MODE
+func RefreshOpenRouterModels() {
+	if MockMode() || os.Getenv("OPENROUTER_KEY") == "" {
+		return
+	}
+
+	for {
+		err := openRouter.fetch()
+		openRouter.mu.Lock()
+		openRouter.err = ""
+		if err != nil {
+			openRouter.err = err.Error()
+			log.Printf("⚠️  Catálogo do OpenRouter não atualizado: %v", err)
+		}
+		openRouter.mu.Unlock()
+		time.Sleep(openRouterRefresh)
+	}
+}
+
+// Modelo do OpenRouter em GET /status, na ordem em que seria tentado
+type OpenRouterModel struct {
+	ID        string  `json:"id"`
+	Pinned    bool    `json:"pinned,omitempty"`
+	Success   float64 `json:"success_rate"`
+	LatencyMs int64   `json:"latency_ms"`
+	Calls     int     `json:"calls"`
+}
+
+// Estado do catálogo do OpenRouter em GET /status
+type OpenRouterStatus struct {
+	Fetched   *time.Time        `json:"fetched,omitempty"` // ausente enquanto valem as listas padrão
+	Error     string            `json:"error,omitempty"`
+	Available int               `json:"available"`
+	Models    []OpenRouterModel `json:"models"`
+	Reasoning []OpenRouterModel `json:"reasoning_models"`
+}
+
+// OpenRouter devolve o catálogo e o ranking atual
+func OpenRouter() OpenRouterStatus {
+	models, reasoning := openRouter.ranked(false), openRouter.ranked(true)
+
+	openRouter.mu.Lock()
+	defer openRouter.mu.Unlock()
+
+	out := OpenRouterStatus{Error: openRouter.err, Available: len(openRouter.models)}
+	if !openRouter.fetched.IsZero() {
+		fetched := openRouter.fetched
+		out.Fetched = &fetched
+	}
+	out.Models = openRouter.describe(models, openRouterPin)
+	out.Reasoning = openRouter.describe(reasoning, openRouterPinR)
+	return out
+}
+
+func (c *openRouterCatalog) describe(models, pinned []string) []OpenRouterModel {
+	out := make([]OpenRouterModel, 0, len(models))
+	for _, id := range models {
+		m := OpenRouterModel{ID: id, Success: openRouterPriorSuccess, LatencyMs: openRouterPriorLatency.Milliseconds()}
+		for _, p := range pinned {
+			m.Pinned = m.Pinned || p == id
+		}
+		if s, ok := c.stats[id]; ok {
+			m.Success = math.Round(s.success*1000) / 1000
+			m.LatencyMs = int64(s.latency * 1000)
+			m.Calls = s.calls
+		}
+		out = append(out, m)
+	}
+	return out
+}
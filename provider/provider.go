@@ -0,0 +1,328 @@
+// Package provider fala com as APIs de LLM (Gemini, Mistral, Cohere, Groq,
+// OpenRouter) e normaliza pedidos e respostas.
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+)
+
+// HTTPClient reutilizável com connection pooling; as chamadas passam por do,
+// que escolhe o client e o prazo conforme UPSTREAM_TIMEOUTS
+var client = &fasthttp.Client{
+	MaxConnsPerHost:     1000,
+	MaxIdleConnDuration: 90 * time.Second,
+	ReadTimeout:         fallbackTimeouts.Read,
+	WriteTimeout:        fallbackTimeouts.Write,
+}
+
+// Turno anterior da conversa
+type Message struct {
+	Role    string `json:"role"` // system, user ou assistant
+	Content string `json:"content"`
+}
+
+// Parâmetros de geração repassados aos provedores
+type Request struct {
+	Text      string
+	System    string    // instruções fixas do turno (nível do aluno); cada provedor usa o campo próprio
+	History   []Message // turnos anteriores, do mais antigo ao mais recente
+	Model     string    // vazio usa o modelo padrão do provedor
+	Reasoning bool      // pede um modelo de raciocínio quando o provedor oferece
+
+	Gemini *GeminiOptions // só o Gemini usa; nil fica com os padrões
+	Budget *Budget        // chamadas restantes do turno; nil não limita
+
+	MaxCostUSD float64  // teto de custo de cada chamada; 0 não limita
+	MaxTokens  int      // teto de tokens da resposta, abaixo do padrão do provedor; 0 não limita
+	Stop       []string // sequências que encerram a resposta, sem entrar nela
+
+	Route string // rota que originou a chamada, para os timeouts de UPSTREAM_TIMEOUTS
+
+	key  *keySlot // chave usada na última chamada, para marcar a cota estourada
+	tape *tape    // gravação ou replay das trocas com o provedor (RECORD_FIXTURES)
+}
+
+// Uso de tokens mostrado ao usuário (sem os tokens de raciocínio)
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Resposta normalizada de um provedor
+type Result struct {
+	Provider        string // preenchido pelo roteamento
+	Text            string
+	Reasoning       string
+	ReasoningTokens int
+	Usage           Usage
+	Language        string // idioma detectado da resposta, preenchido pelo roteamento
+}
+
+// Provedor registrado para roteamento
+type Provider struct {
+	Name   string
+	Call   func(*Request) (*Result, error)
+	Stream StreamFunc // nil quando o provedor não faz streaming
+
+	// Variável da API key; com ela definida o provedor entra no fallback padrão
+	Key string
+
+	// Variável do endpoint dos provedores self-hosted, que dispensam API key
+	Endpoint string
+
+	// Formato e versão da API chamada, mostrados em GET /version
+	API string
+
+	// Declarado em CUSTOM_PROVIDERS; sem Key conta como configurado
+	Custom bool
+}
+
+// Configured diz se a API key (ou o endpoint) do provedor está definida
+func (p Provider) Configured() bool {
+	if p.Endpoint != "" {
+		return os.Getenv(p.Endpoint) != ""
+	}
+	if p.Custom && p.Key == "" {
+		return true
+	}
+	key := p.Key
+	if key == "" {
+		key = keyEnv[p.Name]
+	}
+	return key != "" && os.Getenv(key) != ""
+}
+
+// APIVersion é o API com a versão escolhida por variável de ambiente, quando
+// o provedor permite fixá-la (api-version da Azure, backend local)
+func (p Provider) APIVersion() string {
+	switch p.Name {
+	case "azure":
+		return p.API + "@" + azureAPIVersion()
+	case "local":
+		return localBackend + ":" + p.API
+	}
+	return p.API
+}
+
+// TranscriptionModel é o modelo do Whisper usado em /pronunciation
+func TranscriptionModel() string {
+	return whisperModel
+}
+
+// Ordem fixa usada quando não há estratégia
+var registry = []Provider{
+	{Name: "gemini", Call: CallGemini, Stream: StreamGemini, API: "gemini-generatecontent/v1beta"},
+	{Name: "mistral", Call: CallMistral, Stream: StreamMistral, API: "openai-chat/v1"},
+	{Name: "groq", Call: CallGroq, Stream: StreamGroq, API: "openai-chat/v1"},
+	{Name: "cohere", Call: CallCohere, API: "cohere-chat/v1"},
+	{Name: "openrouter", Call: CallOpenRouter, Stream: StreamOpenRouter, API: "openai-chat/v1"},
+	{Name: "deepseek", Call: CallDeepSeek, Stream: StreamDeepSeek, Key: "DEEPSEEK_KEY", API: "openai-chat/v1"},
+	{Name: "together", Call: CallTogether, Stream: StreamTogether, Key: "TOGETHER_KEY", API: "openai-chat/v1"},
+	{Name: "huggingface", Call: CallHuggingFace, Key: "HF_TOKEN", API: "hf-inference/v1"},
+	{Name: "azure", Call: CallAzureOpenAI, Stream: StreamAzureOpenAI, Key: "AZURE_OPENAI_KEY", API: "azure-openai-chat"},
+	{Name: "local", Call: CallLocal, Stream: StreamLocal, Endpoint: "LOCAL_LLM_URL", API: "openai-chat/v1"},
+}
+
+// All devolve os provedores reais registrados
+func All() []Provider {
+	return registry
+}
+
+// ByName procura um provedor pelo nome, incluindo o mock
+func ByName(name string) (Provider, bool) {
+	if name == Mock.Name {
+		return Mock, true
+	}
+	for _, p := range registry {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Provider{}, false
+}
+
+// capTokens baixa o limite de tokens da resposta no payload para o MaxTokens do pedido
+func capTokens(payload map[string]interface{}, field string, in *Request) {
+	if in.MaxTokens <= 0 {
+		return
+	}
+	if current, ok := payload[field].(int); ok && current <= in.MaxTokens {
+		return
+	}
+	payload[field] = in.MaxTokens
+}
+
+// Limites de stop: 4 sequências é o máximo da OpenAI e das APIs compatíveis
+const (
+	MaxStopSequences = 4
+	maxStopLength    = 64
+)
+
+var (
+	errTooManyStops = fmt.Errorf("stop accepts at most %d sequences", MaxStopSequences)
+	errStopLength   = fmt.Errorf("each stop sequence must have between 1 and %d characters", maxStopLength)
+)
+
+// ValidateStop confere as sequências de parada pedidas
+func ValidateStop(stop []string) error {
+	if len(stop) > MaxStopSequences {
+		return errTooManyStops
+	}
+	for _, s := range stop {
+		if s == "" || utf8.RuneCountInString(s) > maxStopLength {
+			return errStopLength
+		}
+	}
+	return nil
+}
+
+// setStop repassa as sequências de parada no campo do provedor
+func setStop(payload map[string]interface{}, field string, in *Request) {
+	if len(in.Stop) > 0 {
+		payload[field] = in.Stop
+	}
+}
+
+// CutAtStop corta o texto na primeira sequência de parada. Os provedores já
+// param nela, mas alguns modelos do OpenRouter e do HuggingFace ignoram o
+// parâmetro e seguem escrevendo.
+func (in *Request) CutAtStop(text string) string {
+	cut := len(text)
+	for _, s := range in.Stop {
+		if i := strings.Index(text, s); i >= 0 && i < cut {
+			cut = i
+		}
+	}
+	return text[:cut]
+}
+
+// chatMessages monta histórico + texto atual no formato OpenAI
+func chatMessages(in *Request) []map[string]string {
+	messages := make([]map[string]string, 0, len(in.History)+2)
+	if in.System != "" {
+		messages = append(messages, map[string]string{"role": "system", "content": in.System})
+	}
+	for _, m := range in.History {
+		messages = append(messages, map[string]string{"role": m.Role, "content": m.Content})
+	}
+	return append(messages, map[string]string{"role": "user", "content": in.Text})
+}
+
+// Modelos de raciocínio (DeepSeek-R1) por provedor
+const (
+	groqReasoningModel     = "deepseek-r1-distill-llama-70b"
+	deepSeekReasoningModel = "deepseek-reasoner"
+	togetherReasoningModel = "deepseek-ai/DeepSeek-R1-Distill-Llama-70B-free"
+)
+
+// Raciocínio no OpenRouter até a primeira busca do catálogo
+var openRouterReasoningModels = []string{
+	"deepseek/deepseek-r1:free",
+	"deepseek/deepseek-r1-distill-llama-70b:free",
+}
+
+// Formato OpenAI de chat/completions (Groq, OpenRouter, DeepSeek, Together)
+type chatCompletion struct {
+	Choices []struct {
+		Message struct {
+			Content          string `json:"content"`
+			Reasoning        string `json:"reasoning"`
+			ReasoningContent string `json:"reasoning_content"` // DeepSeek
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens            int `json:"prompt_tokens"`
+		CompletionTokens        int `json:"completion_tokens"`
+		CompletionTokensDetails struct {
+			ReasoningTokens int `json:"reasoning_tokens"`
+		} `json:"completion_tokens_details"`
+	} `json:"usage"`
+}
+
+// parseChatCompletion separa resposta, raciocínio e uso de tokens
+func parseChatCompletion(body []byte) (*Result, error) {
+	var completion chatCompletion
+	if err := sonic.Unmarshal(body, &completion); err != nil {
+		return nil, err
+	}
+
+	if len(completion.Choices) == 0 {
+		return nil, errors.New("no choices in response")
+	}
+
+	message := completion.Choices[0].Message
+	text, thought := splitThinkTags(message.Content)
+	if message.Reasoning != "" {
+		thought = message.Reasoning
+	}
+	if message.ReasoningContent != "" {
+		thought = message.ReasoningContent
+	}
+
+	reasoningTokens := completion.Usage.CompletionTokensDetails.ReasoningTokens
+	if reasoningTokens == 0 && thought != "" {
+		reasoningTokens = EstimateTokens(thought)
+	}
+
+	completionTokens := completion.Usage.CompletionTokens - reasoningTokens
+	if completionTokens < 0 {
+		completionTokens = 0
+	}
+
+	return &Result{
+		Text:            text,
+		Reasoning:       thought,
+		ReasoningTokens: reasoningTokens,
+		Usage: Usage{
+			PromptTokens:     completion.Usage.PromptTokens,
+			CompletionTokens: completionTokens,
+			TotalTokens:      completion.Usage.PromptTokens + completionTokens,
+		},
+	}, nil
+}
+
+// splitThinkTags remove o bloco <think>...</think> que alguns modelos R1 devolvem no texto
+func splitThinkTags(content string) (string, string) {
+	start := strings.Index(content, "<think>")
+	if start == -1 {
+		return content, ""
+	}
+
+	end := strings.Index(content[start:], "</think>")
+	if end == -1 {
+		return strings.TrimSpace(content[:start]), strings.TrimSpace(content[start+len("<think>"):])
+	}
+	end += start
+
+	thought := strings.TrimSpace(content[start+len("<think>") : end])
+	text := strings.TrimSpace(content[:start] + content[end+len("</think>"):])
+	return text, thought
+}
+
+// EstimateTokens aproxima a contagem quando o provedor não informa (~4 caracteres por token)
+func EstimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + 3) / 4
+}
+
+// PromptTokens estima a entrada inteira: instruções, histórico e texto
+func (in *Request) PromptTokens() int {
+	return EstimateTokens(in.System) + HistoryTokens(in.History) + EstimateTokens(in.Text)
+}
+
+// HistoryTokens soma a estimativa de todos os turnos
+func HistoryTokens(history []Message) int {
+	total := 0
+	for _, m := range history {
+		total += EstimateTokens(m.Content)
+	}
+	return total
+}
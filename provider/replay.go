@@ -0,0 +1,279 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Gravação e replay das chamadas aos provedores, para testar o parsing de
+// cada um contra respostas reais:
+//
+//	RECORD_FIXTURES=dir   grava cada chamada sem streaming em dir/<provedor>/
+//
+// A fixture guarda o turno pedido, as trocas HTTP com o provedor, sem as
+// chaves, e o resultado. "lingobot-ai-engine replay dir" reexecuta cada uma
+// contra o código atual, servindo as respostas gravadas no lugar da rede. As
+// fixtures de provider/testdata/fixtures rodam em go test ./replay.
+var recordDir = os.Getenv("RECORD_FIXTURES")
+
+// Cabeçalhos que carregam credencial: gravados como redacted
+var secretHeaders = map[string]bool{
+	"authorization":  true,
+	"api-key":        true,
+	"x-api-key":      true,
+	"x-goog-api-key": true,
+	"cookie":         true,
+}
+
+const redacted = "REDACTED"
+
+// Fixture é uma chamada gravada a um provedor
+type Fixture struct {
+	Provider  string         `json:"provider"`
+	Recorded  time.Time      `json:"recorded"`
+	Request   FixtureRequest `json:"request"`
+	Exchanges []Exchange     `json:"exchanges"`
+	Result    *FixtureResult `json:"result,omitempty"`
+	Error     string         `json:"error,omitempty"` // código do UpstreamError quando a chamada falhou
+}
+
+// FixtureRequest é o turno da chamada, sem o estado do roteamento
+type FixtureRequest struct {
+	Text       string         `json:"text"`
+	System     string         `json:"system,omitempty"`
+	History    []Message      `json:"history,omitempty"`
+	Model      string         `json:"model,omitempty"`
+	Reasoning  bool           `json:"reasoning,omitempty"`
+	Gemini     *GeminiOptions `json:"gemini,omitempty"`
+	MaxCostUSD float64        `json:"max_cost_usd,omitempty"`
+	MaxTokens  int            `json:"max_tokens,omitempty"`
+	Stop       []string       `json:"stop,omitempty"`
+	Route      string         `json:"route,omitempty"`
+}
+
+// FixtureResult é o que o provedor devolveu, antes do corte nas stop sequences
+type FixtureResult struct {
+	Text            string `json:"text"`
+	Reasoning       string `json:"reasoning,omitempty"`
+	ReasoningTokens int    `json:"reasoning_tokens,omitempty"`
+	Usage           Usage  `json:"usage"`
+}
+
+// Exchange é um pedido HTTP ao provedor e a resposta dele
+type Exchange struct {
+	Method         string            `json:"method"`
+	URL            string            `json:"url"`
+	Header         map[string]string `json:"header,omitempty"`
+	Body           string            `json:"body"`
+	Status         int               `json:"status"`
+	ResponseHeader map[string]string `json:"response_header,omitempty"`
+	Response       string            `json:"response"`
+}
+
+// Fita de uma chamada: grava as trocas ou, no replay, as devolve em ordem
+type tape struct {
+	replay    bool
+	exchanges []Exchange
+	next      int
+	broken    bool // falha de rede: não há resposta para reproduzir
+}
+
+var errTapeExhausted = errors.New("replay: no recorded exchange left")
+
+// do envia a chamada do turno, passando pela fita quando há uma
+func (in *Request) do(name string, req *fasthttp.Request, resp *fasthttp.Response) error {
+	if in.tape != nil && in.tape.replay {
+		return in.tape.serve(req, resp)
+	}
+	err := do(name, in.Route, req, resp)
+	if in.tape != nil {
+		in.tape.record(in, req, resp, err)
+	}
+	return err
+}
+
+func (t *tape) record(in *Request, req *fasthttp.Request, resp *fasthttp.Response, err error) {
+	if err != nil {
+		t.broken = true
+		return
+	}
+
+	var secrets []string
+	if in.key != nil {
+		secrets = append(secrets, in.key.value)
+	}
+	if key := os.Getenv("LOCAL_LLM_KEY"); key != "" {
+		secrets = append(secrets, key)
+	}
+	scrub := func(s string) string {
+		for _, secret := range secrets {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+		return s
+	}
+
+	e := Exchange{
+		Method:         string(req.Header.Method()),
+		URL:            scrub(req.URI().String()),
+		Header:         map[string]string{},
+		Body:           scrub(string(req.Body())),
+		Status:         resp.StatusCode(),
+		ResponseHeader: map[string]string{},
+		Response:       string(resp.Body()),
+	}
+	for k, v := range req.Header.All() {
+		value := scrub(string(v))
+		if secretHeaders[strings.ToLower(string(k))] {
+			value = redacted
+		}
+		e.Header[string(k)] = value
+	}
+	for k, v := range resp.Header.All() {
+		e.ResponseHeader[string(k)] = string(v)
+	}
+	t.exchanges = append(t.exchanges, e)
+}
+
+// serve devolve a próxima resposta gravada e guarda o pedido feito no lugar
+// do gravado, para o replay comparar os payloads
+func (t *tape) serve(req *fasthttp.Request, resp *fasthttp.Response) error {
+	if t.next >= len(t.exchanges) {
+		return errTapeExhausted
+	}
+	e := &t.exchanges[t.next]
+	t.next++
+
+	resp.Reset()
+	resp.SetStatusCode(e.Status)
+	for k, v := range e.ResponseHeader {
+		if k != "Content-Length" {
+			resp.Header.Set(k, v)
+		}
+	}
+	resp.SetBodyString(e.Response)
+
+	e.Method, e.URL, e.Body = string(req.Header.Method()), req.URI().String(), string(req.Body())
+	return nil
+}
+
+// save grava a fixture da chamada em RECORD_FIXTURES
+func (t *tape) save(name string, in *Request, result *Result, err error) {
+	if t.broken || len(t.exchanges) == 0 {
+		return
+	}
+
+	f := Fixture{
+		Provider:  name,
+		Recorded:  time.Now().UTC(),
+		Request:   fixtureRequest(in),
+		Exchanges: t.exchanges,
+	}
+	if result != nil {
+		f.Result = &FixtureResult{Text: result.Text, Reasoning: result.Reasoning, ReasoningTokens: result.ReasoningTokens, Usage: result.Usage}
+	}
+	var upstream *UpstreamError
+	if errors.As(Classify(name, err), &upstream) {
+		f.Error = upstream.Code
+	}
+
+	raw, _ := json.MarshalIndent(f, "", "  ")
+	sum := sha256.Sum256([]byte(t.exchanges[0].Body))
+	path := filepath.Join(recordDir, name, f.Recorded.Format("20060102T150405.000")+"-"+hex.EncodeToString(sum[:4])+".json")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		log.Printf("⚠️  Fixture de %s não gravada: %v", name, err)
+		return
+	}
+	if err := os.WriteFile(path, append(raw, '\n'), 0o644); err != nil {
+		log.Printf("⚠️  Fixture de %s não gravada: %v", name, err)
+	}
+}
+
+func fixtureRequest(in *Request) FixtureRequest {
+	return FixtureRequest{
+		Text:       in.Text,
+		System:     in.System,
+		History:    in.History,
+		Model:      in.Model,
+		Reasoning:  in.Reasoning,
+		Gemini:     in.Gemini,
+		MaxCostUSD: in.MaxCostUSD,
+		MaxTokens:  in.MaxTokens,
+		Stop:       in.Stop,
+		Route:      in.Route,
+	}
+}
+
+var replayEnv sync.Once
+
+// O replay não chama a rede, mas os provedores recusam a chamada sem chave
+// ou endpoint configurado
+func prepareReplay() {
+	for _, p := range registry {
+		env := p.Key
+		if e, ok := keyEnv[p.Name]; ok {
+			env = e
+		}
+		if env != "" && os.Getenv(env) == "" {
+			os.Setenv(env, "replay")
+		}
+	}
+	if os.Getenv("AZURE_OPENAI_ENDPOINT") == "" {
+		os.Setenv("AZURE_OPENAI_ENDPOINT", "http://replay.invalid")
+	}
+	if os.Getenv("AZURE_OPENAI_DEPLOYMENT") == "" {
+		os.Setenv("AZURE_OPENAI_DEPLOYMENT", "replay")
+	}
+	if localURL == "" {
+		localURL = "http://replay.invalid"
+	}
+	if localModel == "" {
+		localModel = "replay"
+	}
+}
+
+// Replay reexecuta a fixture contra o código atual do provedor. Devolve também
+// as trocas com os pedidos que o código fez agora, no lugar dos gravados.
+// Um panic no parsing volta como erro.
+func Replay(f *Fixture) (result *Result, sent []Exchange, err error) {
+	p, ok := ByName(f.Provider)
+	if !ok || p.Name == Mock.Name {
+		return nil, nil, fmt.Errorf("unknown provider %q", f.Provider)
+	}
+	replayEnv.Do(prepareReplay)
+
+	t := &tape{replay: true, exchanges: append([]Exchange(nil), f.Exchanges...)}
+	r := f.Request
+	in := &Request{
+		Text:       r.Text,
+		System:     r.System,
+		History:    r.History,
+		Model:      r.Model,
+		Reasoning:  r.Reasoning,
+		Gemini:     r.Gemini,
+		MaxCostUSD: r.MaxCostUSD,
+		MaxTokens:  r.MaxTokens,
+		Stop:       r.Stop,
+		Route:      r.Route,
+		tape:       t,
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("panic: %v", r)
+		}
+		sent = t.exchanges[:t.next]
+	}()
+	result, err = p.Call(in)
+	return result, nil, Classify(p.Name, err)
+}
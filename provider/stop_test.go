@@ -0,0 +1,40 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+// O stop do pedido soma às stopSequences do gemini, sem repetir e até o
+// limite do Gemini
+func TestGeminiStopSequences(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested []string
+		stop      []string
+		want      []string
+	}{
+		{"só stop", nil, []string{"FIM"}, []string{"FIM"}},
+		{"só gemini", []string{"###"}, nil, []string{"###"}},
+		{"juntos", []string{"###"}, []string{"FIM", "\n\n"}, []string{"###", "FIM", "\n\n"}},
+		{"repetida", []string{"FIM", "###"}, []string{"FIM"}, []string{"FIM", "###"}},
+		{"acima do limite", []string{"a", "b", "c"}, []string{"d", "e", "f"}, []string{"a", "b", "c", "d", "e"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := &Request{Text: "olá", Stop: tt.stop}
+			if tt.requested != nil {
+				in.Gemini = &GeminiOptions{GenerationConfig: &GeminiGenerationConfig{StopSequences: tt.requested}}
+			}
+
+			generation, _ := geminiPayload(in)["generationConfig"].(*GeminiGenerationConfig)
+			if generation == nil {
+				t.Fatal("no generationConfig in payload")
+			}
+			if !reflect.DeepEqual(generation.StopSequences, tt.want) {
+				t.Errorf("stopSequences = %q, want %q", generation.StopSequences, tt.want)
+			}
+		})
+	}
+}
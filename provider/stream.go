@@ -0,0 +1,273 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/internal/env"
+)
+
+// StreamFunc gera a resposta em pedaços, chamando onChunk a cada trecho de texto.
+// O Result final traz o texto completo e o uso de tokens quando o provedor informa.
+type StreamFunc func(in *Request, onChunk func(string) error) (*Result, error)
+
+// Limites de streaming: o intervalo entre pedaços é vigiado à parte do tempo total.
+//
+//	STREAM_CHUNK_TIMEOUT=10s   silêncio máximo do provedor no meio do stream
+//	STREAM_TOTAL_TIMEOUT=120s  duração máxima de um stream inteiro
+var (
+	chunkTimeout = env.PositiveDuration("STREAM_CHUNK_TIMEOUT", 10*time.Second)
+	totalTimeout = env.PositiveDuration("STREAM_TOTAL_TIMEOUT", 120*time.Second)
+)
+
+// ErrStreamStalled indica que o provedor parou de enviar pedaços no meio do stream
+var ErrStreamStalled = errors.New("provider stream stalled")
+
+// Cliente separado: o ReadTimeout de 30s do client cortaria streams longos
+var streamClient = &fasthttp.Client{
+	MaxConnsPerHost:     1000,
+	MaxIdleConnDuration: 90 * time.Second,
+	ReadTimeout:         totalTimeout,
+	WriteTimeout:        30 * time.Second,
+	Dial:                dialStream,
+}
+
+// Conexões abertas pelo streamClient, pelo par de endereços. O fasthttp não
+// expõe a conexão de uma resposta em stream; o par de endereços dela acha a
+// conexão para o doStream fechar quando o stream para ou o chamador desiste,
+// soltando a leitura presa em vez de esperar o STREAM_TOTAL_TIMEOUT.
+var (
+	streamConnsMu sync.Mutex
+	streamConns   = map[string]*streamConn{}
+)
+
+type streamConn struct {
+	net.Conn
+	key string
+}
+
+func streamConnKey(local, remote net.Addr) string {
+	if local == nil || remote == nil {
+		return ""
+	}
+	return local.String() + "|" + remote.String()
+}
+
+func dialStream(addr string) (net.Conn, error) {
+	conn, err := fasthttp.Dial(addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &streamConn{Conn: conn, key: streamConnKey(conn.LocalAddr(), conn.RemoteAddr())}
+
+	streamConnsMu.Lock()
+	streamConns[c.key] = c
+	streamConnsMu.Unlock()
+	return c, nil
+}
+
+func (c *streamConn) Close() error {
+	streamConnsMu.Lock()
+	if streamConns[c.key] == c {
+		delete(streamConns, c.key)
+	}
+	streamConnsMu.Unlock()
+	return c.Conn.Close()
+}
+
+// connOf acha a conexão que carrega a resposta; nil se não foi o streamClient
+// que a abriu
+func connOf(resp *fasthttp.Response) net.Conn {
+	key := streamConnKey(resp.LocalAddr(), resp.RemoteAddr())
+
+	streamConnsMu.Lock()
+	defer streamConnsMu.Unlock()
+	if c, ok := streamConns[key]; ok {
+		return c
+	}
+	return nil
+}
+
+// doStream envia a requisição e entrega cada linha "data:" do SSE a onData.
+// Se o provedor ficar mais de STREAM_CHUNK_TIMEOUT sem enviar nada, devolve ErrStreamStalled.
+func doStream(req *fasthttp.Request, name string, onData func([]byte) error) error {
+	resp := fasthttp.AcquireResponse()
+	resp.StreamBody = true
+
+	if err := streamClient.Do(req, resp); err != nil {
+		fasthttp.ReleaseResponse(resp)
+		return networkError(name, err)
+	}
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		err := statusError(name, resp)
+		resp.CloseBodyStream()
+		fasthttp.ReleaseResponse(resp)
+		return err
+	}
+
+	// antes da goroutine, que libera a resposta ao terminar
+	conn := connOf(resp)
+
+	lines := make(chan []byte)
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+
+	// a leitura bloqueante fica numa goroutine própria; ela termina quando o
+	// provedor fecha o stream ou quando abort fecha a conexão
+	go func() {
+		err := readSSE(resp.BodyStream(), func(data []byte) error {
+			select {
+			case lines <- append([]byte(nil), data...):
+				return nil
+			case <-stop:
+				return ErrStreamStalled
+			}
+		})
+		if err != nil {
+			// conexão fechada ou com o stream pela metade não volta ao pool
+			resp.SetConnectionClose()
+		}
+		resp.CloseBodyStream()
+		fasthttp.ReleaseResponse(resp)
+		close(lines)
+		done <- err
+	}()
+
+	// abort desiste do stream: sem fechar a conexão, a goroutine e ela ficariam
+	// presas na leitura até o STREAM_TOTAL_TIMEOUT
+	abort := func() {
+		close(stop)
+		if conn != nil {
+			conn.Close()
+		}
+	}
+
+	watchdog := time.NewTimer(chunkTimeout)
+	defer watchdog.Stop()
+
+	for {
+		select {
+		case data, ok := <-lines:
+			if !ok {
+				return <-done
+			}
+			watchdog.Reset(chunkTimeout)
+			if err := onData(data); err != nil {
+				abort()
+				return err
+			}
+		case <-watchdog.C:
+			abort()
+			log.Printf("⏱️  Stream de %s parado há mais de %s", name, chunkTimeout)
+			return ErrStreamStalled
+		}
+	}
+}
+
+func readSSE(body io.Reader, onData func([]byte) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+
+		data := bytes.TrimSpace(line[len("data:"):])
+		if string(data) == "[DONE]" {
+			return nil
+		}
+
+		if err := onData(data); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Pedaço de stream no formato OpenAI
+type chatChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content          string `json:"content"`
+			Reasoning        string `json:"reasoning"`
+			ReasoningContent string `json:"reasoning_content"` // DeepSeek
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// streamChatCompletion faz streaming de um endpoint compatível com OpenAI.
+// O raciocínio não é repassado ao cliente, só acumulado no Result.
+func streamChatCompletion(req *fasthttp.Request, name string, onChunk func(string) error) (*Result, error) {
+	var text, thought strings.Builder
+	var usage Usage
+
+	err := doStream(req, name, func(data []byte) error {
+		var chunk chatChunk
+		if err := sonic.Unmarshal(data, &chunk); err != nil {
+			return err
+		}
+
+		if chunk.Usage != nil {
+			usage.PromptTokens = chunk.Usage.PromptTokens
+			usage.CompletionTokens = chunk.Usage.CompletionTokens
+		}
+
+		if len(chunk.Choices) == 0 {
+			return nil
+		}
+
+		delta := chunk.Choices[0].Delta
+		thought.WriteString(delta.Reasoning)
+		thought.WriteString(delta.ReasoningContent)
+		if delta.Content == "" {
+			return nil
+		}
+
+		text.WriteString(delta.Content)
+		return onChunk(delta.Content)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Text: text.String(), Reasoning: thought.String()}
+	if result.Reasoning != "" {
+		result.ReasoningTokens = EstimateTokens(result.Reasoning)
+		usage.CompletionTokens = max(usage.CompletionTokens-result.ReasoningTokens, 0)
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	result.Usage = usage
+	return result, nil
+}
+
+// newChatRequest prepara o POST de chat/completions com streaming ligado
+func newChatRequest(url, apiKey string, payload map[string]interface{}) *fasthttp.Request {
+	payload["stream"] = true
+	jsonData, _ := sonic.Marshal(payload)
+
+	req := fasthttp.AcquireRequest()
+	req.SetRequestURI(url)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.SetContentType("application/json")
+	req.SetBody(jsonData)
+	return req
+}
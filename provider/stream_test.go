@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// stallingUpstream manda uma linha SSE e para de escrever sem fechar a
+// conexão; closed recebe quando o cliente fecha a conexão
+func stallingUpstream(t *testing.T) (url string, closed <-chan struct{}) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan struct{})
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		br := bufio.NewReader(conn)
+		req, err := http.ReadRequest(br)
+		if err != nil {
+			return
+		}
+		req.Body.Close()
+
+		line := "data: {\"choices\":[{\"delta\":{\"content\":\"Olá\"}}]}\n\n"
+		fmt.Fprintf(conn, "HTTP/1.1 200 OK\r\nContent-Type: text/event-stream\r\nTransfer-Encoding: chunked\r\n\r\n%x\r\n%s\r\n", len(line), line)
+
+		// só volta quando o cliente fecha a conexão
+		conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+		if _, err := br.ReadByte(); err != nil && !errors.Is(err, net.ErrClosed) {
+			var timeout net.Error
+			if errors.As(err, &timeout) && timeout.Timeout() {
+				return
+			}
+			close(ch)
+		}
+	}()
+	return "http://" + ln.Addr().String() + "/v1/chat/completions", ch
+}
+
+func streamRequest(url string) *fasthttp.Request {
+	req := fasthttp.AcquireRequest()
+	req.SetRequestURI(url)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.SetBodyString(`{"stream":true}`)
+	return req
+}
+
+// O stream parado ou abandonado fecha a conexão com o provedor na hora, sem
+// esperar o STREAM_TOTAL_TIMEOUT
+func TestDoStreamClosesUpstream(t *testing.T) {
+	saved := chunkTimeout
+	chunkTimeout = 100 * time.Millisecond
+	defer func() { chunkTimeout = saved }()
+
+	errStop := errors.New("stop")
+	tests := []struct {
+		name   string
+		onData func([]byte) error
+		want   error
+	}{
+		{"watchdog", func([]byte) error { return nil }, ErrStreamStalled},
+		{"chamador desiste", func([]byte) error { return errStop }, errStop},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, closed := stallingUpstream(t)
+			req := streamRequest(url)
+			defer fasthttp.ReleaseRequest(req)
+
+			if err := doStream(req, "test", tt.onData); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+
+			select {
+			case <-closed:
+			case <-time.After(2 * time.Second):
+				t.Fatal("upstream connection still open after doStream returned")
+			}
+		})
+	}
+}
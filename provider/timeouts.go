@@ -0,0 +1,196 @@
+package provider
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+)
+
+// Timeouts das chamadas aos provedores, por provedor e por rota, definidos em
+// UPSTREAM_TIMEOUTS:
+//
+//	[{"provider":"gemini","upstream":"10s"},
+//	 {"route":"/flashcards","upstream":"45s"},
+//	 {"provider":"local","route":"/ai/async","upstream":"3m","read":"3m"}]
+//
+// upstream é o prazo da chamada inteira, do envio ao fim da resposta; read e
+// write limitam a leitura da resposta e a escrita do pedido na conexão, e read
+// vazio acompanha o upstream da mesma entrada. Cada campo é resolvido à
+// parte, do mais específico ao mais geral: provedor e rota, só a rota, só o
+// provedor e por último o padrão de 30s. As entradas de UPSTREAM_TIMEOUTS
+// ganham das padrão. A transcrição usa o provedor "whisper". Streams seguem
+// STREAM_CHUNK_TIMEOUT e STREAM_TOTAL_TIMEOUT.
+type TimeoutConfig struct {
+	Provider string `json:"provider,omitempty"`
+	Route    string `json:"route,omitempty"` // caminho HTTP ou grpc:Método
+	Read     string `json:"read,omitempty"`
+	Write    string `json:"write,omitempty"`
+	Upstream string `json:"upstream,omitempty"`
+
+	read, write, upstream time.Duration
+}
+
+// Timeouts efetivos de uma chamada
+type Timeouts struct {
+	Read     time.Duration
+	Write    time.Duration
+	Upstream time.Duration
+}
+
+// Padrão de quem não tem entrada; era o timeout único do client
+var fallbackTimeouts = Timeouts{Read: 30 * time.Second, Write: 30 * time.Second, Upstream: 30 * time.Second}
+
+// Chat precisa responder rápido para o fallback ter tempo de agir; o áudio
+// do Whisper sobe devagar e leva mais para transcrever
+var defaultTimeouts = []TimeoutConfig{
+	{Provider: "gemini", Upstream: "15s"},
+	{Provider: "mistral", Upstream: "25s"},
+	{Provider: "groq", Upstream: "20s"},
+	{Provider: "huggingface", Upstream: "60s"}, // modelo frio carrega na primeira chamada
+	{Provider: "local", Upstream: "60s"},
+	{Provider: "whisper", Write: "60s", Upstream: "120s"},
+}
+
+var timeoutTable = loadTimeouts(os.Getenv("UPSTREAM_TIMEOUTS"))
+
+func loadTimeouts(raw string) []TimeoutConfig {
+	list := []TimeoutConfig{}
+	if raw != "" {
+		var custom []TimeoutConfig
+		if err := sonic.UnmarshalString(raw, &custom); err != nil {
+			log.Printf("⚠️  UPSTREAM_TIMEOUTS inválido, usando a tabela padrão: %v", err)
+		} else {
+			list = append(list, custom...)
+		}
+	}
+	list = append(list, defaultTimeouts...)
+
+	// as entradas de UPSTREAM_TIMEOUTS vêm antes e ganham na busca
+	out := make([]TimeoutConfig, 0, len(list))
+	for _, t := range list {
+		if t.Provider == "" && t.Route == "" {
+			log.Printf("⚠️  Timeout sem provider nem route ignorado em UPSTREAM_TIMEOUTS")
+			continue
+		}
+		if !t.parse() {
+			log.Printf("⚠️  Timeout inválido ignorado em UPSTREAM_TIMEOUTS: %q em %q", t.Route, t.Provider)
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+func (t *TimeoutConfig) parse() bool {
+	var okRead, okWrite, okUpstream bool
+	t.read, okRead = parseTimeout(t.Read)
+	t.write, okWrite = parseTimeout(t.Write)
+	t.upstream, okUpstream = parseTimeout(t.Upstream)
+	return okRead && okWrite && okUpstream
+}
+
+// parseTimeout aceita vazio (não definido) ou uma duração positiva
+func parseTimeout(raw string) (time.Duration, bool) {
+	if raw == "" {
+		return 0, true
+	}
+	d, err := time.ParseDuration(raw)
+	return d, err == nil && d > 0
+}
+
+// TimeoutsFor resolve os timeouts de uma chamada ao provedor feita pela rota
+func TimeoutsFor(name, route string) Timeouts {
+	var out Timeouts
+	for _, match := range []func(TimeoutConfig) bool{
+		func(t TimeoutConfig) bool { return route != "" && t.Provider == name && t.Route == route },
+		func(t TimeoutConfig) bool { return route != "" && t.Provider == "" && t.Route == route },
+		func(t TimeoutConfig) bool { return t.Provider == name && t.Route == "" },
+	} {
+		for _, t := range timeoutTable {
+			if !match(t) {
+				continue
+			}
+			if out.Read == 0 {
+				out.Read = t.read
+				if out.Read == 0 {
+					out.Read = t.upstream
+				}
+			}
+			if out.Write == 0 {
+				out.Write = t.write
+			}
+			if out.Upstream == 0 {
+				out.Upstream = t.upstream
+			}
+		}
+	}
+
+	if out.Read == 0 {
+		out.Read = fallbackTimeouts.Read
+	}
+	if out.Write == 0 {
+		out.Write = fallbackTimeouts.Write
+	}
+	if out.Upstream == 0 {
+		out.Upstream = fallbackTimeouts.Upstream
+	}
+	return out
+}
+
+// Um client por par de read/write: os timeouts de conexão do fasthttp são do
+// client, não do pedido. O par padrão usa o client compartilhado.
+var (
+	clientsMu sync.Mutex
+	clients   = map[[2]time.Duration]*fasthttp.Client{
+		{fallbackTimeouts.Read, fallbackTimeouts.Write}: client,
+	}
+)
+
+func clientFor(t Timeouts) *fasthttp.Client {
+	key := [2]time.Duration{t.Read, t.Write}
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	c, ok := clients[key]
+	if !ok {
+		c = &fasthttp.Client{
+			MaxConnsPerHost:     client.MaxConnsPerHost,
+			MaxIdleConnDuration: client.MaxIdleConnDuration,
+			ReadTimeout:         t.Read,
+			WriteTimeout:        t.Write,
+		}
+		clients[key] = c
+	}
+	return c
+}
+
+// clientsFor lista os clients que do usa para o provedor: o das chamadas sem
+// rota própria e o de cada rota com entrada em UPSTREAM_TIMEOUTS, sem repetir
+func clientsFor(name string) []*fasthttp.Client {
+	seen := map[*fasthttp.Client]bool{}
+	var out []*fasthttp.Client
+	add := func(t Timeouts) {
+		if c := clientFor(t); !seen[c] {
+			seen[c] = true
+			out = append(out, c)
+		}
+	}
+
+	add(TimeoutsFor(name, ""))
+	for _, t := range timeoutTable {
+		if t.Route != "" && (t.Provider == "" || t.Provider == name) {
+			add(TimeoutsFor(name, t.Route))
+		}
+	}
+	return out
+}
+
+// do envia a chamada ao provedor com os timeouts dele na rota
+func do(name, route string, req *fasthttp.Request, resp *fasthttp.Response) error {
+	t := TimeoutsFor(name, route)
+	return clientFor(t).DoTimeout(req, resp, t.Upstream)
+}
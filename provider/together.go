@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+)
+
+const togetherURL = "https://api.together.xyz/v1/chat/completions"
+
+func togetherPayload(in *Request) map[string]interface{} {
+	payload := map[string]interface{}{
+		"model":       "meta-llama/Llama-3.3-70B-Instruct-Turbo-Free",
+		"messages":    chatMessages(in),
+		"max_tokens":  1000,
+		"temperature": 0.7,
+	}
+
+	if in.Model != "" {
+		payload["model"] = in.Model
+	}
+
+	if in.Reasoning {
+		payload["model"] = togetherReasoningModel
+		payload["max_tokens"] = 4000
+		payload["temperature"] = 0.6
+	}
+	capTokens(payload, "max_tokens", in)
+	setStop(payload, "stop", in)
+	return payload
+}
+
+// CallTogether chama a Together AI (formato OpenAI)
+func CallTogether(in *Request) (*Result, error) {
+	apiKey, err := in.apiKey("together", "TOGETHER_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	jsonData, _ := sonic.Marshal(togetherPayload(in))
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(togetherURL)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.SetContentType("application/json")
+	req.SetBody(jsonData)
+
+	if err := in.do("together", req, resp); err != nil {
+		return nil, networkError("together", err)
+	}
+
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, statusError("together", resp)
+	}
+
+	result, err := parseChatCompletion(resp.Body())
+	if err != nil {
+		return nil, badResponse("together", err)
+	}
+	return result, nil
+}
+
+// StreamTogether faz streaming do chat/completions da Together
+func StreamTogether(in *Request, onChunk func(string) error) (*Result, error) {
+	apiKey, err := in.apiKey("together", "TOGETHER_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	req := newChatRequest(togetherURL, apiKey, togetherPayload(in))
+	defer fasthttp.ReleaseRequest(req)
+
+	return streamChatCompletion(req, "together", onChunk)
+}
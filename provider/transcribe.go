@@ -0,0 +1,127 @@
+package provider
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/internal/env"
+)
+
+// Whisper na Groq (formato OpenAI de audio/transcriptions)
+const groqTranscriptionURL = "https://api.groq.com/openai/v1/audio/transcriptions"
+
+// WHISPER_MODEL: modelo da transcrição; padrão whisper-large-v3-turbo
+var whisperModel = env.String("WHISPER_MODEL", "whisper-large-v3-turbo")
+
+// Transcript é a fala transcrita, com o tempo de cada palavra em segundos
+type Transcript struct {
+	Text     string
+	Language string
+	Words    []TranscribedWord
+	Provider string
+}
+
+type TranscribedWord struct {
+	Word  string
+	Start float64
+	End   float64
+}
+
+// Resposta verbose_json com timestamp_granularities[]=word
+type whisperVerbose struct {
+	Text     string `json:"text"`
+	Language string `json:"language"`
+	Words    []struct {
+		Word  string  `json:"word"`
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+	} `json:"words"`
+}
+
+// Transcribe transcreve o áudio com o Whisper; no MOCK_MODE devolve
+// MOCK_TRANSCRIPT ou, sem ele, o próprio arquivo quando é texto (eco).
+// lang é a dica de idioma (código ISO-639-1); vazio deixa o Whisper detectar.
+// route escolhe os timeouts do provedor "whisper" em UPSTREAM_TIMEOUTS.
+func Transcribe(audio []byte, filename, lang, route string) (*Transcript, error) {
+	if MockMode() {
+		return transcribeMock(audio)
+	}
+
+	key, err := pickKey("groq", keyEnv["groq"])
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if filename == "" {
+		filename = "audio"
+	}
+	part, _ := form.CreateFormFile("file", filename)
+	part.Write(audio)
+	form.WriteField("model", whisperModel)
+	form.WriteField("response_format", "verbose_json")
+	form.WriteField("timestamp_granularities[]", "word")
+	form.WriteField("temperature", "0")
+	// a frase alvo não vai como prompt: o Whisper puxaria a transcrição para ela
+	if len(lang) == 2 {
+		form.WriteField("language", lang)
+	}
+	form.Close()
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(groqTranscriptionURL)
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.Set("Authorization", "Bearer "+key.value)
+	req.Header.SetContentType(form.FormDataContentType())
+	req.SetBody(body.Bytes())
+
+	if err := do("whisper", route, req, resp); err != nil {
+		return nil, networkError("groq", err)
+	}
+	if resp.StatusCode() != fasthttp.StatusOK {
+		err := statusError("groq", resp)
+		key.report(err)
+		return nil, err
+	}
+
+	var out whisperVerbose
+	if err := sonic.Unmarshal(resp.Body(), &out); err != nil {
+		return nil, badResponse("groq", err)
+	}
+
+	t := &Transcript{Text: strings.TrimSpace(out.Text), Language: out.Language, Provider: "groq:" + whisperModel}
+	for _, w := range out.Words {
+		t.Words = append(t.Words, TranscribedWord{Word: strings.TrimSpace(w.Word), Start: w.Start, End: w.End})
+	}
+	return t, nil
+}
+
+// transcribeMock distribui as palavras em 400ms cada
+func transcribeMock(audio []byte) (*Transcript, error) {
+	text := os.Getenv("MOCK_TRANSCRIPT")
+	if text == "" {
+		if !utf8.Valid(audio) {
+			return nil, errors.New("mock transcription needs MOCK_TRANSCRIPT or a UTF-8 file")
+		}
+		text = string(audio)
+	}
+
+	t := &Transcript{Text: strings.TrimSpace(text), Provider: "mock"}
+	for i, w := range strings.Fields(text) {
+		start := float64(i*400) / 1000
+		t.Words = append(t.Words, TranscribedWord{Word: w, Start: start, End: float64(i*400+350) / 1000})
+	}
+	return t, nil
+}
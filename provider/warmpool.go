@@ -0,0 +1,450 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/internal/env"
+)
+
+// Pool de modelos quentes do provedor local. Carregar um modelo no Ollama leva
+// até um minuto; sem o pool, o fallback local pagaria isso justo quando os
+// provedores externos caíram.
+//
+//	LOCAL_LLM_WARM=llama3.1:8b,qwen2.5:7b@07:00-23:00
+//	    modelos mantidos carregados; com @início-fim só dentro da janela
+//	    (LOCAL_LLM_TZ, padrão UTC), e descarregados fora dela. Sem a
+//	    variável, LOCAL_LLM_MODEL fica sempre carregado.
+//	LOCAL_LLM_WARM_INTERVAL=1m   intervalo da checagem de saúde e do pool
+//	LOCAL_LLM_LOAD_TIMEOUT=5m    espera máxima pelo carregamento de um modelo
+//	LOCAL_LLM_ALLOW_COLD=1       chama modelo frio e espera carregar, em vez de recusar
+//
+// O vLLM serve modelos fixos, escolhidos ao subir o servidor: o pool só
+// acompanha a saúde e a lista de modelos servidos.
+var (
+	warmInterval    = env.PositiveDuration("LOCAL_LLM_WARM_INTERVAL", time.Minute)
+	warmLoadTimeout = env.PositiveDuration("LOCAL_LLM_LOAD_TIMEOUT", 5*time.Minute)
+	allowCold       = os.Getenv("LOCAL_LLM_ALLOW_COLD") == "1" || os.Getenv("LOCAL_LLM_ALLOW_COLD") == "true"
+)
+
+// Cliente do pool: carregar um modelo passa dos 30s do client
+var warmClient = &fasthttp.Client{
+	MaxConnsPerHost: 4,
+	ReadTimeout:     warmLoadTimeout,
+	WriteTimeout:    30 * time.Second,
+}
+
+// Modelo do pool; sem janela fica sempre carregado
+type warmModel struct {
+	name     string
+	from, to time.Duration // desde a meia-noite; to < from atravessa a meia-noite
+	window   bool
+}
+
+// active diz se o modelo deve estar carregado em now
+func (m warmModel) active(now time.Time) bool {
+	if !m.window {
+		return true
+	}
+	t := now.In(warmLocation)
+	since := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if m.from <= m.to {
+		return since >= m.from && since < m.to
+	}
+	return since >= m.from || since < m.to
+}
+
+var (
+	warmLocation = loadWarmLocation(os.Getenv("LOCAL_LLM_TZ"))
+	warmModels   = loadWarmModels(os.Getenv("LOCAL_LLM_WARM"))
+)
+
+func loadWarmLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Printf("⚠️  LOCAL_LLM_TZ inválido, usando UTC")
+		return time.UTC
+	}
+	return loc
+}
+
+func loadWarmModels(raw string) []warmModel {
+	if raw == "" {
+		if localModel == "" {
+			return nil
+		}
+		return []warmModel{{name: ollamaName(localModel)}}
+	}
+
+	var models []warmModel
+	for _, entry := range strings.Split(raw, ",") {
+		name, window, hasWindow := strings.Cut(strings.TrimSpace(entry), "@")
+		if name == "" {
+			continue
+		}
+		m := warmModel{name: ollamaName(name)}
+		if hasWindow {
+			from, to, ok := parseWindow(window)
+			if !ok {
+				log.Printf("⚠️  Janela %q inválida para %s em LOCAL_LLM_WARM, mantendo sempre carregado", window, name)
+			}
+			m.from, m.to, m.window = from, to, ok
+		}
+		models = append(models, m)
+	}
+	return models
+}
+
+// parseWindow lê HH:MM-HH:MM
+func parseWindow(raw string) (from, to time.Duration, ok bool) {
+	start, end, found := strings.Cut(raw, "-")
+	if !found {
+		return 0, 0, false
+	}
+	a, errA := time.Parse("15:04", strings.TrimSpace(start))
+	b, errB := time.Parse("15:04", strings.TrimSpace(end))
+	if errA != nil || errB != nil || a.Equal(b) {
+		return 0, 0, false
+	}
+	offset := func(t time.Time) time.Duration {
+		return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	return offset(a), offset(b), true
+}
+
+// ollamaName completa a tag implícita: "llama3.1" e "llama3.1:latest" são o mesmo modelo
+func ollamaName(name string) string {
+	if localBackend == "ollama" && !strings.Contains(name, ":") {
+		return name + ":latest"
+	}
+	return name
+}
+
+// LoadedModel é um modelo carregado no backend local
+type LoadedModel struct {
+	Name      string     `json:"name"`
+	SizeVRAM  int64      `json:"size_vram,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Ollama descarrega nesse horário
+	Warm      bool       `json:"warm"`                 // mantido pelo pool agora
+}
+
+// LocalStatus é o estado do backend local em GET /status
+type LocalStatus struct {
+	Backend   string        `json:"backend"`
+	Healthy   bool          `json:"healthy"`
+	Error     string        `json:"error,omitempty"`
+	CheckedAt *time.Time    `json:"checked_at,omitempty"`
+	Loaded    []LoadedModel `json:"loaded"`
+	Warm      []string      `json:"warm"`              // modelos que o pool mantém carregados agora
+	Loading   []string      `json:"loading,omitempty"` // carregamentos em andamento
+}
+
+type warmPool struct {
+	mu      sync.Mutex
+	checked time.Time
+	healthy bool
+	err     string
+	loaded  map[string]LoadedModel
+	loading map[string]bool
+}
+
+var pool = &warmPool{loaded: map[string]LoadedModel{}, loading: map[string]bool{}}
+
+// WarmLocal checa o backend local a cada LOCAL_LLM_WARM_INTERVAL e mantém os
+// modelos de LOCAL_LLM_WARM carregados; não faz nada sem LOCAL_LLM_URL
+func WarmLocal() {
+	if localURL == "" || MockMode() {
+		return
+	}
+
+	names := make([]string, len(warmModels))
+	for i, m := range warmModels {
+		names[i] = m.name
+	}
+	log.Printf("🔥 Pool local (%s em %s): %s", localBackend, localURL, strings.Join(names, ", "))
+
+	for {
+		pool.refresh(time.Now())
+		time.Sleep(warmInterval)
+	}
+}
+
+// refresh atualiza a saúde e os modelos carregados, carrega os modelos da
+// janela atual e descarrega os que saíram dela. Os carregamentos são em
+// sequência para não disputar a VRAM.
+func (p *warmPool) refresh(now time.Time) {
+	loaded, err := probeLocal()
+	p.mu.Lock()
+	p.checked = time.Now()
+	p.healthy = err == nil
+	p.err = ""
+	p.loaded = loaded
+	if err != nil {
+		// com o backend fora não dá para saber o que segue carregado
+		p.err = err.Error()
+		p.loaded = map[string]LoadedModel{}
+	}
+	p.mu.Unlock()
+
+	if err != nil {
+		log.Printf("⚠️  Backend local fora: %v", err)
+		return
+	}
+	if localBackend != "ollama" {
+		return
+	}
+
+	for _, m := range warmModels {
+		current, isLoaded := loaded[m.name]
+		switch {
+		case m.active(now):
+			// keep_alive -1 não expira; expiração próxima quer dizer que uma
+			// chamada comum trocou o keep_alive pelo padrão do servidor
+			if isLoaded && (current.ExpiresAt == nil || current.ExpiresAt.After(now.Add(2*warmInterval))) {
+				continue
+			}
+			p.load(m.name, -1)
+		case isLoaded && m.window:
+			p.load(m.name, 0)
+		}
+	}
+}
+
+// load carrega (keepAlive -1) ou descarrega (keepAlive 0) o modelo no Ollama;
+// nil usa o keep_alive padrão do servidor
+func (p *warmPool) load(model string, keepAlive interface{}) {
+	p.mu.Lock()
+	if p.loading[model] {
+		p.mu.Unlock()
+		return
+	}
+	p.loading[model] = true
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.loading, model)
+		p.mu.Unlock()
+	}()
+
+	body := map[string]interface{}{"model": model}
+	if keepAlive != nil {
+		body["keep_alive"] = keepAlive
+	}
+	jsonData, _ := sonic.Marshal(body)
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(localURL + "/api/generate")
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.Header.SetContentType("application/json")
+	req.SetBody(jsonData)
+
+	unload := keepAlive == 0
+	start := time.Now()
+	if err := warmClient.Do(req, resp); err != nil {
+		log.Printf("⚠️  Falha ao carregar %s no backend local: %v", model, err)
+		return
+	}
+	if resp.StatusCode() != fasthttp.StatusOK {
+		log.Printf("⚠️  Falha ao carregar %s no backend local: status %d: %s", model, resp.StatusCode(), upstreamDetail(resp.Body()))
+		return
+	}
+
+	p.mu.Lock()
+	if unload {
+		delete(p.loaded, model)
+	} else if _, ok := p.loaded[model]; !ok {
+		p.loaded[model] = LoadedModel{Name: model}
+	}
+	p.mu.Unlock()
+
+	if unload {
+		log.Printf("🧊 Modelo local %s descarregado (fora da janela)", model)
+		return
+	}
+	log.Printf("🔥 Modelo local %s carregado em %s", model, time.Since(start).Round(100*time.Millisecond))
+}
+
+// ready confere o modelo antes da chamada. Sem checagem recente deixa passar;
+// com o backend fora ou o modelo frio recusa na hora com erro retentável, e o
+// modelo frio começa a carregar em segundo plano.
+func (p *warmPool) ready(model string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if model == "" {
+		// o vLLM serve modelos fixos: sem LOCAL_LLM_MODEL usa o primeiro
+		if localBackend != "ollama" {
+			for name := range p.loaded {
+				if model == "" || name < model {
+					model = name
+				}
+			}
+		}
+		if model == "" {
+			return "", notConfigured("local", "local LLM model not configured")
+		}
+	}
+	model = ollamaName(model)
+
+	if p.checked.IsZero() || time.Since(p.checked) > 3*warmInterval {
+		return model, nil
+	}
+	if !p.healthy {
+		return "", &UpstreamError{
+			Provider:   "local",
+			Code:       CodeUnavailable,
+			Retryable:  true,
+			RetryAfter: warmInterval,
+			Detail:     "local backend is down: " + p.err,
+		}
+	}
+	if _, ok := p.loaded[model]; ok {
+		return model, nil
+	}
+
+	if localBackend != "ollama" {
+		return "", &UpstreamError{Provider: "local", Code: CodeModelNotFound, Detail: "model " + model + " is not served by the local backend"}
+	}
+	if allowCold {
+		return model, nil
+	}
+	if !p.loading[model] {
+		go p.load(model, nil)
+	}
+	return "", &UpstreamError{
+		Provider:   "local",
+		Code:       CodeUnavailable,
+		Retryable:  true,
+		RetryAfter: 30 * time.Second,
+		Detail:     "model " + model + " is cold, loading in background",
+	}
+}
+
+// markDown registra a falha de rede de uma chamada, sem esperar a próxima checagem
+func (p *warmPool) markDown(err error) {
+	p.mu.Lock()
+	p.checked = time.Now()
+	p.healthy = false
+	p.err = err.Error()
+	p.mu.Unlock()
+}
+
+// Resposta do GET /api/ps do Ollama
+type ollamaPS struct {
+	Models []struct {
+		Name      string    `json:"name"`
+		SizeVRAM  int64     `json:"size_vram"`
+		ExpiresAt time.Time `json:"expires_at"`
+	} `json:"models"`
+}
+
+// Resposta do GET /v1/models do vLLM
+type vllmModels struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+}
+
+// probeLocal lista os modelos carregados: /api/ps no Ollama, /v1/models no vLLM
+func probeLocal() (map[string]LoadedModel, error) {
+	path := "/api/ps"
+	if localBackend != "ollama" {
+		path = "/v1/models"
+	}
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(localURL + path)
+	req.Header.SetMethod(fasthttp.MethodGet)
+	if key := os.Getenv("LOCAL_LLM_KEY"); key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	if err := client.DoTimeout(req, resp, 10*time.Second); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != fasthttp.StatusOK {
+		return nil, fmt.Errorf("status %d from %s", resp.StatusCode(), path)
+	}
+
+	loaded := map[string]LoadedModel{}
+	if localBackend != "ollama" {
+		var out vllmModels
+		if err := sonic.Unmarshal(resp.Body(), &out); err != nil {
+			return nil, errors.New("malformed " + path + " response")
+		}
+		for _, m := range out.Data {
+			loaded[m.ID] = LoadedModel{Name: m.ID}
+		}
+		return loaded, nil
+	}
+
+	var out ollamaPS
+	if err := sonic.Unmarshal(resp.Body(), &out); err != nil {
+		return nil, errors.New("malformed " + path + " response")
+	}
+	for _, m := range out.Models {
+		entry := LoadedModel{Name: m.Name, SizeVRAM: m.SizeVRAM}
+		if !m.ExpiresAt.IsZero() {
+			expires := m.ExpiresAt
+			entry.ExpiresAt = &expires
+		}
+		loaded[m.Name] = entry
+	}
+	return loaded, nil
+}
+
+// Local devolve o estado do backend local; false sem LOCAL_LLM_URL
+func Local() (LocalStatus, bool) {
+	if localURL == "" {
+		return LocalStatus{}, false
+	}
+
+	now := time.Now()
+	warm := map[string]bool{}
+	status := LocalStatus{Backend: localBackend, Loaded: []LoadedModel{}, Warm: []string{}}
+	if localBackend == "ollama" {
+		for _, m := range warmModels {
+			if m.active(now) {
+				warm[m.name] = true
+				status.Warm = append(status.Warm, m.name)
+			}
+		}
+	}
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	status.Healthy, status.Error = pool.healthy, pool.err
+	if !pool.checked.IsZero() {
+		checked := pool.checked
+		status.CheckedAt = &checked
+	}
+	for _, m := range pool.loaded {
+		m.Warm = warm[m.Name]
+		status.Loaded = append(status.Loaded, m)
+	}
+	for name := range pool.loading {
+		status.Loading = append(status.Loading, name)
+	}
+	sort.Slice(status.Loaded, func(i, j int) bool { return status.Loaded[i].Name < status.Loaded[j].Name })
+	sort.Strings(status.Loading)
+	return status, true
+}
@@ -0,0 +1,179 @@
+package provider
+
+import (
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/internal/env"
+)
+
+// Hosts aquecidos pelo Warmup. Depois de um cold start os pools dos clients do
+// provedor estão vazios e o primeiro pedido a cada provedor paga DNS + TCP +
+// TLS; um HEAD na raiz do host deixa a conexão pronta por MaxIdleConnDuration.
+// Cada client tem o seu pool: o das chamadas sem rota própria, o de cada rota
+// com timeouts próprios em UPSTREAM_TIMEOUTS e o de streaming são aquecidos.
+var warmHosts = map[string]string{
+	"gemini":      "https://generativelanguage.googleapis.com/",
+	"mistral":     "https://api.mistral.ai/",
+	"groq":        "https://api.groq.com/",
+	"cohere":      "https://api.cohere.ai/",
+	"openrouter":  "https://openrouter.ai/",
+	"deepseek":    "https://api.deepseek.com/",
+	"together":    "https://api.together.xyz/",
+	"huggingface": "https://api-inference.huggingface.co/",
+}
+
+// WARMUP_TIMEOUT: espera máxima por provedor no aquecimento
+var warmupTimeout = env.PositiveDuration("WARMUP_TIMEOUT", 5*time.Second)
+
+// Resultado do aquecimento de um provedor
+type WarmResult struct {
+	Provider string `json:"provider"`
+	Host     string `json:"host"`
+	Pools    int    `json:"pools"` // clients aquecidos
+	OK       bool   `json:"ok"`    // todos os pools aquecidos
+	Millis   int64  `json:"ms"`    // do pool mais lento
+	Error    string `json:"error,omitempty"`
+}
+
+// warmURL devolve a URL aquecida do provedor; vazio quando não há host fixo
+func warmURL(name string) string {
+	switch name {
+	case "azure":
+		if endpoint := strings.TrimRight(os.Getenv("AZURE_OPENAI_ENDPOINT"), "/"); endpoint != "" {
+			return endpoint + "/"
+		}
+		return ""
+	case "local":
+		if localURL != "" {
+			return localURL + "/"
+		}
+		return ""
+	}
+	return warmHosts[name]
+}
+
+// Warmup abre em paralelo uma conexão com cada provedor configurado. Qualquer
+// resposta HTTP conta como sucesso: o que importa é a conexão TLS ficar no
+// pool. No MOCK_MODE nenhum provedor real é chamado.
+func Warmup() []WarmResult {
+	PretouchResponses()
+	if MockMode() {
+		return []WarmResult{}
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	results := []WarmResult{}
+	for _, p := range registry {
+		target := warmURL(p.Name)
+		if target == "" || !p.Configured() {
+			continue
+		}
+
+		clients := clientsFor(p.Name)
+		if p.Stream != nil {
+			clients = append(clients, streamClient)
+		}
+
+		wg.Add(1)
+		go func(name, target string, clients []*fasthttp.Client) {
+			defer wg.Done()
+			r := warmProvider(name, target, clients)
+			mu.Lock()
+			results = append(results, r)
+			mu.Unlock()
+		}(p.Name, target, clients)
+	}
+	wg.Wait()
+
+	// ordem do registro, para a resposta não mudar a cada chamada
+	order := map[string]int{}
+	for i, p := range registry {
+		order[p.Name] = i
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return order[results[i].Provider] < order[results[j].Provider]
+	})
+	return results
+}
+
+// warmProvider aquece em paralelo o pool de cada client do provedor
+func warmProvider(name, target string, clients []*fasthttp.Client) WarmResult {
+	errs := make([]error, len(clients))
+	took := make([]time.Duration, len(clients))
+
+	var wg sync.WaitGroup
+	for i, c := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			errs[i] = warmOne(c, target)
+			took[i] = time.Since(start)
+		}()
+	}
+	wg.Wait()
+
+	r := WarmResult{Provider: name, Host: hostOf(target), Pools: len(clients), OK: true}
+	for i, err := range errs {
+		r.Millis = max(r.Millis, took[i].Milliseconds())
+		if err != nil && r.OK {
+			r.OK, r.Error = false, err.Error()
+			log.Printf("⚠️  Aquecimento de %s falhou: %v", name, err)
+		}
+	}
+	return r
+}
+
+func warmOne(c *fasthttp.Client, target string) error {
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI(target)
+	req.Header.SetMethod(fasthttp.MethodHead)
+	return c.DoTimeout(req, resp, warmupTimeout)
+}
+
+func hostOf(target string) string {
+	var uri fasthttp.URI
+	if err := uri.Parse(nil, []byte(target)); err != nil {
+		return ""
+	}
+	return string(uri.Host())
+}
+
+var pretouchOnce sync.Once
+
+// PretouchResponses compila de antemão os decodificadores do sonic para as
+// respostas dos provedores; sem isso o primeiro pedido de cada formato paga
+// a compilação JIT
+func PretouchResponses() {
+	pretouchOnce.Do(func() {
+		Pretouch(
+			chatCompletion{}, chatChunk{}, geminiChunk{},
+			[]huggingFaceOutput{}, huggingFaceError{},
+			map[string]interface{}{}, Request{}, Result{},
+		)
+	})
+}
+
+// Pretouch compila o sonic para o tipo de cada valor; falha só é registrada,
+// o tipo é compilado no primeiro uso
+func Pretouch(values ...interface{}) {
+	for _, v := range values {
+		if err := sonic.Pretouch(reflect.TypeOf(v)); err != nil {
+			log.Printf("⚠️  Pretouch de %T falhou: %v", v, err)
+		}
+	}
+}
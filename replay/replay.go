@@ -0,0 +1,151 @@
+// Package replay reexecuta as fixtures gravadas com RECORD_FIXTURES contra o
+// código atual dos provedores: uma mudança no formato da resposta, ou no
+// parsing dela, aparece aqui antes de chegar em produção.
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"lingobot-ai-engine/provider"
+)
+
+const usage = `usage: lingobot-ai-engine replay [-payload] [-v] <dir|fixture.json>...
+
+  -payload  também compara o corpo enviado ao provedor com o gravado; modelos e
+            parâmetros vêm do env, então rode com o mesmo env da gravação
+  -v        lista as fixtures que passaram`
+
+// Command executa o subcomando "replay"
+func Command(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	payload := flags.Bool("payload", false, "")
+	verbose := flags.Bool("v", false, "")
+	flags.Usage = func() {}
+	if err := flags.Parse(args); err != nil || flags.NArg() == 0 {
+		return errors.New(usage)
+	}
+
+	paths, err := collect(flags.Args())
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return errors.New("no fixtures found")
+	}
+
+	var failed int
+	for _, path := range paths {
+		problems := Check(path, *payload)
+		if len(problems) == 0 {
+			if *verbose {
+				fmt.Printf("ok    %s\n", path)
+			}
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL  %s\n", path)
+		for _, p := range problems {
+			fmt.Printf("      %s\n", p)
+		}
+	}
+
+	fmt.Printf("%d fixtures, %d falharam\n", len(paths), failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d fixtures failed", failed, len(paths))
+	}
+	return nil
+}
+
+// collect expande diretórios nas fixtures .json de dentro deles
+func collect(args []string) ([]string, error) {
+	var paths []string
+	for _, arg := range args {
+		err := filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.HasSuffix(path, ".json") {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// Check reexecuta uma fixture e devolve as diferenças para o gravado
+func Check(path string, payload bool) []string {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var f provider.Fixture
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return []string{"invalid fixture: " + err.Error()}
+	}
+
+	result, sent, err := provider.Replay(&f)
+
+	var problems []string
+	var upstream *provider.UpstreamError
+	switch {
+	case f.Error != "" && !errors.As(err, &upstream):
+		problems = append(problems, fmt.Sprintf("error = %v, want %s", err, f.Error))
+	case f.Error != "" && upstream.Code != f.Error:
+		problems = append(problems, fmt.Sprintf("error = %s (%s), want %s", upstream.Code, upstream.Detail, f.Error))
+	case f.Error == "" && err != nil:
+		problems = append(problems, fmt.Sprintf("error = %v, want none", err))
+	case f.Result != nil:
+		problems = append(problems, compareResult(result, f.Result)...)
+	}
+
+	if len(sent) != len(f.Exchanges) {
+		problems = append(problems, fmt.Sprintf("%d requests sent, %d recorded", len(sent), len(f.Exchanges)))
+	}
+	if payload {
+		for i := 0; i < len(sent) && i < len(f.Exchanges); i++ {
+			if !sameJSON(sent[i].Body, f.Exchanges[i].Body) {
+				problems = append(problems, fmt.Sprintf("request %d body differs\n       got: %s\n      want: %s", i+1, sent[i].Body, f.Exchanges[i].Body))
+			}
+		}
+	}
+	return problems
+}
+
+func compareResult(got *provider.Result, want *provider.FixtureResult) []string {
+	var problems []string
+	if got.Text != want.Text {
+		problems = append(problems, fmt.Sprintf("text = %q, want %q", got.Text, want.Text))
+	}
+	if got.Reasoning != want.Reasoning {
+		problems = append(problems, fmt.Sprintf("reasoning = %q, want %q", got.Reasoning, want.Reasoning))
+	}
+	if got.ReasoningTokens != want.ReasoningTokens {
+		problems = append(problems, fmt.Sprintf("reasoning_tokens = %d, want %d", got.ReasoningTokens, want.ReasoningTokens))
+	}
+	if got.Usage != want.Usage {
+		problems = append(problems, fmt.Sprintf("usage = %+v, want %+v", got.Usage, want.Usage))
+	}
+	return problems
+}
+
+// sameJSON compara corpos pelo conteúdo, ignorando ordem de campos e espaços;
+// corpo que não é JSON compara byte a byte
+func sameJSON(a, b string) bool {
+	var x, y interface{}
+	if json.Unmarshal([]byte(a), &x) != nil || json.Unmarshal([]byte(b), &y) != nil {
+		return a == b
+	}
+	return reflect.DeepEqual(x, y)
+}
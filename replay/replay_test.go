@@ -0,0 +1,116 @@
+package replay
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lingobot-ai-engine/provider"
+)
+
+const fixtures = "../provider/testdata/fixtures"
+
+// Cada fixture gravada tem de passar contra o parsing atual, com o mesmo payload
+func TestFixtures(t *testing.T) {
+	paths, err := collect([]string{fixtures})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no fixtures found")
+	}
+
+	seen := map[string]bool{}
+	for _, path := range paths {
+		seen[filepath.Base(filepath.Dir(path))] = true
+		t.Run(strings.TrimPrefix(path, fixtures+"/"), func(t *testing.T) {
+			for _, p := range Check(path, true) {
+				t.Error(p)
+			}
+		})
+	}
+
+	// provedor novo sem fixture passa despercebido
+	for _, p := range provider.All() {
+		if !p.Custom && p.Name != provider.Mock.Name && !seen[p.Name] {
+			t.Errorf("no fixture for provider %s", p.Name)
+		}
+	}
+}
+
+// Uma mudança no formato da resposta tem de aparecer como falha, sem panic
+func TestChangedResponse(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		response string
+		want     string
+	}{
+		{"mistral/ok.json", `{"choices":"none"}`, "error = mistral: upstream_bad_response"},
+		{"mistral/ok.json", `{"choices":[]}`, "error = mistral: upstream_bad_response"},
+		{"cohere/ok.json", `{"generation_id":"c2d4"}`, "error = cohere: upstream_bad_response"},
+		{"cohere/ok.json", `{"text":42}`, "error = cohere: upstream_bad_response"},
+		{"gemini/ok.json", `{"candidates":[]}`, "error = gemini: upstream_bad_response"},
+		{"huggingface/ok.json", `{"generated_text":"oi"}`, "error = huggingface: upstream_bad_response"},
+		{"groq/ok.json", strings.Replace(exchangeResponse(t, "groq/ok.json"), "Biblioteca.", "Livraria.", 1), `text = "Livraria.", want "Biblioteca."`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			path := rewrite(t, tt.fixture, tt.response)
+			problems := Check(path, false)
+			if len(problems) == 0 || !strings.HasPrefix(problems[0], tt.want) {
+				t.Errorf("problems = %q, want prefix %q", problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameJSON(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{`{"a":1,"b":[1,2]}`, `{"b":[1,2],"a":1}`, true},
+		{`{"a":1}`, `{ "a" : 1 }`, true},
+		{`{"a":1}`, `{"a":2}`, false},
+		{`not json`, `not json`, true},
+		{`not json`, `{"a":1}`, false},
+	}
+	for _, tt := range tests {
+		if got := sameJSON(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameJSON(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func readFixture(t *testing.T, name string) provider.Fixture {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join(fixtures, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f provider.Fixture
+	if err := json.Unmarshal(raw, &f); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func exchangeResponse(t *testing.T, name string) string {
+	return readFixture(t, name).Exchanges[0].Response
+}
+
+// rewrite grava a fixture com outra resposta do provedor num diretório temporário
+func rewrite(t *testing.T, name, response string) string {
+	t.Helper()
+	f := readFixture(t, name)
+	f.Exchanges[0].Response = response
+	raw, _ := json.Marshal(f)
+	path := filepath.Join(t.TempDir(), filepath.Base(name))
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,259 @@
+// Package routing escolhe e executa os provedores de cada turno: ordem fixa,
+// estratégia auto por latência, regras por idioma, experimentos e tráfego sombra.
+package routing
+
+import (
+	"errors"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"lingobot-ai-engine/language"
+	"lingobot-ai-engine/provider"
+)
+
+const (
+	latencyWindow   = 50                      // amostras para o p50
+	errorAlpha      = 0.2                     // peso da última amostra no EWMA de erro
+	defaultLatency  = 1500 * time.Millisecond // latência assumida sem amostras
+	minErrorPenalty = 0.05                    // provedor com 100% de erro ainda é tentado por último
+)
+
+// Estatísticas móveis de um provedor
+type providerStats struct {
+	mu        sync.Mutex
+	latencies []time.Duration // ring buffer das últimas chamadas com sucesso
+	next      int
+	errorRate float64 // EWMA de 0 a 1
+	calls     int
+}
+
+func (s *providerStats) record(latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+
+	failure := 0.0
+	if err != nil {
+		failure = 1.0
+	}
+	s.errorRate = errorAlpha*failure + (1-errorAlpha)*s.errorRate
+
+	if err != nil {
+		return
+	}
+
+	if len(s.latencies) < latencyWindow {
+		s.latencies = append(s.latencies, latency)
+		return
+	}
+	s.latencies[s.next] = latency
+	s.next = (s.next + 1) % latencyWindow
+}
+
+// snapshot devolve p50 e taxa de erro atuais
+func (s *providerStats) snapshot() (time.Duration, float64, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.latencies) == 0 {
+		return defaultLatency, s.errorRate, s.calls
+	}
+
+	sorted := make([]time.Duration, len(s.latencies))
+	copy(sorted, s.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return sorted[len(sorted)/2], s.errorRate, s.calls
+}
+
+var (
+	statsMu sync.Mutex
+	stats   = map[string]*providerStats{}
+)
+
+func statsFor(name string) *providerStats {
+	statsMu.Lock()
+	defer statsMu.Unlock()
+
+	s, ok := stats[name]
+	if !ok {
+		s = &providerStats{}
+		stats[name] = s
+	}
+	return s
+}
+
+// AZURE_ONLY=1 para instâncias que por contrato não podem sair do Azure:
+// todo turno vai ao deployment Azure e nenhum outro provedor é chamado,
+// nem para compressão ou tráfego sombra
+var azureOnly = os.Getenv("AZURE_ONLY") == "1" || os.Getenv("AZURE_ONLY") == "true"
+
+// ErrProviderDisabled indica provedor bloqueado por AZURE_ONLY
+var ErrProviderDisabled = errors.New("provider disabled: AZURE_ONLY allows only azure")
+
+func allowed(p provider.Provider) error {
+	if azureOnly && p.Name != "azure" && p.Name != provider.Mock.Name {
+		return ErrProviderDisabled
+	}
+	return nil
+}
+
+// Call executa a chamada e alimenta as estatísticas do balanceador
+func Call(p provider.Provider, in *provider.Request) (*provider.Result, error) {
+	if err := allowed(p); err != nil {
+		return nil, err
+	}
+	if err := in.Budget.Take(); err != nil {
+		return nil, err
+	}
+
+	reserved, err := throttle(p, in)
+	if err != nil {
+		in.Budget.Refund() // a chamada não saiu daqui
+		return nil, err
+	}
+
+	if err := upstream.acquire(); err != nil {
+		in.Budget.Refund()
+		return nil, err
+	}
+	defer upstream.release()
+
+	start := time.Now()
+	result, err := p.Generate(in)
+	err = provider.Classify(p.Name, err)
+	if notConfigured(err) {
+		in.Budget.Refund() // sem chave a chamada não chegou a sair
+	}
+	statsFor(p.Name).record(time.Since(start), err)
+	if result != nil {
+		result.Provider = p.Name
+		result.Text = in.CutAtStop(result.Text)
+		result.Language = language.Detect(result.Text)
+		reserved.settle(result.Usage)
+	}
+	return result, err
+}
+
+func notConfigured(err error) bool {
+	var upstream *provider.UpstreamError
+	return errors.As(err, &upstream) && upstream.Code == provider.CodeNotConfigured
+}
+
+// CallStream é o Call com streaming; a latência medida é a do turno inteiro
+func CallStream(p provider.Provider, in *provider.Request, onChunk func(string) error) (*provider.Result, error) {
+	if err := allowed(p); err != nil {
+		return nil, err
+	}
+	if err := in.Budget.Take(); err != nil {
+		return nil, err
+	}
+
+	reserved, err := throttle(p, in)
+	if err != nil {
+		in.Budget.Refund() // a chamada não saiu daqui
+		return nil, err
+	}
+
+	if err := upstream.acquire(); err != nil {
+		in.Budget.Refund()
+		return nil, err
+	}
+	defer upstream.release()
+
+	start := time.Now()
+	stop := newStopFilter(in.Stop, onChunk)
+	result, err := p.GenerateStream(in, stop.write)
+	switch {
+	case errors.Is(err, errStopSequence):
+		// o stream foi fechado na parada, sem o uso final do provedor
+		text := stop.text()
+		completion := provider.EstimateTokens(text)
+		result, err = &provider.Result{Text: text, Usage: provider.Usage{
+			PromptTokens:     in.PromptTokens(),
+			CompletionTokens: completion,
+			TotalTokens:      in.PromptTokens() + completion,
+		}}, nil
+	case err == nil:
+		if err = stop.flush(); err != nil {
+			result = nil
+		} else {
+			result.Text = in.CutAtStop(result.Text)
+		}
+	case notConfigured(provider.Classify(p.Name, err)):
+		in.Budget.Refund()
+	}
+	statsFor(p.Name).record(time.Since(start), err)
+	if result != nil {
+		result.Provider = p.Name
+		reserved.settle(result.Usage)
+	}
+	return result, err
+}
+
+// Pesos por provedor via PROVIDER_WEIGHTS="gemini=2,mistral=1,cohere=0"
+var providerWeights = loadProviderWeights(os.Getenv("PROVIDER_WEIGHTS"))
+
+func loadProviderWeights(raw string) map[string]float64 {
+	weights := map[string]float64{}
+	for _, entry := range strings.Split(raw, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || weight < 0 {
+			log.Printf("⚠️  Peso inválido para %q em PROVIDER_WEIGHTS", name)
+			continue
+		}
+		weights[strings.TrimSpace(name)] = weight
+	}
+	return weights
+}
+
+func weightOf(name string) float64 {
+	if w, ok := providerWeights[name]; ok {
+		return w
+	}
+	return 1
+}
+
+// score combina peso, p50 e taxa de erro: maior é melhor
+func score(name string) float64 {
+	p50, errorRate, _ := statsFor(name).snapshot()
+	reliability := math.Max(1-errorRate, minErrorPenalty)
+	return weightOf(name) * reliability / p50.Seconds()
+}
+
+// Rank ordena pelo score os provedores configurados com peso > 0; no
+// MOCK_MODE todos contam, já que nenhum é chamado de verdade
+func Rank() []provider.Provider {
+	all := provider.All()
+	mock := provider.MockMode()
+	ranked := make([]provider.Provider, 0, len(all))
+	for _, p := range all {
+		if weightOf(p.Name) > 0 && (mock || p.Configured()) {
+			ranked = append(ranked, p)
+		}
+	}
+
+	scores := make(map[string]float64, len(ranked))
+	for _, p := range ranked {
+		scores[p.Name] = score(p.Name)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return scores[ranked[i].Name] > scores[ranked[j].Name]
+	})
+	return ranked
+}
+
+var errNoProviders = errors.New("no providers enabled")
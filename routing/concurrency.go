@@ -0,0 +1,107 @@
+package routing
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"lingobot-ai-engine/internal/env"
+)
+
+// ErrOverloaded indica que não abriu vaga para chamar um provedor a tempo
+var ErrOverloaded = errors.New("gateway overloaded, retry later")
+
+// Limite de chamadas simultâneas aos provedores, somando todos eles. Num pico,
+// quem passa de UPSTREAM_MAX_INFLIGHT espera numa fila de UPSTREAM_QUEUE_DEPTH
+// por até UPSTREAM_QUEUE_TIMEOUT; fila cheia ou espera longa recebe
+// ErrOverloaded na hora, em vez de milhares de goroutines presas nos sockets.
+// Ao contrário dos limites de PROVIDER_RATE_LIMITS, a conta é por instância:
+// o que ela protege são os sockets e goroutines do próprio processo.
+type concurrencyLimiter struct {
+	slots   chan struct{}
+	depth   int64
+	timeout time.Duration
+	waiting atomic.Int64
+}
+
+var upstream = newConcurrencyLimiter(
+	env.Int("UPSTREAM_MAX_INFLIGHT", 64),
+	env.Int("UPSTREAM_QUEUE_DEPTH", 128),
+	env.Duration("UPSTREAM_QUEUE_TIMEOUT", 2*time.Second),
+)
+
+// newConcurrencyLimiter devolve nil (sem limite) quando maxInFlight é 0
+func newConcurrencyLimiter(maxInFlight, depth int, timeout time.Duration) *concurrencyLimiter {
+	if maxInFlight == 0 {
+		return nil
+	}
+	return &concurrencyLimiter{
+		slots:   make(chan struct{}, maxInFlight),
+		depth:   int64(depth),
+		timeout: timeout,
+	}
+}
+
+// acquire reserva uma vaga, esperando na fila se preciso; release devolve
+func (l *concurrencyLimiter) acquire() error {
+	if l == nil {
+		return nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if l.waiting.Add(1) > l.depth {
+		l.waiting.Add(-1)
+		return ErrOverloaded
+	}
+	defer l.waiting.Add(-1)
+
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrOverloaded
+	}
+}
+
+// tryAcquire só pega vaga livre, sem fila: para tráfego dispensável como a sombra
+func (l *concurrencyLimiter) tryAcquire() bool {
+	if l == nil {
+		return true
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *concurrencyLimiter) release() {
+	if l != nil {
+		<-l.slots
+	}
+}
+
+// Upstream é a ocupação do limite para o /scaling-hint: chamadas em andamento e na fila
+func Upstream() (inFlight, queued int64) {
+	if upstream == nil {
+		return 0, 0
+	}
+	return int64(len(upstream.slots)), upstream.waiting.Load()
+}
+
+// OverloadRetryAfter é a espera sugerida ao cliente recusado por sobrecarga
+func OverloadRetryAfter() time.Duration {
+	if upstream == nil {
+		return time.Second
+	}
+	return max(upstream.timeout, time.Second)
+}
@@ -0,0 +1,211 @@
+package routing
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/bytedance/sonic"
+
+	"lingobot-ai-engine/provider"
+	"lingobot-ai-engine/tokens"
+)
+
+// Janela de contexto e tokenizador de cada modelo, definidos em MODEL_CONTEXT:
+//
+//	[{"provider":"azure","model":"gpt-4o-mini","context_window":128000,"tokenizer":"openai"},
+//	 {"provider":"local","model":"phi3","context_window":4096}]
+//
+// Mesma busca do MODEL_PRICES: sem model vale para o modelo padrão do
+// provedor, "reasoning":true para o de raciocínio, e as entradas de
+// MODEL_CONTEXT ganham das padrão. Modelo sem janela conhecida não é conferido.
+type ModelContext struct {
+	Provider  string `json:"provider"`
+	Model     string `json:"model,omitempty"`
+	Reasoning bool   `json:"reasoning,omitempty"`
+	Window    int    `json:"context_window"`
+	Tokenizer string `json:"tokenizer,omitempty"` // família em tokens; vazio usa generic
+}
+
+// Janelas de tabela dos modelos padrão e dos de MODELS. Azure e o modelo local
+// dependem do deployment e ficam de fora.
+var defaultContexts = []ModelContext{
+	{Provider: "gemini", Window: 1048576, Tokenizer: tokens.Gemini},
+	{Provider: "gemini", Model: "gemini-2.0-flash", Window: 1048576, Tokenizer: tokens.Gemini},
+	{Provider: "gemini", Model: "gemini-2.5-pro", Window: 1048576, Tokenizer: tokens.Gemini},
+	{Provider: "mistral", Window: 32768, Tokenizer: tokens.Mistral},
+	{Provider: "mistral", Model: "mistral-tiny", Window: 32768, Tokenizer: tokens.Mistral},
+	{Provider: "mistral", Model: "mistral-small-latest", Window: 131072, Tokenizer: tokens.Mistral},
+	{Provider: "groq", Window: 131072, Tokenizer: tokens.Llama3},
+	{Provider: "groq", Model: "meta-llama/llama-4-scout-17b-16e-instruct", Window: 131072, Tokenizer: tokens.Llama3},
+	{Provider: "groq", Model: "llama-3.1-8b-instant", Window: 131072, Tokenizer: tokens.Llama3},
+	{Provider: "groq", Reasoning: true, Window: 131072, Tokenizer: tokens.Llama3},
+	{Provider: "cohere", Window: 128000, Tokenizer: tokens.Cohere},
+	{Provider: "cohere", Model: "command-r", Window: 128000, Tokenizer: tokens.Cohere},
+	{Provider: "openrouter", Window: 131072, Tokenizer: tokens.Generic}, // o padrão troca de modelo gratuito
+	{Provider: "openrouter", Reasoning: true, Window: 163840, Tokenizer: tokens.DeepSeek},
+	{Provider: "deepseek", Window: 65536, Tokenizer: tokens.DeepSeek},
+	{Provider: "deepseek", Reasoning: true, Window: 65536, Tokenizer: tokens.DeepSeek},
+	{Provider: "together", Window: 131072, Tokenizer: tokens.Llama3},
+	{Provider: "together", Model: "meta-llama/Llama-3.3-70B-Instruct-Turbo-Free", Window: 131072, Tokenizer: tokens.Llama3},
+	{Provider: "together", Reasoning: true, Window: 8192, Tokenizer: tokens.Llama3}, // endpoint gratuito
+	{Provider: "huggingface", Window: 32768, Tokenizer: tokens.Mistral},
+}
+
+var contexts = loadContexts(os.Getenv("MODEL_CONTEXT"))
+
+func loadContexts(raw string) []ModelContext {
+	if raw == "" {
+		return defaultContexts
+	}
+
+	var custom []ModelContext
+	if err := sonic.UnmarshalString(raw, &custom); err != nil {
+		log.Printf("⚠️  MODEL_CONTEXT inválido, usando a tabela padrão: %v", err)
+		return defaultContexts
+	}
+
+	list := make([]ModelContext, 0, len(custom)+len(defaultContexts))
+	for _, c := range custom {
+		if c.Window <= 0 || (c.Tokenizer != "" && !tokens.Known(c.Tokenizer)) {
+			log.Printf("⚠️  Janela ignorada em MODEL_CONTEXT: %q em %q", c.Model, c.Provider)
+			continue
+		}
+		list = append(list, c)
+	}
+	return append(list, defaultContexts...)
+}
+
+// contextFor acha a janela do modelo exato ou, sem modelo, a do padrão do provedor
+func contextFor(name, model string, reasoning bool) (ModelContext, bool) {
+	for _, c := range contexts {
+		if c.Provider != name {
+			continue
+		}
+		if model != "" && c.Model == model {
+			return c, true
+		}
+		if model == "" && c.Model == "" && c.Reasoning == reasoning {
+			return c, true
+		}
+	}
+	return ModelContext{}, false
+}
+
+func (c ModelContext) tokenizer() string {
+	if c.Tokenizer == "" {
+		return tokens.Generic
+	}
+	return c.Tokenizer
+}
+
+// Resposta reservada na janela quando o pedido não limita max_tokens; é o
+// max_tokens que os provedores mandam por padrão
+const defaultOutputReserve = 1000
+
+// countPrompt estima a entrada inteira no tokenizador da família
+func countPrompt(tokenizer string, in *provider.Request) int {
+	total := 0
+	if in.System != "" {
+		total += tokens.Count(tokenizer, in.System) + tokens.MessageOverhead
+	}
+	for _, m := range in.History {
+		total += tokens.Count(tokenizer, m.Content) + tokens.MessageOverhead
+	}
+	return total + tokens.Count(tokenizer, in.Text) + tokens.MessageOverhead
+}
+
+// fitContext confere o pedido contra a janela do candidato. Se não couber,
+// descarta os turnos mais antigos do histórico; se nem o texto atual couber,
+// falha com context_length_exceeded antes de chamar o provedor.
+func fitContext(c Candidate, in *provider.Request) (*provider.Request, error) {
+	window, ok := contextFor(c.Provider.Name, in.Model, in.Reasoning)
+	if !ok {
+		return in, nil
+	}
+
+	limit := window.Window - defaultOutputReserve
+	if in.MaxTokens > 0 {
+		limit = window.Window - in.MaxTokens
+	}
+	tokenizer := window.tokenizer()
+
+	before := countPrompt(tokenizer, in)
+	if before <= limit {
+		return in, nil
+	}
+
+	fitted := *in
+	prompt := before
+	for len(fitted.History) > 0 && prompt > limit {
+		prompt -= tokens.Count(tokenizer, fitted.History[0].Content) + tokens.MessageOverhead
+		fitted.History = fitted.History[1:]
+	}
+	if prompt > limit {
+		return nil, &provider.UpstreamError{
+			Provider: c.Provider.Name,
+			Code:     provider.CodeContextLength,
+			Detail:   fmt.Sprintf("prompt has about %d tokens, the model accepts %d with %d reserved for the answer", prompt, window.Window, window.Window-limit),
+		}
+	}
+
+	log.Printf("✂️  Histórico cortado para caber em %s: %d → %d tokens (%d turnos descartados)",
+		c.Provider.Name, before, prompt, len(in.History)-len(fitted.History))
+	return &fitted, nil
+}
+
+// TokenCount é a contagem de um pedido num provedor e modelo, para o POST /tokenize
+type TokenCount struct {
+	Provider  string `json:"provider"`
+	Model     string `json:"model,omitempty"` // vazio é o modelo padrão do provedor
+	Reasoning bool   `json:"reasoning,omitempty"`
+	Tokenizer string `json:"tokenizer"`
+	Tokens    int    `json:"tokens"`
+	Window    int    `json:"context_window"` // 0 quando a janela do modelo não é conhecida
+	Fits      bool   `json:"fits"`           // cabe com a resposta reservada, sem cortar o histórico
+}
+
+// Tokenize conta o pedido em cada modelo de janela conhecida; name e model
+// filtram a lista quando não vazios. Modelo pedido fora da tabela é contado
+// no tokenizador genérico, sem janela.
+func Tokenize(in *provider.Request, name, model string) []TokenCount {
+	counts := []TokenCount{}
+	seen := map[ModelContext]bool{}
+	for _, c := range contexts {
+		if (name != "" && c.Provider != name) || (model != "" && c.Model != model) {
+			continue
+		}
+		// entrada de MODEL_CONTEXT esconde a padrão do mesmo modelo
+		key := ModelContext{Provider: c.Provider, Model: c.Model, Reasoning: c.Reasoning}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		n := countPrompt(c.tokenizer(), in)
+		reserve := defaultOutputReserve
+		if in.MaxTokens > 0 {
+			reserve = in.MaxTokens
+		}
+		counts = append(counts, TokenCount{
+			Provider:  c.Provider,
+			Model:     c.Model,
+			Reasoning: c.Reasoning,
+			Tokenizer: c.tokenizer(),
+			Tokens:    n,
+			Window:    c.Window,
+			Fits:      n+reserve <= c.Window,
+		})
+	}
+
+	if len(counts) == 0 && name != "" {
+		counts = append(counts, TokenCount{
+			Provider:  name,
+			Model:     model,
+			Tokenizer: tokens.Generic,
+			Tokens:    countPrompt(tokens.Generic, in),
+			Fits:      true,
+		})
+	}
+	return counts
+}
@@ -0,0 +1,167 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+
+	"github.com/bytedance/sonic"
+
+	"lingobot-ai-engine/provider"
+)
+
+// ErrCostLimit indica que nenhum candidato do plano cabe no max_cost_usd do pedido
+var ErrCostLimit = errors.New("no provider fits max_cost_usd")
+
+// Preço em USD por milhão de tokens, definido em MODEL_PRICES:
+//
+//	[{"provider":"groq","model":"llama-3.1-8b-instant","input":0.05,"output":0.08},
+//	 {"provider":"azure","model":"gpt-4o-mini","input":0.15,"output":0.60,"max_output_tokens":2000}]
+//
+// Sem model, o preço vale para o modelo padrão do provedor; com "reasoning":true,
+// para o modelo de raciocínio padrão. As entradas de MODEL_PRICES substituem as
+// padrão do mesmo provedor e modelo. Modelo sem preço não entra em pedido com teto.
+type ModelPrice struct {
+	Provider  string  `json:"provider"`
+	Model     string  `json:"model,omitempty"`
+	Reasoning bool    `json:"reasoning,omitempty"`
+	Input     float64 `json:"input"`
+	Output    float64 `json:"output"`
+	MaxOutput int     `json:"max_output_tokens,omitempty"` // maior max_tokens mandado com teto de custo
+}
+
+// Preços de tabela dos modelos padrão; os gratuitos do OpenRouter e da Together
+// custam zero. Azure e HuggingFace dependem do deployment e ficam de fora.
+var defaultPrices = []ModelPrice{
+	{Provider: "gemini", Input: 0.10, Output: 0.40},
+	{Provider: "gemini", Model: "gemini-2.0-flash", Input: 0.10, Output: 0.40},
+	{Provider: "gemini", Model: "gemini-2.5-pro", Input: 1.25, Output: 10.00},
+	{Provider: "mistral", Input: 0.25, Output: 0.25},
+	{Provider: "mistral", Model: "mistral-tiny", Input: 0.25, Output: 0.25},
+	{Provider: "mistral", Model: "mistral-small-latest", Input: 0.10, Output: 0.30},
+	{Provider: "groq", Input: 0.11, Output: 0.34},
+	{Provider: "groq", Model: "meta-llama/llama-4-scout-17b-16e-instruct", Input: 0.11, Output: 0.34},
+	{Provider: "groq", Model: "llama-3.1-8b-instant", Input: 0.05, Output: 0.08},
+	{Provider: "groq", Reasoning: true, Input: 0.75, Output: 0.99},
+	{Provider: "cohere", Input: 0.15, Output: 0.60},
+	{Provider: "cohere", Model: "command-r", Input: 0.15, Output: 0.60},
+	{Provider: "openrouter"},
+	{Provider: "openrouter", Reasoning: true},
+	{Provider: "deepseek", Input: 0.27, Output: 1.10},
+	{Provider: "deepseek", Reasoning: true, Input: 0.55, Output: 2.19},
+	{Provider: "together"},
+	{Provider: "together", Model: "meta-llama/Llama-3.3-70B-Instruct-Turbo-Free"},
+	{Provider: "together", Reasoning: true},
+	{Provider: "mock"},
+}
+
+var prices = loadPrices(os.Getenv("MODEL_PRICES"))
+
+func loadPrices(raw string) []ModelPrice {
+	if raw == "" {
+		return defaultPrices
+	}
+
+	var custom []ModelPrice
+	if err := sonic.UnmarshalString(raw, &custom); err != nil {
+		log.Printf("⚠️  MODEL_PRICES inválido, usando a tabela padrão: %v", err)
+		return defaultPrices
+	}
+
+	// as entradas de MODEL_PRICES vêm antes e ganham na busca
+	list := make([]ModelPrice, 0, len(custom)+len(defaultPrices))
+	for _, p := range custom {
+		if p.Input < 0 || p.Output < 0 {
+			log.Printf("⚠️  Preço negativo ignorado em MODEL_PRICES: %q em %q", p.Model, p.Provider)
+			continue
+		}
+		list = append(list, p)
+	}
+	return append(list, defaultPrices...)
+}
+
+// priceFor acha o preço do modelo exato ou, sem modelo, o do padrão do provedor
+func priceFor(name, model string, reasoning bool) (ModelPrice, bool) {
+	for _, p := range prices {
+		if p.Provider != name {
+			continue
+		}
+		if model != "" && p.Model == model {
+			return p, true
+		}
+		if model == "" && p.Model == "" && p.Reasoning == reasoning {
+			return p, true
+		}
+	}
+	return ModelPrice{}, false
+}
+
+// Cost estima em USD uma chamada pelos tokens usados; os de raciocínio entram
+// em completion. false quando o modelo não tem preço.
+func Cost(name, model string, reasoning bool, prompt, completion int) (float64, bool) {
+	price, ok := priceFor(name, model, reasoning)
+	if !ok {
+		return 0, false
+	}
+	return (float64(prompt)*price.Input + float64(completion)*price.Output) / 1e6, true
+}
+
+// Tokens de resposta: abaixo do mínimo a resposta sairia cortada demais para
+// servir; sem max_output_tokens no preço, o teto mandado ao provedor é o padrão
+const (
+	minCompletionTokens = 64
+	defaultMaxOutput    = 4096
+)
+
+// withinCost fica com os candidatos que cabem no MaxCostUSD do pedido, cada um
+// com o max_tokens que o teto permite. O custo é o pior caso de cada chamada:
+// o prompt estimado mais a resposta inteira.
+func withinCost(in *provider.Request, candidates []Candidate) ([]Candidate, error) {
+	if in.MaxCostUSD <= 0 || len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	prompt := float64(in.PromptTokens())
+	cheapest := math.Inf(1)
+
+	fit := make([]Candidate, 0, len(candidates))
+	for _, c := range candidates {
+		model := c.Model
+		if model == "" {
+			model = in.Model
+		}
+		price, ok := priceFor(c.Provider.Name, model, in.Reasoning)
+		if !ok {
+			continue
+		}
+
+		maxOutput := price.MaxOutput
+		if maxOutput <= 0 {
+			maxOutput = defaultMaxOutput
+		}
+		tokens := maxOutput
+		left := in.MaxCostUSD - prompt*price.Input/1e6
+		if price.Output > 0 {
+			tokens = int(math.Min(float64(maxOutput), math.Floor(left*1e6/price.Output)))
+		}
+		if left < 0 || tokens < minCompletionTokens {
+			cheapest = math.Min(cheapest, (prompt*price.Input+minCompletionTokens*price.Output)/1e6)
+			continue
+		}
+
+		if c.MaxTokens == 0 || tokens < c.MaxTokens {
+			c.MaxTokens = tokens
+		}
+		fit = append(fit, c)
+	}
+
+	if len(fit) == 0 {
+		if math.IsInf(cheapest, 1) {
+			return nil, fmt.Errorf("%w: no provider in the plan has a known price", ErrCostLimit)
+		}
+		return nil, fmt.Errorf("%w: the cheapest option needs about $%.6f", ErrCostLimit, cheapest)
+	}
+	return fit, nil
+}
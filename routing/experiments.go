@@ -0,0 +1,209 @@
+package routing
+
+import (
+	"hash/fnv"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+
+	"lingobot-ai-engine/provider"
+)
+
+// Experimentos A/B entre pares provedor/modelo, definidos em EXPERIMENTS:
+//
+//	[{"name":"llama-vs-gemini","arms":[
+//	  {"name":"control","provider":"gemini","percent":50},
+//	  {"name":"groq-llama","provider":"groq","model":"llama-3.3-70b-versatile","percent":50}]}]
+//
+// A soma dos percentuais pode ficar abaixo de 100; o resto segue o roteamento normal.
+type Experiment struct {
+	Name string          `json:"name"`
+	Arms []ExperimentArm `json:"arms"`
+}
+
+type ExperimentArm struct {
+	Name     string  `json:"name"`
+	Provider string  `json:"provider"`
+	Model    string  `json:"model"`
+	Percent  float64 `json:"percent"`
+
+	experiment string
+	provider   provider.Provider
+	metrics    armMetrics
+}
+
+// Contadores de um braço do experimento
+type armMetrics struct {
+	mu         sync.Mutex
+	requests   int
+	errors     int
+	latency    time.Duration
+	thumbsUp   int
+	thumbsDown int
+}
+
+var experiments = loadExperiments(os.Getenv("EXPERIMENTS"))
+
+func loadExperiments(raw string) []*Experiment {
+	if raw == "" {
+		return nil
+	}
+
+	var list []*Experiment
+	if err := sonic.UnmarshalString(raw, &list); err != nil {
+		log.Printf("⚠️  EXPERIMENTS inválido, experimentos desligados: %v", err)
+		return nil
+	}
+
+	valid := list[:0]
+	for _, exp := range list {
+		total := 0.0
+		ok := exp.Name != "" && len(exp.Arms) > 0
+		for i := range exp.Arms {
+			arm := &exp.Arms[i]
+			p, found := provider.ByName(arm.Provider)
+			if !found || arm.Name == "" || arm.Percent <= 0 {
+				ok = false
+				break
+			}
+			arm.experiment = exp.Name
+			arm.provider = p
+			total += arm.Percent
+		}
+
+		if !ok || total > 100 {
+			log.Printf("⚠️  Experimento %q ignorado: braços inválidos", exp.Name)
+			continue
+		}
+		valid = append(valid, exp)
+	}
+	return valid
+}
+
+// bucket mapeia cliente+experimento para [0, 100) de forma determinística
+func bucket(experiment, client string) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(experiment))
+	h.Write([]byte{0})
+	h.Write([]byte(client))
+	return float64(h.Sum64()%10000) / 100
+}
+
+// Assign devolve o primeiro experimento em que o cliente cai num braço
+func Assign(client string) (*Experiment, *ExperimentArm) {
+	if client == "" {
+		return nil, nil
+	}
+
+	for _, exp := range experiments {
+		b := bucket(exp.Name, client)
+		cumulative := 0.0
+		for i := range exp.Arms {
+			cumulative += exp.Arms[i].Percent
+			if b < cumulative {
+				return exp, &exp.Arms[i]
+			}
+		}
+	}
+	return nil, nil
+}
+
+// record soma uma chamada às métricas do braço
+func (arm *ExperimentArm) record(latency time.Duration, err error) {
+	arm.metrics.mu.Lock()
+	defer arm.metrics.mu.Unlock()
+
+	arm.metrics.requests++
+	if err != nil {
+		arm.metrics.errors++
+	} else {
+		arm.metrics.latency += latency
+	}
+}
+
+// Marca de experimento devolvida junto com a resposta
+type ExperimentTag struct {
+	Experiment string `json:"experiment"`
+	Arm        string `json:"arm"`
+}
+
+// Tag identifica o braço que atendeu o turno, ou nil fora de experimento
+func (c Candidate) Tag() *ExperimentTag {
+	if c.Arm == nil {
+		return nil
+	}
+	return &ExperimentTag{Experiment: c.Arm.experiment, Arm: c.Arm.Name}
+}
+
+// RecordFeedback registra a preferência do usuário no braço atribuído.
+// A atribuição é determinística, então o braço é recalculado a partir do cliente.
+func RecordFeedback(client string, liked bool) (*ExperimentTag, bool) {
+	exp, arm := Assign(client)
+	if arm == nil {
+		return nil, false
+	}
+
+	arm.metrics.mu.Lock()
+	if liked {
+		arm.metrics.thumbsUp++
+	} else {
+		arm.metrics.thumbsDown++
+	}
+	arm.metrics.mu.Unlock()
+
+	return &ExperimentTag{Experiment: exp.Name, Arm: arm.Name}, true
+}
+
+// Métricas de um braço em GET /experiments
+type ArmReport struct {
+	Name         string  `json:"name"`
+	Provider     string  `json:"provider"`
+	Model        string  `json:"model,omitempty"`
+	Percent      float64 `json:"percent"`
+	Requests     int     `json:"requests"`
+	Errors       int     `json:"errors"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	ThumbsUp     int     `json:"thumbs_up"`
+	ThumbsDown   int     `json:"thumbs_down"`
+	Approval     float64 `json:"approval"`
+}
+
+type ExperimentReport struct {
+	Name string      `json:"name"`
+	Arms []ArmReport `json:"arms"`
+}
+
+// Reports consolida as métricas por braço
+func Reports() []ExperimentReport {
+	reports := make([]ExperimentReport, 0, len(experiments))
+	for _, exp := range experiments {
+		report := ExperimentReport{Name: exp.Name}
+		for i := range exp.Arms {
+			arm := &exp.Arms[i]
+			arm.metrics.mu.Lock()
+			r := ArmReport{
+				Name:       arm.Name,
+				Provider:   arm.Provider,
+				Model:      arm.Model,
+				Percent:    arm.Percent,
+				Requests:   arm.metrics.requests,
+				Errors:     arm.metrics.errors,
+				ThumbsUp:   arm.metrics.thumbsUp,
+				ThumbsDown: arm.metrics.thumbsDown,
+			}
+			if ok := r.Requests - r.Errors; ok > 0 {
+				r.AvgLatencyMs = ms(arm.metrics.latency / time.Duration(ok))
+			}
+			if votes := r.ThumbsUp + r.ThumbsDown; votes > 0 {
+				r.Approval = float64(r.ThumbsUp) / float64(votes)
+			}
+			arm.metrics.mu.Unlock()
+			report.Arms = append(report.Arms, r)
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
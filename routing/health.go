@@ -0,0 +1,40 @@
+package routing
+
+import "lingobot-ai-engine/provider"
+
+// Taxa de erro (EWMA do balanceador) a partir da qual o provedor conta como fora
+const downErrorRate = 0.5
+
+// ProviderHealth é o estado de um provedor visto por esta instância
+type ProviderHealth struct {
+	Name      string  `json:"name"`
+	Up        bool    `json:"up"`
+	ErrorRate float64 `json:"error_rate"`
+	P50Ms     int64   `json:"p50_ms,omitempty"` // vazio antes da primeira chamada
+	Calls     int     `json:"calls"`
+}
+
+// Health lista os provedores que esta instância pode chamar: configurados e
+// liberados pelo AZURE_ONLY, ou só o mock no MOCK_MODE. Provedor sem chamadas
+// conta como no ar.
+func Health() []ProviderHealth {
+	candidates := provider.All()
+	if provider.MockMode() {
+		candidates = []provider.Provider{provider.Mock}
+	}
+
+	out := []ProviderHealth{}
+	for _, p := range candidates {
+		if allowed(p) != nil || (p.Name != provider.Mock.Name && !p.Configured()) {
+			continue
+		}
+
+		p50, errorRate, calls := statsFor(p.Name).snapshot()
+		h := ProviderHealth{Name: p.Name, Up: errorRate < downErrorRate, ErrorRate: errorRate, Calls: calls}
+		if calls > 0 {
+			h.P50Ms = p50.Milliseconds()
+		}
+		out = append(out, h)
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package routing
+
+import (
+	"log"
+	"os"
+
+	"github.com/bytedance/sonic"
+
+	"lingobot-ai-engine/language"
+	"lingobot-ai-engine/provider"
+)
+
+// Regra de roteamento por idioma, definida em ROUTING_RULES:
+//
+//	[{"language":"ja","provider":"gemini"},
+//	 {"language":"pt","provider":"mistral","model":"mistral-large-latest"}]
+type languageRule struct {
+	Language string `json:"language"`
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+
+	provider provider.Provider
+}
+
+var languageRules = loadLanguageRules(os.Getenv("ROUTING_RULES"))
+
+func loadLanguageRules(raw string) map[string]languageRule {
+	if raw == "" {
+		return nil
+	}
+
+	var list []languageRule
+	if err := sonic.UnmarshalString(raw, &list); err != nil {
+		log.Printf("⚠️  ROUTING_RULES inválido, roteamento por idioma desligado: %v", err)
+		return nil
+	}
+
+	rules := make(map[string]languageRule, len(list))
+	for _, rule := range list {
+		p, ok := provider.ByName(rule.Provider)
+		if !ok || rule.Language == "" {
+			log.Printf("⚠️  Regra de idioma ignorada: %q → %q", rule.Language, rule.Provider)
+			continue
+		}
+		rule.provider = p
+		rules[language.Normalize(rule.Language)] = rule
+	}
+	return rules
+}
+
+// ruleForLanguage usa o idioma declarado ou, na falta dele, o detectado
+func ruleForLanguage(declared, text string) (languageRule, bool) {
+	if len(languageRules) == 0 {
+		return languageRule{}, false
+	}
+
+	lang := language.Normalize(declared)
+	if lang == "" {
+		lang = language.Detect(text)
+	}
+
+	rule, ok := languageRules[lang]
+	return rule, ok
+}
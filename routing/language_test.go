@@ -0,0 +1,116 @@
+package routing
+
+import "testing"
+
+const testRules = `[
+	{"language":"ja","provider":"gemini"},
+	{"language":"pt-BR","provider":"mistral","model":"mistral-large-latest"},
+	{"language":"ru","provider":"nope"},
+	{"language":"","provider":"groq"},
+	{"language":"ko","provider":"groq"},
+	{"language":"KO","provider":"cohere"}
+]`
+
+// withRules troca ROUTING_RULES durante o teste
+func withRules(t *testing.T, raw string) {
+	t.Helper()
+	saved := languageRules
+	languageRules = loadLanguageRules(raw)
+	t.Cleanup(func() { languageRules = saved })
+}
+
+func TestLoadLanguageRules(t *testing.T) {
+	rules := loadLanguageRules(testRules)
+
+	want := map[string]string{"ja": "gemini", "pt": "mistral", "ko": "cohere"}
+	if len(rules) != len(want) {
+		t.Fatalf("rules = %v, want %v", rules, want)
+	}
+	for lang, name := range want {
+		if got := rules[lang].provider.Name; got != name {
+			t.Errorf("rules[%s] = %q, want %q", lang, got, name)
+		}
+	}
+
+	if rules := loadLanguageRules(`{"language":"ja"`); rules != nil {
+		t.Errorf("invalid JSON: rules = %v, want none", rules)
+	}
+	if rules := loadLanguageRules(""); rules != nil {
+		t.Errorf("empty: rules = %v, want none", rules)
+	}
+}
+
+func TestRuleForLanguage(t *testing.T) {
+	withRules(t, testRules)
+
+	tests := []struct {
+		name     string
+		declared string
+		text     string
+		want     string // provedor da regra; vazio quando nenhuma vale
+	}{
+		{"declarado", "ja", "hello", "gemini"},
+		{"declarado com região", "pt-PT", "hello", "mistral"},
+		{"declarado em maiúsculas", "JA", "hello", "gemini"},
+		{"declarado vence o detectado", "pt", "こんにちは", "mistral"},
+		{"detectado sem declarado", "", "こんにちは、元気ですか", "gemini"},
+		{"detectado coreano", "", "안녕하세요", "cohere"},
+		{"declarado sem regra não cai no detectado", "fr", "こんにちは", ""},
+		{"detectado sem regra", "", "Привет, как дела?", ""},
+		{"idioma não detectado", "", "hello there", ""},
+		{"idioma desconhecido", "xx", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := ruleForLanguage(tt.declared, tt.text)
+			switch {
+			case tt.want == "" && ok:
+				t.Errorf("rule = %s, want none", rule.provider.Name)
+			case tt.want != "" && !ok:
+				t.Errorf("no rule, want %s", tt.want)
+			case ok && rule.provider.Name != tt.want:
+				t.Errorf("rule = %s, want %s", rule.provider.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleForLanguageWithoutRules(t *testing.T) {
+	withRules(t, "")
+	if rule, ok := ruleForLanguage("ja", "こんにちは"); ok {
+		t.Errorf("rule = %s, want none", rule.provider.Name)
+	}
+}
+
+// A regra de idioma vem antes do DefaultChain, mas depois do modelo pedido,
+// do raciocínio e da estratégia auto
+func TestPlanLanguagePrecedence(t *testing.T) {
+	withRules(t, testRules)
+
+	plan := Plan(Options{Language: "pt", Text: "olá"})
+	if len(plan) < 2 || plan[0].Provider.Name != "mistral" || plan[0].Model != "mistral-large-latest" {
+		t.Fatalf("plan[0] = %+v, want mistral with the rule model", plan)
+	}
+	if chain := DefaultChain(); len(plan) != len(chain)+1 {
+		t.Errorf("plan has %d candidates, want the rule plus %d of the default chain", len(plan), len(chain))
+	}
+
+	plan = Plan(Options{Language: "fr", Text: "bonjour"})
+	if chain := DefaultChain(); len(plan) != len(chain) || plan[0].Provider.Name != chain[0].Provider.Name {
+		t.Errorf("no rule: plan = %+v, want the default chain", plan)
+	}
+
+	if list := ModelList(); len(list) > 0 {
+		m := list[0]
+		plan = Plan(Options{Language: "ja", Text: "こんにちは", Model: m.Model})
+		if plan[0].Provider.Name != m.Provider || plan[0].Model != m.Model {
+			t.Errorf("explicit model: plan[0] = %+v, want %s on %s", plan[0], m.Model, m.Provider)
+		}
+	}
+
+	plan = Plan(Options{Language: "ja", Text: "こんにちは", Reasoning: true})
+	if plan[0].Provider.Name != "groq" {
+		t.Errorf("reasoning: plan[0] = %s, want groq", plan[0].Provider.Name)
+	}
+}
@@ -0,0 +1,114 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/bytedance/sonic"
+
+	"lingobot-ai-engine/provider"
+)
+
+// Modelos que o cliente pode pedir no campo model, definidos em MODELS:
+//
+//	[{"alias":"fast","provider":"groq","model":"llama-3.1-8b-instant"},
+//	 {"alias":"fast","provider":"together","model":"meta-llama/Llama-3.3-70B-Instruct-Turbo-Free"},
+//	 {"provider":"mistral","model":"mistral-small-latest"}]
+//
+// O cliente pede pelo alias ou pelo nome do modelo; o que não está na lista é
+// recusado. Entradas repetidas do mesmo alias viram fallback, na ordem. Os
+// modelos dos provedores de CUSTOM_PROVIDERS entram sempre, no fim da lista.
+type ModelEntry struct {
+	Alias    string `json:"alias,omitempty"`
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+
+	provider provider.Provider
+}
+
+// ErrModelNotAllowed indica modelo fora da lista de MODELS
+var ErrModelNotAllowed = errors.New("model not allowed")
+
+// Sem MODELS: os aliases padrão e os modelos padrão de cada provedor
+var defaultModels = []ModelEntry{
+	{Alias: "fast", Provider: "groq", Model: "meta-llama/llama-4-scout-17b-16e-instruct"},
+	{Alias: "smart", Provider: "gemini", Model: "gemini-2.5-pro"},
+	{Alias: "cheap", Provider: "mistral", Model: "mistral-tiny"},
+	{Provider: "gemini", Model: "gemini-2.0-flash"},
+	{Provider: "cohere", Model: "command-r"},
+}
+
+var models = loadModels(os.Getenv("MODELS"))
+
+func loadModels(raw string) []ModelEntry {
+	list := defaultModels
+	if raw != "" {
+		if err := sonic.UnmarshalString(raw, &list); err != nil {
+			log.Printf("⚠️  MODELS inválido, usando a lista padrão: %v", err)
+			list = defaultModels
+		}
+	}
+
+	listed := map[[2]string]bool{}
+	for _, m := range list {
+		listed[[2]string{m.Provider, m.Model}] = true
+	}
+	for _, c := range provider.Custom() {
+		for _, model := range c.Models {
+			if !listed[[2]string{c.Name, model}] {
+				list = append(list, ModelEntry{Provider: c.Name, Model: model})
+			}
+		}
+	}
+
+	valid := make([]ModelEntry, 0, len(list))
+	for _, m := range list {
+		p, ok := provider.ByName(m.Provider)
+		if !ok || m.Model == "" {
+			log.Printf("⚠️  Modelo ignorado em MODELS: %q em %q", m.Model, m.Provider)
+			continue
+		}
+		m.provider = p
+		valid = append(valid, m)
+	}
+	return valid
+}
+
+// Models resolve o alias ou nome de modelo pedido pelo cliente em candidatos,
+// restritos ao provedor informado quando ele não é vazio
+func Models(name, providerName string) ([]Candidate, error) {
+	var candidates []Candidate
+	for _, m := range models {
+		if !strings.EqualFold(m.Alias, name) && m.Model != name {
+			continue
+		}
+		if providerName != "" && m.Provider != providerName {
+			continue
+		}
+		candidates = append(candidates, Candidate{Provider: m.provider, Model: m.Model})
+	}
+
+	if len(candidates) == 0 {
+		if providerName != "" {
+			return nil, fmt.Errorf("%w on %s: %s", ErrModelNotAllowed, providerName, name)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrModelNotAllowed, name)
+	}
+	return candidates, nil
+}
+
+// Fixed é o plano de provedor fixo com o modelo pedido, se houver
+func Fixed(p provider.Provider, model string) ([]Candidate, error) {
+	if model == "" {
+		return Single(p), nil
+	}
+	return Models(model, p.Name)
+}
+
+// ModelList devolve os modelos aceitos, para o GET /models
+func ModelList() []ModelEntry {
+	return append([]ModelEntry(nil), models...)
+}
@@ -0,0 +1,303 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"lingobot-ai-engine/language"
+	"lingobot-ai-engine/provider"
+)
+
+// Candidato a atender o turno, na ordem em que será tentado
+type Candidate struct {
+	Provider provider.Provider
+	Model    string         // vazio mantém o modelo do pedido
+	Arm      *ExperimentArm // braço de experimento que originou o candidato
+
+	MaxTokens int // teto da resposta que cabe no max_cost_usd; 0 não limita
+
+	// preenchidos por Execute no candidato que respondeu
+	Depth   int           // candidatos chamados antes dele; acima de 0 houve fallback
+	Latency time.Duration // da primeira tentativa até a resposta
+}
+
+// Critérios de roteamento vindos do pedido
+type Options struct {
+	Text         string
+	Language     string // idioma declarado; vazio usa o detectado
+	Client       string // sessão ou API key, para experimentos
+	Strategy     string // "auto" para balancear por latência
+	Model        string // alias ou modelo de MODELS, já validado
+	Reasoning    bool
+	ForceMistral bool
+}
+
+func byName(name string) Candidate {
+	p, _ := provider.ByName(name)
+	return Candidate{Provider: p}
+}
+
+// DefaultChain é a ordem fixa: Gemini e, se falhar, Mistral; DeepSeek,
+// Together e Azure entram no fim quando têm API key configurada, o modelo
+// local quando LOCAL_LLM_URL está definido e, por último, os provedores de
+// CUSTOM_PROVIDERS marcados com fallback
+func DefaultChain() []Candidate {
+	chain := []Candidate{byName("gemini"), byName("mistral")}
+	for _, name := range []string{"deepseek", "together", "azure", "local"} {
+		if c := byName(name); c.Provider.Configured() {
+			chain = append(chain, c)
+		}
+	}
+	for _, custom := range provider.Custom() {
+		if c := byName(custom.Name); custom.Fallback && c.Provider.Configured() {
+			chain = append(chain, c)
+		}
+	}
+	return chain
+}
+
+// Plan monta a lista de candidatos do /ai
+func Plan(opts Options) []Candidate {
+	// modelo pedido explicitamente não passa por regras nem experimentos
+	if opts.Model != "" {
+		if candidates, err := Models(opts.Model, ""); err == nil {
+			return candidates
+		}
+	}
+
+	if azureOnly {
+		return []Candidate{byName("azure")}
+	}
+
+	switch {
+	case opts.Reasoning:
+		// provedores que servem DeepSeek-R1
+		candidates := []Candidate{byName("groq"), byName("openrouter")}
+		for _, name := range []string{"deepseek", "together"} {
+			if c := byName(name); c.Provider.Configured() {
+				candidates = append(candidates, c)
+			}
+		}
+		return candidates
+	case opts.ForceMistral:
+		return []Candidate{byName("mistral")}
+	case opts.Strategy == "auto":
+		ranked := Rank()
+		candidates := make([]Candidate, 0, len(ranked))
+		for _, p := range ranked {
+			candidates = append(candidates, Candidate{Provider: p})
+		}
+		return candidates
+	}
+
+	if rule, ok := ruleForLanguage(opts.Language, opts.Text); ok {
+		return append([]Candidate{{Provider: rule.provider, Model: rule.Model}}, DefaultChain()...)
+	}
+
+	if _, arm := Assign(opts.Client); arm != nil {
+		// se o braço falhar o usuário ainda recebe resposta pelo fallback normal
+		return append([]Candidate{{Provider: arm.provider, Model: arm.Model, Arm: arm}}, DefaultChain()...)
+	}
+
+	return DefaultChain()
+}
+
+// Single é o plano das rotas de provedor fixo (/gemini, /groq...)
+func Single(p provider.Provider) []Candidate {
+	return []Candidate{{Provider: p}}
+}
+
+func (c Candidate) request(in *provider.Request) *provider.Request {
+	if c.Model == "" && c.MaxTokens == 0 {
+		return in
+	}
+	routed := *in
+	if c.Model != "" {
+		routed.Model = c.Model
+	}
+	if c.MaxTokens > 0 {
+		routed.MaxTokens = c.MaxTokens
+	}
+	return &routed
+}
+
+// Execute tenta os candidatos em ordem e devolve o primeiro que responder
+func Execute(in *provider.Request, candidates []Candidate) (*provider.Result, Candidate, error) {
+	var throttled *ThrottledError
+
+	candidates, err := withinCost(in, candidates)
+	if err != nil {
+		return nil, Candidate{}, err
+	}
+
+	err = errNoProviders
+	began, depth := time.Now(), 0
+	for _, c := range candidates {
+		start := time.Now()
+		previous := err
+		req, fitErr := fitContext(c, c.request(in))
+		if fitErr != nil {
+			// pedido grande demais para este modelo; outro candidato pode ter janela maior
+			err = fitErr
+			continue
+		}
+		var result *provider.Result
+		result, err = Call(c.Provider, req)
+		if err == nil {
+			if c.Arm != nil {
+				c.Arm.record(time.Since(start), err)
+			}
+			c.Depth, c.Latency = depth, time.Since(began)
+			return result, c, nil
+		}
+		if attempted(c, err) {
+			depth++
+		}
+
+		// limite nosso não é falha do braço nem do provedor
+		if t, ok := err.(*ThrottledError); ok {
+			throttled = earliest(throttled, t)
+			continue
+		}
+		// orçamento do turno esgotado ou gateway sobrecarregado: os próximos
+		// candidatos seriam recusados também
+		if errors.Is(err, provider.ErrBudgetExhausted) {
+			return nil, c, exhausted(err, previous)
+		}
+		if err == ErrOverloaded {
+			return nil, c, err
+		}
+		if c.Arm != nil {
+			c.Arm.record(time.Since(start), err)
+		}
+
+		// bloqueio de segurança vale para o turno: outro provedor não é a saída
+		if errors.Is(err, provider.ErrSafetyBlocked) {
+			return nil, c, err
+		}
+	}
+
+	if throttled != nil {
+		return nil, Candidate{}, throttled
+	}
+	return nil, Candidate{}, err
+}
+
+// attempted diz se a chamada chegou ao provedor: candidato barrado pelo limite
+// de PROVIDER_RATE_LIMITS, desligado ou sem chave não conta como fallback
+func attempted(c Candidate, err error) bool {
+	var throttled *ThrottledError
+	return !errors.As(err, &throttled) && !errors.Is(err, ErrProviderDisabled) &&
+		!notConfigured(provider.Classify(c.Provider.Name, err))
+}
+
+// exhausted junta ao erro de orçamento a falha que levou ao último fallback
+func exhausted(err, previous error) error {
+	if previous == nil || previous == errNoProviders {
+		return err
+	}
+	return fmt.Errorf("%w (last error: %w)", err, previous)
+}
+
+// earliest fica com o limite que libera primeiro, para o Retry-After
+func earliest(current, next *ThrottledError) *ThrottledError {
+	if current == nil || next.RetryAfter < current.RetryAfter {
+		return next
+	}
+	return current
+}
+
+// ExecuteStream troca de candidato enquanto nada foi enviado ao cliente.
+// Se o provedor trava no meio do stream, o próximo candidato continua a
+// resposta parcial em vez de deixar o cliente esperando.
+func ExecuteStream(in *provider.Request, candidates []Candidate, onChunk func(string) error) (*provider.Result, Candidate, error) {
+	var partial strings.Builder
+	var usage provider.Usage
+	var throttled *ThrottledError
+
+	candidates, err := withinCost(in, candidates)
+	if err != nil {
+		return nil, Candidate{}, err
+	}
+
+	err = errNoProviders
+	began, depth := time.Now(), 0
+	for _, c := range candidates {
+		started := false
+		start := time.Now()
+
+		sent := partial.Len()
+		req := c.request(in)
+		if sent > 0 {
+			req = continuation(req, partial.String())
+		}
+		req, fitErr := fitContext(c, req)
+		if fitErr != nil {
+			err = fitErr
+			continue
+		}
+
+		previous := err
+		var result *provider.Result
+		result, err = CallStream(c.Provider, req, func(chunk string) error {
+			started = true
+			partial.WriteString(chunk)
+			return onChunk(chunk)
+		})
+		if t, ok := err.(*ThrottledError); ok {
+			throttled = earliest(throttled, t)
+			continue
+		}
+		if errors.Is(err, provider.ErrBudgetExhausted) {
+			return nil, c, exhausted(err, previous)
+		}
+		if err == ErrOverloaded {
+			return nil, c, err
+		}
+		if c.Arm != nil {
+			c.Arm.record(time.Since(start), err)
+		}
+
+		if err == nil {
+			// o texto pode ter vindo de mais de um candidato
+			result.Text = partial.String()
+			result.Language = language.Detect(result.Text)
+			result.Usage.PromptTokens += usage.PromptTokens
+			result.Usage.CompletionTokens += usage.CompletionTokens
+			result.Usage.TotalTokens += usage.TotalTokens
+			c.Depth, c.Latency = depth, time.Since(began)
+			return result, c, nil
+		}
+		if attempted(c, err) {
+			depth++
+		}
+
+		if (started && !errors.Is(err, provider.ErrStreamStalled)) || errors.Is(err, provider.ErrSafetyBlocked) {
+			return nil, c, err
+		}
+
+		if started {
+			// a parte já enviada entra na conta do uso
+			usage.CompletionTokens += provider.EstimateTokens(partial.String()[sent:])
+			usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+		}
+	}
+
+	if throttled != nil {
+		return nil, Candidate{}, throttled
+	}
+	return nil, Candidate{}, err
+}
+
+// continuation pede ao próximo provedor que siga a resposta parcial
+func continuation(in *provider.Request, partial string) *provider.Request {
+	next := *in
+	next.History = append(append([]provider.Message{}, in.History...),
+		provider.Message{Role: "user", Content: in.Text},
+		provider.Message{Role: "assistant", Content: partial},
+	)
+	next.Text = "Continue exatamente de onde a resposta anterior parou, sem repetir nada do que já foi escrito."
+	return &next
+}
@@ -0,0 +1,61 @@
+package routing
+
+import (
+	"errors"
+	"testing"
+
+	"lingobot-ai-engine/kv"
+	"lingobot-ai-engine/provider"
+)
+
+// O X-Fallback-Depth conta só os candidatos chamados: o barrado pelo limite
+// não é fallback
+func TestExecuteDepth(t *testing.T) {
+	savedBuckets, savedLimiters := rateBuckets, limiters
+	defer func() { rateBuckets, limiters = savedBuckets, savedLimiters }()
+	rateBuckets = kv.NewMemory()
+
+	limited := &limiter{name: "limitado", requests: newBucket(1)}
+	limiters = map[string]*limiter{"limitado": limited}
+	if _, err := limited.acquire(0); err != nil {
+		t.Fatal(err)
+	}
+
+	ok := func(*provider.Request) (*provider.Result, error) { return &provider.Result{Text: "oi"}, nil }
+	fail := func(*provider.Request) (*provider.Result, error) { return nil, errors.New("boom") }
+	throttled := Candidate{Provider: provider.Provider{Name: "limitado", Call: ok}}
+	failing := Candidate{Provider: provider.Provider{Name: "falho", Call: fail}}
+	answering := Candidate{Provider: provider.Provider{Name: "certo", Call: ok}}
+
+	tests := []struct {
+		name       string
+		candidates []Candidate
+		want       int
+	}{
+		{"primeiro responde", []Candidate{answering}, 0},
+		{"limitado pulado", []Candidate{throttled, answering}, 0},
+		{"falha conta", []Candidate{failing, answering}, 1},
+		{"limitado e falha", []Candidate{throttled, failing, answering}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := &provider.Request{Text: "olá", Budget: provider.NewBudget()}
+			_, c, err := Execute(in, tt.candidates)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Depth != tt.want {
+				t.Errorf("Depth = %d, want %d", c.Depth, tt.want)
+			}
+
+			_, c, err = ExecuteStream(&provider.Request{Text: "olá", Budget: provider.NewBudget()}, tt.candidates, func(string) error { return nil })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Depth != tt.want {
+				t.Errorf("stream Depth = %d, want %d", c.Depth, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package routing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bytedance/sonic"
+
+	"lingobot-ai-engine/provider"
+)
+
+// Tráfego sombra: espelha uma fração dos prompts de produção para um provedor
+// candidato. A resposta sombra só vai para o log, nunca para o cliente.
+//
+//	SHADOW_PROVIDER=groq SHADOW_MODEL=deepseek-r1-distill-llama-70b SHADOW_PERCENT=5
+var shadow = loadShadowConfig()
+
+const maxShadowInFlight = 16
+
+type shadowConfig struct {
+	provider provider.Provider
+	model    string
+	percent  float64
+	slots    chan struct{} // limita chamadas sombra simultâneas
+}
+
+func loadShadowConfig() *shadowConfig {
+	name := os.Getenv("SHADOW_PROVIDER")
+	if name == "" {
+		return nil
+	}
+
+	p, ok := provider.ByName(name)
+	if !ok {
+		log.Printf("⚠️  SHADOW_PROVIDER %q desconhecido, tráfego sombra desligado", name)
+		return nil
+	}
+
+	percent, err := strconv.ParseFloat(os.Getenv("SHADOW_PERCENT"), 64)
+	if err != nil || percent <= 0 || percent > 100 {
+		log.Printf("⚠️  SHADOW_PERCENT inválido, tráfego sombra desligado")
+		return nil
+	}
+
+	return &shadowConfig{
+		provider: p,
+		model:    os.Getenv("SHADOW_MODEL"),
+		percent:  percent,
+		slots:    make(chan struct{}, maxShadowInFlight),
+	}
+}
+
+// Linha de log comparando a resposta de produção com a sombra
+type shadowRecord struct {
+	PromptHash string     `json:"prompt_hash"`
+	Primary    shadowSide `json:"primary"`
+	Shadow     shadowSide `json:"shadow"`
+}
+
+type shadowSide struct {
+	Provider  string  `json:"provider"`
+	Model     string  `json:"model,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+	Response  string  `json:"response,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Mirror dispara a chamada sombra sem bloquear a resposta ao cliente
+func Mirror(in *provider.Request, primary *provider.Result, primaryLatency time.Duration) {
+	if shadow == nil || primary == nil || rand.Float64()*100 >= shadow.percent {
+		return
+	}
+	if allowed(shadow.provider) != nil {
+		return
+	}
+
+	// a sombra também conta no orçamento do turno
+	if in.Budget.Take() != nil {
+		return
+	}
+
+	select {
+	case shadow.slots <- struct{}{}:
+	default:
+		// sombra saturada: descarta em vez de acumular goroutines
+		in.Budget.Refund()
+		return
+	}
+
+	// a sombra não entra na fila do limite de chamadas: sem vaga, não roda
+	if !upstream.tryAcquire() {
+		<-shadow.slots
+		in.Budget.Refund()
+		return
+	}
+
+	// nem espera pelo PROVIDER_RATE_LIMITS: a cota é dos turnos de verdade
+	reserved, ok := tryThrottle(shadow.provider, in)
+	if !ok {
+		upstream.release()
+		<-shadow.slots
+		in.Budget.Refund()
+		return
+	}
+
+	mirrored := *in
+	mirrored.Model = shadow.model
+
+	go func() {
+		defer func() { <-shadow.slots }()
+		defer upstream.release()
+
+		start := time.Now()
+		result, err := shadow.provider.Generate(&mirrored)
+		if result != nil {
+			reserved.settle(result.Usage)
+		}
+
+		sum := sha256.Sum256([]byte(in.Text))
+		record := shadowRecord{
+			PromptHash: hex.EncodeToString(sum[:8]),
+			Primary: shadowSide{
+				Provider:  primary.Provider,
+				LatencyMs: ms(primaryLatency),
+				Response:  primary.Text,
+			},
+			Shadow: shadowSide{
+				Provider:  shadow.provider.Name,
+				Model:     shadow.model,
+				LatencyMs: ms(time.Since(start)),
+			},
+		}
+		if err != nil {
+			record.Shadow.Error = err.Error()
+		} else {
+			record.Shadow.Response = result.Text
+		}
+
+		line, _ := sonic.MarshalString(record)
+		log.Printf("👥 shadow %s", line)
+	}()
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,84 @@
+package routing
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// errStopSequence encerra o stream do provedor quando aparece uma sequência
+// de parada; CallStream a troca por uma resposta completa
+var errStopSequence = errors.New("stop sequence reached")
+
+// stopFilter aplica as sequências de parada no stream, como o CutAtStop faz
+// na resposta inteira. Segura no fim até o tamanho da maior sequência menos um
+// byte, para achar a que vem partida entre dois pedaços; o resto vai ao cliente.
+type stopFilter struct {
+	stops   []string
+	hold    int
+	pending string
+	sent    strings.Builder
+	onChunk func(string) error
+}
+
+func newStopFilter(stops []string, onChunk func(string) error) *stopFilter {
+	f := &stopFilter{stops: stops, onChunk: onChunk}
+	for _, s := range stops {
+		f.hold = max(f.hold, len(s)-1)
+	}
+	return f
+}
+
+// write recebe um pedaço do provedor; devolve errStopSequence na primeira
+// sequência encontrada, depois de enviar o texto anterior a ela
+func (f *stopFilter) write(chunk string) error {
+	if len(f.stops) == 0 {
+		f.sent.WriteString(chunk)
+		return f.onChunk(chunk)
+	}
+
+	f.pending += chunk
+	cut := -1
+	for _, s := range f.stops {
+		if i := strings.Index(f.pending, s); i >= 0 && (cut < 0 || i < cut) {
+			cut = i
+		}
+	}
+	if cut >= 0 {
+		if err := f.emit(f.pending[:cut]); err != nil {
+			return err
+		}
+		f.pending = ""
+		return errStopSequence
+	}
+
+	// o pedaço segurado começa numa fronteira de caractere; sem nada segurado
+	// (paradas de um byte) o corte é o fim do texto
+	cut = max(len(f.pending)-f.hold, 0)
+	for cut > 0 && cut < len(f.pending) && !utf8.RuneStart(f.pending[cut]) {
+		cut--
+	}
+	err := f.emit(f.pending[:cut])
+	f.pending = f.pending[cut:]
+	return err
+}
+
+// flush envia o que ficou segurado quando o stream termina sem parada
+func (f *stopFilter) flush() error {
+	err := f.emit(f.pending)
+	f.pending = ""
+	return err
+}
+
+func (f *stopFilter) emit(text string) error {
+	if text == "" {
+		return nil
+	}
+	f.sent.WriteString(text)
+	return f.onChunk(text)
+}
+
+// text é o que chegou ao cliente
+func (f *stopFilter) text() string {
+	return f.sent.String()
+}
@@ -0,0 +1,121 @@
+package routing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"lingobot-ai-engine/provider"
+)
+
+func TestStopFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		stops   []string
+		chunks  []string
+		want    string
+		stopped bool
+	}{
+		{"sem parada", nil, []string{"Olá, ", "tudo bem?"}, "Olá, tudo bem?", false},
+		{"parada num pedaço", []string{"\n\n"}, []string{"Olá.\n\nTchau"}, "Olá.", true},
+		{"parada partida", []string{"FIM"}, []string{"Olá F", "I", "M depois"}, "Olá ", true},
+		{"primeira parada vence", []string{"B", "A"}, []string{"xxAyyB"}, "xx", true},
+		{"quase parada", []string{"FIM"}, []string{"Olá FI", "NAL"}, "Olá FINAL", false},
+		{"acento segurado", []string{"ção!"}, []string{"informa", "ção."}, "informação.", false},
+		{"parada no começo", []string{"###"}, []string{"###", "resto"}, "", true},
+		{"parada de um byte", []string{"\n"}, []string{"Olá", " mundo\nTchau"}, "Olá mundo", true},
+		{"ponto", []string{"."}, []string{"Olá", " mundo", ". Tchau"}, "Olá mundo", true},
+		{"um byte sem parada", []string{"."}, []string{"Olá ", "ção"}, "Olá ção", false},
+		{"um byte e maior", []string{".", "FIM"}, []string{"Olá F", "IM."}, "Olá ", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got strings.Builder
+			f := newStopFilter(tt.stops, func(chunk string) error {
+				got.WriteString(chunk)
+				return nil
+			})
+
+			stopped := false
+			for _, c := range tt.chunks {
+				if err := f.write(c); err == errStopSequence {
+					stopped = true
+					break
+				} else if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !stopped {
+				if err := f.flush(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got.String() != tt.want || f.text() != tt.want {
+				t.Errorf("sent %q (text %q), want %q", got.String(), f.text(), tt.want)
+			}
+			if stopped != tt.stopped {
+				t.Errorf("stopped = %v, want %v", stopped, tt.stopped)
+			}
+		})
+	}
+}
+
+// Os pedaços enviados nunca partem um caractere
+func TestStopFilterRuneBoundary(t *testing.T) {
+	var chunks []string
+	f := newStopFilter([]string{"ãããã"}, func(chunk string) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	for _, c := range []string{"aéééé", "ééé", "b"} {
+		if err := f.write(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.flush(); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range chunks {
+		if !utf8.ValidString(c) {
+			t.Errorf("chunk %q splits a character", c)
+		}
+	}
+	if got := strings.Join(chunks, ""); got != "aéééééééb" {
+		t.Errorf("sent %q", got)
+	}
+}
+
+// O provedor que ignora a parada tem o stream fechado nela
+func TestCallStreamStop(t *testing.T) {
+	var upstream []string
+	p := provider.Provider{Name: "stop-test", Stream: func(in *provider.Request, onChunk func(string) error) (*provider.Result, error) {
+		for _, c := range []string{"um ", "dois", " FI", "M três", " quatro"} {
+			upstream = append(upstream, c)
+			if err := onChunk(c); err != nil {
+				return nil, err
+			}
+		}
+		return &provider.Result{Text: "um dois FIM três quatro"}, nil
+	}}
+
+	var sent []string
+	result, err := CallStream(p, &provider.Request{Text: "conte", Stop: []string{"FIM"}}, func(chunk string) error {
+		sent = append(sent, chunk)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Text != "um dois " || strings.Join(sent, "") != "um dois " {
+		t.Errorf("result %q, sent %q, want %q", result.Text, sent, "um dois ")
+	}
+	if want := []string{"um ", "dois", " FI", "M três"}; !reflect.DeepEqual(upstream, want) {
+		t.Errorf("upstream read %q, want it closed at the stop: %q", upstream, want)
+	}
+	if result.Provider != "stop-test" || result.Usage.CompletionTokens == 0 {
+		t.Errorf("result = %+v, want provider and estimated usage", result)
+	}
+}
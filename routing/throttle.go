@@ -0,0 +1,306 @@
+package routing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bytedance/sonic"
+
+	"lingobot-ai-engine/kv"
+	"lingobot-ai-engine/provider"
+)
+
+// Limites de um provedor, abaixo dos que ele aplica do lado de lá
+type rateLimitConfig struct {
+	RPM     float64 `json:"rpm"`      // requisições por minuto, somando as instâncias (0 = sem limite)
+	TPM     float64 `json:"tpm"`      // tokens por minuto, prompt + resposta (0 = sem limite)
+	MaxWait string  `json:"max_wait"` // espera máxima na fila antes de desistir
+}
+
+const defaultMaxWait = 2 * time.Second
+
+// ThrottledError indica que o provedor está no limite configurado
+type ThrottledError struct {
+	Provider   string
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("%s rate limit reached, retry in %s", e.Provider, e.RetryAfter.Round(time.Second))
+}
+
+// Baldes de fichas: enchem rate por segundo até capacity. O nível fica
+// negativo quando há reservas esperando na fila.
+type tokenBucket struct {
+	capacity float64
+	rate     float64
+}
+
+func newBucket(perMinute float64) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &tokenBucket{capacity: perMinute, rate: perMinute / 60}
+}
+
+func (b *tokenBucket) refill(level float64, elapsed time.Duration) float64 {
+	if b == nil {
+		return 0
+	}
+	return math.Min(b.capacity, level+elapsed.Seconds()*b.rate)
+}
+
+// wait é quanto falta para haver n fichas
+func (b *tokenBucket) wait(level, n float64) time.Duration {
+	if b == nil || level >= n {
+		return 0
+	}
+	return time.Duration((n - level) / b.rate * float64(time.Second))
+}
+
+// untilFull é quanto falta para o balde voltar a encher
+func (b *tokenBucket) untilFull(level float64) time.Duration {
+	if b == nil {
+		return 0
+	}
+	return b.wait(level, b.capacity)
+}
+
+// O nível dos dois baldes de cada provedor fica no kv, numa chave só: com
+// REDIS_URL a cota do provedor vale para a soma das instâncias, que chamam com
+// a mesma chave. Cada reserva lê o estado, reabastece, desconta e regrava com
+// CompareAndSwap, repetindo se outra instância gravou no meio.
+var rateBuckets = kv.Prefixed(kv.Default, "ratelimit")
+
+const casAttempts = 8
+
+// Estado gravado: nível dos baldes de requisições e de tokens e a hora do último
+// reabastecimento
+type bucketState struct {
+	requests float64
+	tokens   float64
+	last     time.Time
+}
+
+func (st bucketState) encode() []byte {
+	return fmt.Appendf(nil, "%s %s %d",
+		strconv.FormatFloat(st.requests, 'g', -1, 64), strconv.FormatFloat(st.tokens, 'g', -1, 64), st.last.UnixNano())
+}
+
+func decodeState(raw []byte) (bucketState, bool) {
+	var st bucketState
+	var nanos int64
+	if _, err := fmt.Sscanf(string(raw), "%g %g %d", &st.requests, &st.tokens, &nanos); err != nil {
+		return st, false
+	}
+	st.last = time.Unix(0, nanos)
+	return st, true
+}
+
+type limiter struct {
+	name     string
+	requests *tokenBucket
+	tokens   *tokenBucket
+	maxWait  time.Duration
+}
+
+// reservation são os tokens estimados descontados, para o acerto posterior
+type reservation struct {
+	l         *limiter
+	estimated float64
+}
+
+// load lê o estado e reabastece até agora; sem estado os baldes estão cheios
+func (l *limiter) load(now time.Time) ([]byte, bucketState, error) {
+	raw, ok, err := rateBuckets.Get(l.name)
+	if err != nil {
+		return nil, bucketState{}, err
+	}
+	st, valid := decodeState(raw)
+	if !ok || !valid {
+		if l.requests != nil {
+			st.requests = l.requests.capacity
+		}
+		if l.tokens != nil {
+			st.tokens = l.tokens.capacity
+		}
+		st.last = now
+	}
+	if !ok {
+		raw = nil
+	}
+
+	elapsed := max(now.Sub(st.last), 0) // relógio de outra instância adiantado
+	st.requests = l.requests.refill(st.requests, elapsed)
+	st.tokens = l.tokens.refill(st.tokens, elapsed)
+	st.last = now
+	return raw, st, nil
+}
+
+// save grava o estado se ninguém mudou desde load; a chave expira quando os
+// baldes estariam cheios de novo, que é o mesmo que não ter estado
+func (l *limiter) save(old []byte, st bucketState) (bool, error) {
+	ttl := max(l.requests.untilFull(st.requests), l.tokens.untilFull(st.tokens)) + time.Second
+	return rateBuckets.CompareAndSwap(l.name, old, st.encode(), ttl)
+}
+
+// reserve desconta uma requisição e os tokens estimados se a espera couber em
+// maxWait; devolve a espera
+func (l *limiter) reserve(tokens int, maxWait time.Duration) (*reservation, time.Duration, error) {
+	n := float64(tokens)
+	if l.tokens != nil {
+		n = math.Min(n, l.tokens.capacity) // prompt maior que o TPM só espera o balde encher
+	}
+
+	for range casAttempts {
+		now := time.Now()
+		raw, st, err := l.load(now)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		wait := max(l.requests.wait(st.requests, 1), l.tokens.wait(st.tokens, n))
+		if wait > maxWait {
+			return nil, 0, &ThrottledError{Provider: l.name, RetryAfter: wait}
+		}
+		if l.requests != nil {
+			st.requests--
+		}
+		if l.tokens != nil {
+			st.tokens -= n
+		}
+
+		saved, err := l.save(raw, st)
+		if err != nil {
+			return nil, 0, err
+		}
+		if saved {
+			return &reservation{l: l, estimated: n}, wait, nil
+		}
+	}
+	return nil, 0, errContended
+}
+
+var errContended = errors.New("rate limit state contended")
+
+// acquire reserva e espera a vez, até maxWait. Com o store fora do ar a
+// chamada segue sem limite.
+func (l *limiter) acquire(tokens int) (*reservation, error) {
+	r, wait, err := l.reserve(tokens, l.maxWait)
+	var throttled *ThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		return nil, err
+	case err != nil:
+		log.Printf("⚠️  Limite de %s indisponível: %v", l.name, err)
+		return nil, nil
+	}
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+	return r, nil
+}
+
+// settle acerta o balde de tokens com o uso real informado pelo provedor
+func (r *reservation) settle(usage provider.Usage) {
+	if r == nil || r.l.tokens == nil || usage.TotalTokens == 0 {
+		return
+	}
+	delta := float64(usage.TotalTokens) - r.estimated
+	for range casAttempts {
+		raw, st, err := r.l.load(time.Now())
+		if err != nil {
+			log.Printf("⚠️  Acerto do limite de %s falhou: %v", r.l.name, err)
+			return
+		}
+		st.tokens -= delta
+		saved, err := r.l.save(raw, st)
+		if err != nil {
+			log.Printf("⚠️  Acerto do limite de %s falhou: %v", r.l.name, err)
+		}
+		if saved || err != nil {
+			return
+		}
+	}
+	log.Printf("⚠️  Acerto do limite de %s falhou: %v", r.l.name, errContended)
+}
+
+// PROVIDER_RATE_LIMITS='{"groq":{"rpm":30,"tpm":6000},"mistral":{"rpm":60,"max_wait":"5s"}}'
+var limiters = loadRateLimits(os.Getenv("PROVIDER_RATE_LIMITS"))
+
+func loadRateLimits(raw string) map[string]*limiter {
+	if raw == "" {
+		return nil
+	}
+
+	var entries map[string]json.RawMessage
+	if err := sonic.UnmarshalString(raw, &entries); err != nil {
+		log.Printf("⚠️  PROVIDER_RATE_LIMITS inválido, sem limites por provedor: %v", err)
+		return nil
+	}
+
+	out := make(map[string]*limiter, len(entries))
+	for name, entry := range entries {
+		var cfg rateLimitConfig
+		if err := sonic.Unmarshal(entry, &cfg); err != nil {
+			log.Printf("⚠️  Provedor %q inválido em PROVIDER_RATE_LIMITS: %v", name, err)
+			continue
+		}
+
+		maxWait := defaultMaxWait
+		if cfg.MaxWait != "" {
+			d, err := time.ParseDuration(cfg.MaxWait)
+			if err != nil || d < 0 {
+				log.Printf("⚠️  max_wait inválido para %q em PROVIDER_RATE_LIMITS, usando %s", name, maxWait)
+			} else {
+				maxWait = d
+			}
+		}
+
+		if !perMinute(cfg.RPM) || !perMinute(cfg.TPM) {
+			log.Printf("⚠️  rpm/tpm inválido para %q em PROVIDER_RATE_LIMITS (inteiro não negativo), provedor ignorado", name)
+			continue
+		}
+
+		out[name] = &limiter{name: name, requests: newBucket(cfg.RPM), tokens: newBucket(cfg.TPM), maxWait: maxWait}
+	}
+	return out
+}
+
+// perMinute aceita só inteiros não negativos: 0.5 viraria "sem limite"
+func perMinute(v float64) bool {
+	return v >= 0 && v == math.Trunc(v)
+}
+
+// throttle segura a chamada até caber nos limites do provedor; devolve a
+// reserva para o acerto posterior
+func throttle(p provider.Provider, in *provider.Request) (*reservation, error) {
+	l, ok := limiters[p.Name]
+	if !ok {
+		return nil, nil
+	}
+	return l.acquire(in.PromptTokens())
+}
+
+// tryThrottle reserva sem esperar, para tráfego dispensável como a sombra:
+// false quando o provedor está no limite. Com o store fora do ar a chamada segue.
+func tryThrottle(p provider.Provider, in *provider.Request) (*reservation, bool) {
+	l, ok := limiters[p.Name]
+	if !ok {
+		return nil, true
+	}
+	r, _, err := l.reserve(in.PromptTokens(), 0)
+	if errors.As(err, new(*ThrottledError)) {
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("⚠️  Limite de %s indisponível: %v", l.name, err)
+	}
+	return r, true
+}
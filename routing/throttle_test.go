@@ -0,0 +1,142 @@
+package routing
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"lingobot-ai-engine/kv"
+	"lingobot-ai-engine/provider"
+)
+
+// Duas instâncias com o mesmo store dividem os baldes do provedor, e o que
+// não coube não fica descontado
+func TestThrottleSharedBucket(t *testing.T) {
+	saved := rateBuckets
+	defer func() { rateBuckets = saved }()
+	rateBuckets = kv.NewMemory()
+
+	a := &limiter{name: "groq", requests: newBucket(2), tokens: newBucket(100)}
+	b := &limiter{name: "groq", requests: newBucket(2), tokens: newBucket(100)}
+
+	tests := []struct {
+		name      string
+		l         *limiter
+		tokens    int
+		throttled bool
+	}{
+		{"primeira instância", a, 60, false},
+		{"tokens além do TPM", b, 50, true},
+		{"segunda instância", b, 30, false},
+		{"RPM somado", a, 1, true},
+	}
+
+	var first *reservation
+	for _, tt := range tests {
+		r, err := tt.l.acquire(tt.tokens)
+		var throttled *ThrottledError
+		if got := errors.As(err, &throttled); got != tt.throttled {
+			t.Fatalf("%s: err = %v, want throttled %v", tt.name, err, tt.throttled)
+		}
+		if !tt.throttled && r == nil {
+			t.Fatalf("%s: no reservation", tt.name)
+		}
+		if first == nil {
+			first = r
+		}
+	}
+
+	// o uso real menor que a estimativa devolve tokens ao balde
+	first.settle(provider.Usage{TotalTokens: 20})
+	raw, _, _ := rateBuckets.Get("groq")
+	st, ok := decodeState(raw)
+	if !ok {
+		t.Fatalf("state %q", raw)
+	}
+	if math.Abs(st.requests) > 0.01 || math.Abs(st.tokens-50) > 0.01 {
+		t.Errorf("state = %+v, want 0 requests and 50 tokens", st)
+	}
+}
+
+// O balde reabastece aos poucos: esvaziado, a próxima vaga abre em 60/rpm
+// segundos, sem a rajada de um balde novo a cada minuto
+func TestThrottleRefill(t *testing.T) {
+	saved := rateBuckets
+	defer func() { rateBuckets = saved }()
+	rateBuckets = kv.NewMemory()
+
+	l := &limiter{name: "mistral", requests: newBucket(60)}
+	for i := range 60 {
+		if _, wait, err := l.reserve(0, 0); err != nil || wait != 0 {
+			t.Fatalf("reserve %d: wait %s, err %v", i, wait, err)
+		}
+	}
+
+	if _, _, err := l.reserve(0, 0); !errors.As(err, new(*ThrottledError)) {
+		t.Fatalf("full bucket: err = %v, want throttled", err)
+	}
+	_, wait, err := l.reserve(0, 2*time.Second)
+	if err != nil || wait < 900*time.Millisecond || wait > time.Second {
+		t.Errorf("queued: wait %s, err %v; want about 1s", wait, err)
+	}
+}
+
+// rpm e tpm fracionários ou negativos não viram "sem limite" calados
+func TestLoadRateLimits(t *testing.T) {
+	limits := loadRateLimits(`{
+		"groq": {"rpm": 30, "tpm": 6000},
+		"mistral": {"rpm": 0.5},
+		"cohere": {"tpm": -10},
+		"deepseek": {"rpm": 10, "max_wait": "nope"}
+	}`)
+
+	if _, ok := limits["groq"]; !ok {
+		t.Error("groq: valid limit dropped")
+	}
+	for _, name := range []string{"mistral", "cohere"} {
+		if _, ok := limits[name]; ok {
+			t.Errorf("%s: invalid limit accepted", name)
+		}
+	}
+	if l := limits["deepseek"]; l == nil || l.maxWait != defaultMaxWait {
+		t.Errorf("deepseek: limiter %+v, want default max_wait", l)
+	}
+}
+
+// A sombra não espera nem passa do limite do provedor, e o que ela reserva é
+// descontado dos turnos de verdade
+func TestMirrorThrottled(t *testing.T) {
+	savedBuckets, savedLimiters, savedShadow := rateBuckets, limiters, shadow
+	defer func() { rateBuckets, limiters, shadow = savedBuckets, savedLimiters, savedShadow }()
+	rateBuckets = kv.NewMemory()
+
+	calls := make(chan struct{}, 4)
+	p := provider.Provider{Name: "sombra", Call: func(*provider.Request) (*provider.Result, error) {
+		calls <- struct{}{}
+		return &provider.Result{Text: "oi"}, nil
+	}}
+	limiters = map[string]*limiter{"sombra": {name: "sombra", requests: newBucket(1)}}
+	shadow = &shadowConfig{provider: p, percent: 100, slots: make(chan struct{}, maxShadowInFlight)}
+
+	primary := &provider.Result{Provider: "gemini", Text: "olá"}
+	for range 2 {
+		in := &provider.Request{Text: "olá", Budget: provider.NewBudget()}
+		Mirror(in, primary, time.Millisecond)
+	}
+
+	select {
+	case <-calls:
+	case <-time.After(time.Second):
+		t.Fatal("first shadow call never ran")
+	}
+	select {
+	case <-calls:
+		t.Error("second shadow call ran past the rate limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if _, _, err := limiters["sombra"].reserve(0, 0); !errors.As(err, new(*ThrottledError)) {
+		t.Errorf("reserve after shadow: err = %v, want throttled", err)
+	}
+}
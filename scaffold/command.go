@@ -0,0 +1,59 @@
+package scaffold
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+const usage = `usage: lingobot-ai-engine newprovider [-dry-run] <spec.json>
+
+  rodar na raiz do repositório; -dry-run só lista o que seria gerado`
+
+// Command executa o subcomando "newprovider"
+func Command(args []string) error {
+	flags := flag.NewFlagSet("newprovider", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "")
+	flags.Usage = func() {}
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return errors.New(usage)
+	}
+
+	if _, err := os.Stat("provider/provider.go"); err != nil {
+		return errors.New("provider/provider.go not found: run from the repository root")
+	}
+
+	s, err := LoadSpec(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	files, err := Generate(".", s)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		action := "update"
+		if f.New {
+			action = "create"
+		}
+		fmt.Printf("%s %s\n", action, f.Path)
+	}
+	if *dryRun {
+		return nil
+	}
+	if err := Write(".", files); err != nil {
+		return err
+	}
+
+	fmt.Printf(`
+%s gerado. Falta:
+  - conferir provider/testdata/fixtures/%s/ok.json com uma resposta real da API
+    (RECORD_FIXTURES=provider/testdata/fixtures grava uma ao lado)
+  - go test ./provider ./replay -run '%s|Fixtures'
+  - decidir se entra no DefaultChain ou no plano de raciocínio (routing/plan.go)
+  - documentar %s no deploy
+`, s.Display, s.Name, s.Ident, s.KeyEnv)
+	return nil
+}
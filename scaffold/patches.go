@@ -0,0 +1,110 @@
+package scaffold
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"strings"
+)
+
+// patch insere o provedor num arquivo existente do repositório
+type patch struct {
+	path  string
+	apply func(current []byte, s *Spec) ([]byte, error)
+}
+
+var patches = []patch{
+	{"provider/provider.go", patchRegistry},
+	{"server/server.go", patchRoutes},
+	{"main.go", patchEndpoints},
+	{"server/openapi.json", patchOpenAPI},
+}
+
+// insertBefore põe line antes da primeira linha que contém anchor depois de start
+func insertBefore(current []byte, start, anchor, line string) ([]byte, error) {
+	src := string(current)
+	from := strings.Index(src, start)
+	if from == -1 {
+		return nil, fmt.Errorf("anchor %q not found", start)
+	}
+	at := strings.Index(src[from:], anchor)
+	if at == -1 {
+		return nil, fmt.Errorf("anchor %q not found after %q", anchor, start)
+	}
+	at += from
+	// volta para o começo da linha do anchor
+	at = strings.LastIndex(src[:at], "\n") + 1
+	return []byte(src[:at] + line + "\n" + src[at:]), nil
+}
+
+func gofmt(src []byte, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(src)
+}
+
+// patchRegistry acrescenta o provedor no fim do registry
+func patchRegistry(current []byte, s *Spec) ([]byte, error) {
+	if bytes.Contains(current, []byte(`Name: "`+s.Name+`"`)) {
+		return nil, fmt.Errorf("provider %q is already registered", s.Name)
+	}
+	entry := fmt.Sprintf("\t{Name: %q, Call: Call%s", s.Name, s.Ident)
+	if s.Stream {
+		entry += ", Stream: Stream" + s.Ident
+	}
+	entry += fmt.Sprintf(", Key: %q, API: %q},", s.KeyEnv, "openai-chat/v1")
+	return gofmt(insertBefore(current, "var registry = []Provider{", "\n}", entry))
+}
+
+// patchRoutes cria a rota /<name> antes da do mock
+func patchRoutes(current []byte, s *Spec) ([]byte, error) {
+	line := fmt.Sprintf("\t\tcase %q:\n\t\t\tcreateAIHandler(byName(%q))(ctx)", "/"+s.Name, s.Name)
+	return gofmt(insertBefore(current, "handler := func", `case "/mock":`, line))
+}
+
+// patchEndpoints lista a rota no log de inicialização
+func patchEndpoints(current []byte, s *Spec) ([]byte, error) {
+	line := fmt.Sprintf("\t\t{%q, %q, %q},", "POST", "/"+s.Name, s.Display)
+	return gofmt(insertBefore(current, "endpoints := ", `"/mock"`, line))
+}
+
+// patchOpenAPI copia a operação do /together para /<name>, logo depois dela.
+// Edita o texto em vez de reserializar, para não reordenar o arquivo.
+func patchOpenAPI(current []byte, s *Spec) ([]byte, error) {
+	var spec struct {
+		Paths map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(current, &spec); err != nil {
+		return nil, err
+	}
+	if _, exists := spec.Paths["/"+s.Name]; exists {
+		return nil, fmt.Errorf("path /%s already documented", s.Name)
+	}
+
+	src := string(current)
+	const open = "\n    \"/together\": {\n"
+	from := strings.Index(src, open)
+	if from == -1 {
+		return nil, errors.New("path /together not found to copy from")
+	}
+	from++
+	end := strings.Index(src[from:], "\n    }")
+	if end == -1 {
+		return nil, errors.New("end of /together not found")
+	}
+	end += from + len("\n    }")
+	block := src[from:end]
+
+	copied := strings.Replace(block, `"/together"`, fmt.Sprintf("%q", "/"+s.Name), 1)
+	copied = strings.Replace(copied, `"chatTogether"`, fmt.Sprintf("%q", "chat"+s.Ident), 1)
+	copied = strings.Replace(copied, "provedor Together AI,", "provedor "+s.Display+",", 1)
+
+	out := src[:end] + ",\n" + copied + src[end:]
+	if !json.Valid([]byte(out)) {
+		return nil, errors.New("patched document is not valid JSON")
+	}
+	return []byte(out), nil
+}
@@ -0,0 +1,304 @@
+// Package scaffold gera o esqueleto de um provedor novo no formato OpenAI a
+// partir de uma spec em JSON, em vez de copiar o together.go à mão:
+//
+//	{
+//	  "name": "fireworks",
+//	  "display_name": "Fireworks AI",
+//	  "url": "https://api.fireworks.ai/inference/v1/chat/completions",
+//	  "key_env": "FIREWORKS_KEY",
+//	  "model": "accounts/fireworks/models/llama-v3p3-70b-instruct",
+//	  "reasoning_model": "accounts/fireworks/models/deepseek-r1",
+//	  "stream": true,
+//	  "headers": {"X-Title": "LingoBot"}
+//	}
+//
+// Gera provider/<name>.go (payload, Call e Stream), o teste e a fixture
+// provider/testdata/fixtures/<name>/ok.json, no formato do RECORD_FIXTURES que
+// go test ./replay reexecuta, e registra o provedor no registry, na rota
+// /<name>, na lista de endpoints do main.go e no openapi.json.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"lingobot-ai-engine/internal/routes"
+	"lingobot-ai-engine/provider"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+var templates = template.Must(template.ParseFS(templateFiles, "templates/*.tmpl"))
+
+// Spec descreve o provedor novo
+type Spec struct {
+	Name           string            `json:"name"` // rota, nome no registry e nos logs
+	Display        string            `json:"display_name"`
+	Ident          string            `json:"go_name"` // sufixo de CallX/StreamX; padrão é o nome capitalizado
+	URL            string            `json:"url"`
+	KeyEnv         string            `json:"key_env"`
+	Model          string            `json:"model"`
+	ReasoningModel string            `json:"reasoning_model"`
+	MaxTokens      int               `json:"max_tokens"`
+	Temperature    *float64          `json:"temperature"`
+	Stream         bool              `json:"stream"`
+	Format         string            `json:"format"` // só "openai" por enquanto
+	HeaderMap      map[string]string `json:"headers"`
+}
+
+// header extra, em ordem estável para o código gerado
+type header struct{ Name, Value string }
+
+var (
+	namePattern  = regexp.MustCompile(`^[a-z][a-z0-9]*$`)
+	identPattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	envPattern   = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+)
+
+// LoadSpec lê e valida a spec, preenchendo os padrões
+func LoadSpec(path string) (*Spec, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Spec
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("spec %s: %w", path, err)
+	}
+
+	if s.Format == "" {
+		s.Format = "openai"
+	}
+	if s.Ident == "" && s.Name != "" {
+		s.Ident = strings.ToUpper(s.Name[:1]) + s.Name[1:]
+	}
+	if s.Display == "" {
+		s.Display = s.Ident
+	}
+	if s.KeyEnv == "" {
+		s.KeyEnv = strings.ToUpper(s.Name) + "_KEY"
+	}
+	if s.MaxTokens == 0 {
+		s.MaxTokens = 1000
+	}
+	if s.Temperature == nil {
+		t := 0.7
+		s.Temperature = &t
+	}
+
+	switch {
+	case !namePattern.MatchString(s.Name):
+		return nil, fmt.Errorf("name %q must be lowercase letters and digits", s.Name)
+	case s.Name == "mock":
+		return nil, errors.New("name \"mock\" is reserved")
+	case routes.Taken(s.Name):
+		return nil, fmt.Errorf("name %q collides with the fixed route /%s", s.Name, s.Name)
+	case !identPattern.MatchString(s.Ident):
+		return nil, fmt.Errorf("go_name %q is not an exported Go identifier", s.Ident)
+	case s.Format != "openai":
+		return nil, fmt.Errorf("format %q is not supported: only OpenAI-compatible chat/completions can be generated", s.Format)
+	case !strings.HasPrefix(s.URL, "https://") && !strings.HasPrefix(s.URL, "http://"):
+		return nil, fmt.Errorf("url %q must be an http(s) chat/completions endpoint", s.URL)
+	case !envPattern.MatchString(s.KeyEnv):
+		return nil, fmt.Errorf("key_env %q is not a valid environment variable name", s.KeyEnv)
+	case s.Model == "":
+		return nil, errors.New("model is required")
+	case s.MaxTokens < 0:
+		return nil, errors.New("max_tokens must be positive")
+	}
+	values := []string{s.URL, s.Model, s.ReasoningModel, s.Display}
+	for name, value := range s.HeaderMap {
+		values = append(values, name, value)
+	}
+	for _, v := range values {
+		if strings.ContainsAny(v, "\"\\\n`") {
+			return nil, fmt.Errorf("%q has characters that cannot go into generated code", v)
+		}
+	}
+	return &s, nil
+}
+
+// Var é o prefixo dos identificadores não exportados (fireworksURL, fireworksPayload)
+func (s *Spec) Var() string {
+	return strings.ToLower(s.Ident[:1]) + s.Ident[1:]
+}
+
+// Temp é a temperature já com o padrão
+func (s *Spec) Temp() float64 {
+	return *s.Temperature
+}
+
+// Headers devolve os headers extras ordenados pelo nome
+func (s *Spec) Headers() []header {
+	list := make([]header, 0, len(s.HeaderMap))
+	for name, value := range s.HeaderMap {
+		list = append(list, header{name, value})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// File é um arquivo gerado ou alterado, relativo à raiz do repositório
+type File struct {
+	Path    string
+	Content []byte
+	New     bool
+}
+
+// Generate monta os arquivos do provedor a partir da raiz do repositório em root
+func Generate(root string, s *Spec) ([]File, error) {
+	code := filepath.Join("provider", s.Name+".go")
+	if _, err := os.Stat(filepath.Join(root, code)); err == nil {
+		return nil, fmt.Errorf("%s already exists", code)
+	}
+
+	var files []File
+	for _, t := range []struct{ template, path string }{
+		{"provider.go.tmpl", code},
+		{"provider_test.go.tmpl", filepath.Join("provider", s.Name+"_test.go")},
+	} {
+		src, err := render(t.template, s)
+		if err != nil {
+			return nil, err
+		}
+		formatted, err := format.Source(src)
+		if err != nil {
+			return nil, fmt.Errorf("generated %s does not compile: %w", t.path, err)
+		}
+		files = append(files, File{Path: t.path, Content: formatted, New: true})
+	}
+
+	fixture, err := goldenFixture(s)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, File{Path: filepath.Join("provider", "testdata", "fixtures", s.Name, "ok.json"), Content: fixture, New: true})
+
+	for _, p := range patches {
+		path := filepath.Join(root, p.path)
+		current, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := p.apply(current, s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.path, err)
+		}
+		files = append(files, File{Path: p.path, Content: updated})
+	}
+	return files, nil
+}
+
+func render(name string, s *Spec) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Turno da fixture gerada
+const (
+	goldenSystem = "You are a friendly language tutor."
+	goldenText   = "How do I say \"good morning\" in Portuguese?"
+)
+
+// goldenFixture é a chamada do turno de teste como o RECORD_FIXTURES a
+// gravaria: o corpo que o payload gerado deve produzir e uma resposta fiel da
+// API, para o teste do provedor e para go test ./replay
+func goldenFixture(s *Spec) ([]byte, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"model": s.Model,
+		"messages": []map[string]string{
+			{"role": "system", "content": goldenSystem},
+			{"role": "user", "content": goldenText},
+		},
+		"max_tokens":  s.MaxTokens,
+		"temperature": *s.Temperature,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := render("response.json.tmpl", s)
+	if err != nil {
+		return nil, err
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, response); err != nil {
+		return nil, fmt.Errorf("response.json.tmpl: %w", err)
+	}
+
+	header := map[string]string{"Authorization": "REDACTED", "Content-Type": "application/json"}
+	for name, value := range s.HeaderMap {
+		header[name] = value
+	}
+
+	f := provider.Fixture{
+		Provider: s.Name,
+		Recorded: time.Now().UTC().Truncate(time.Second),
+		Request: provider.FixtureRequest{
+			Text:    goldenText,
+			History: []provider.Message{{Role: "system", Content: goldenSystem}},
+		},
+		Exchanges: []provider.Exchange{{
+			Method:         "POST",
+			URL:            s.URL,
+			Header:         header,
+			Body:           string(body),
+			Status:         200,
+			ResponseHeader: map[string]string{"Content-Type": "application/json"},
+			Response:       compact.String(),
+		}},
+		Result: &provider.FixtureResult{
+			Text:  "Bom dia!",
+			Usage: provider.Usage{PromptTokens: 24, CompletionTokens: 4, TotalTokens: 28},
+		},
+	}
+	out, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// Write grava os arquivos; os novos nunca sobrescrevem um existente
+func Write(root string, files []File) error {
+	for _, f := range files {
+		path := filepath.Join(root, f.Path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if f.New {
+			flag = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+		}
+		out, err := os.OpenFile(path, flag, 0o644)
+		if err != nil {
+			return err
+		}
+		if _, err := out.Write(f.Content); err != nil {
+			out.Close()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
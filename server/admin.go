@@ -0,0 +1,73 @@
+package server
+
+import (
+	"crypto/subtle"
+	"os"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/moderation"
+	"lingobot-ai-engine/provider"
+)
+
+// blocklistWebhookHandler recebe regras assinadas com HMAC-SHA256 em X-Lingobot-Signature
+func blocklistWebhookHandler(ctx *fasthttp.RequestCtx) {
+	if !ctx.IsPost() {
+		writeErrorCode(ctx, fasthttp.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if os.Getenv("ADMIN_WEBHOOK_SECRET") == "" {
+		writeErrorCode(ctx, fasthttp.StatusNotFound, codeNotFound, "endpoint not found")
+		return
+	}
+
+	if !moderation.VerifySignature(ctx.PostBody(), ctx.Request.Header.Peek("X-Lingobot-Signature")) {
+		writeErrorCode(ctx, fasthttp.StatusUnauthorized, codeUnauthorized, "invalid signature")
+		return
+	}
+
+	if err := moderation.Apply(ctx.PostBody()); err != nil {
+		writeError(ctx, fasthttp.StatusBadRequest, err)
+		return
+	}
+
+	body, _ := sonic.Marshal(map[string]string{"version": moderation.Version()})
+	ctx.SetContentType("application/json")
+	ctx.SetBody(body)
+}
+
+// adminAuthorized confere o "Authorization: Bearer <ADMIN_TOKEN>" das rotas de
+// consulta do admin; sem ADMIN_TOKEN elas não existem. Responde se recusar.
+func adminAuthorized(ctx *fasthttp.RequestCtx) bool {
+	token := os.Getenv("ADMIN_TOKEN")
+	if token == "" {
+		writeErrorCode(ctx, fasthttp.StatusNotFound, codeNotFound, "endpoint not found")
+		return false
+	}
+
+	given, ok := strings.CutPrefix(string(ctx.Request.Header.Peek("Authorization")), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		writeErrorCode(ctx, fasthttp.StatusUnauthorized, codeUnauthorized, "invalid admin token")
+		return false
+	}
+	return true
+}
+
+// keysHandler mostra as chaves dos provedores e as que estão fora de uso por
+// cota estourada, sem os valores (GET /admin/keys)
+func keysHandler(ctx *fasthttp.RequestCtx) {
+	if !adminAuthorized(ctx) {
+		return
+	}
+	if !ctx.IsGet() {
+		writeErrorCode(ctx, fasthttp.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	body, _ := sonic.Marshal(map[string]interface{}{"keys": provider.Keys()})
+	ctx.SetContentType("application/json")
+	ctx.SetBody(body)
+}
@@ -0,0 +1,412 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/conversation"
+	"lingobot-ai-engine/dedupe"
+	"lingobot-ai-engine/hooks"
+	"lingobot-ai-engine/language"
+	"lingobot-ai-engine/moderation"
+	"lingobot-ai-engine/postprocess"
+	"lingobot-ai-engine/provider"
+	"lingobot-ai-engine/routing"
+)
+
+// Corpo de pedido dos endpoints de chat
+type chatRequest struct {
+	Text             string                  `json:"text"`
+	History          []provider.Message      `json:"history"`
+	Persona          string                  `json:"persona"`
+	SessionID        string                  `json:"session_id"`
+	ConversationID   string                  `json:"conversation_id"`
+	Language         string                  `json:"language"`
+	ForceMistral     bool                    `json:"force_mistral"`
+	ForceCohere      bool                    `json:"force_cohere"`
+	ForceGroq        bool                    `json:"force_groq"`
+	Reasoning        bool                    `json:"reasoning"`
+	IncludeReasoning bool                    `json:"include_reasoning"`
+	Strategy         string                  `json:"strategy"`
+	Model            string                  `json:"model"` // alias ou modelo permitido em MODELS
+	Gemini           *provider.GeminiOptions `json:"gemini"`
+	MaxCostUSD       float64                 `json:"max_cost_usd"`   // teto de custo por chamada ao provedor
+	Level            string                  `json:"level"`          // nível CEFR do aluno (A1–C2)
+	Post             []string                `json:"post"`           // limpeza da resposta (strip_markdown, max_chars:N...)
+	Stop             []string                `json:"stop"`           // sequências que encerram a resposta
+	ReplyLanguage    string                  `json:"reply_language"` // idioma cobrado da resposta (ISO 639-1) ou "auto"
+	Debug            bool                    `json:"debug"`
+
+	// origem do turno, para os hooks
+	route  string
+	client string
+	// API key do chamador, para o teto de custo; o client prefere a sessão
+	key string
+
+	// idioma pedido à resposta pelos endpoints de tutor (target_language...)
+	reply string
+	// reply_language "auto" sem idioma detectável: nenhum idioma é cobrado,
+	// nem o declarado em language
+	replyUnknown bool
+
+	// o nível entra só como instrução, sem conferência nem reescrita do
+	// vocabulário: respostas em JSON e retornos na língua nativa do aluno
+	levelOnly bool
+
+	// conferência do vocabulário da resposta, para os hooks
+	vocabulary *hooks.Vocabulary
+
+	// a resposta veio do regenerate, sem chamar provedor
+	cached bool
+}
+
+// from marca a rota, o cliente e a API key de onde veio o turno
+func (r *chatRequest) from(route, client, key string) *chatRequest {
+	r.route, r.client, r.key = route, client, key
+	return r
+}
+
+// replyLanguage é o código do idioma em que a resposta deveria vir: o pedido
+// pelo endpoint, o declarado ou o detectado no texto. Vazio quando não há
+// código reconhecível ("inglês" em vez de "en") e com reply_language "auto"
+// sem idioma detectado.
+func (r *chatRequest) replyLanguage() string {
+	if r.replyUnknown {
+		return ""
+	}
+	lang := r.reply
+	if lang == "" {
+		lang = language.Normalize(r.Language)
+	}
+	if lang == "" && r.reply == "" {
+		lang = language.Detect(r.Text)
+	}
+	if len(lang) != 2 {
+		return ""
+	}
+	return lang
+}
+
+// levelSession identifica a sessão que guarda o nível padrão: session_id ou,
+// sem ele, a conversa
+func (r *chatRequest) levelSession() string {
+	if r.SessionID != "" {
+		return r.SessionID
+	}
+	return r.ConversationID
+}
+
+func (r *chatRequest) routingOptions(ctx *fasthttp.RequestCtx) routing.Options {
+	return r.options(clientID(ctx, r.SessionID))
+}
+
+// plan monta os candidatos do turno de chat, o mesmo no HTTP e no gRPC: name
+// fixa o provedor como a rota /<name>; vazio usa o plano de fallback da rota
+// path. Rota desligada em ENABLED_ROUTES ou DISABLED_ROUTES não atende por
+// nenhum dos dois.
+func (r *chatRequest) plan(path, name string) ([]routing.Candidate, error) {
+	var p provider.Provider
+	if name != "" {
+		var ok bool
+		if p, ok = provider.ByName(name); !ok {
+			return nil, &invalidOptionError{fmt.Errorf("unknown provider %q", name)}
+		}
+		path = "/" + name
+	}
+	if !RouteEnabled(path) {
+		return nil, errRouteDisabled
+	}
+
+	if name == "" {
+		return routing.Plan(r.options(r.client)), nil
+	}
+	return routing.Fixed(p, r.Model)
+}
+
+// writePlanError responde a falha de plan no HTTP
+func writePlanError(ctx *fasthttp.RequestCtx, err error) {
+	if err == errRouteDisabled {
+		writeErrorCode(ctx, fasthttp.StatusNotFound, codeNotFound, err.Error())
+		return
+	}
+	writeError(ctx, fasthttp.StatusBadRequest, err)
+}
+
+func (r *chatRequest) options(client string) routing.Options {
+	return routing.Options{
+		Text:         r.Text,
+		Language:     r.Language,
+		Client:       client,
+		Strategy:     r.Strategy,
+		Model:        r.Model,
+		Reasoning:    r.Reasoning,
+		ForceMistral: r.ForceMistral,
+	}
+}
+
+// Corpo de resposta dos endpoints de IA
+type aiResponse struct {
+	Response   string                 `json:"response"`
+	Language   string                 `json:"response_language,omitempty"`
+	Reasoning  string                 `json:"reasoning,omitempty"`
+	Usage      *provider.Usage        `json:"usage,omitempty"`
+	Experiment *routing.ExperimentTag `json:"experiment,omitempty"`
+	Timings    *Timings               `json:"timings,omitempty"`
+}
+
+// newAIResponse só expõe o raciocínio quando o cliente pede
+func newAIResponse(result *provider.Result, includeReasoning bool) aiResponse {
+	out := aiResponse{Response: result.Text, Language: result.Language}
+	if includeReasoning {
+		out.Reasoning = result.Reasoning
+	}
+	if result.Usage.TotalTokens > 0 {
+		usage := result.Usage
+		out.Usage = &usage
+	}
+	return out
+}
+
+// parseChatRequest valida método e corpo; devolve false se já respondeu com erro
+func parseChatRequest(ctx *fasthttp.RequestCtx, req *chatRequest) bool {
+	if !ctx.IsPost() {
+		writeErrorCode(ctx, fasthttp.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+		return false
+	}
+
+	if err := sonic.Unmarshal(ctx.PostBody(), req); err != nil {
+		writeErrorCode(ctx, fasthttp.StatusBadRequest, codeInvalidJSON, "invalid JSON")
+		return false
+	}
+
+	switch err := req.validate(); {
+	case err == nil:
+	case err == errTextRequired, errors.Is(err, routing.ErrModelNotAllowed), errors.As(err, new(*invalidOptionError)):
+		writeError(ctx, fasthttp.StatusBadRequest, err)
+		return false
+	default:
+		writeError(ctx, fasthttp.StatusUnprocessableEntity, err)
+		return false
+	}
+
+	return true
+}
+
+var (
+	errTextRequired    = errors.New("text field is required")
+	errResponseBlocked = errors.New("response blocked by moderation policy")
+)
+
+// invalidOptionError marca opção de provedor inválida no pedido (400)
+type invalidOptionError struct{ err error }
+
+func (e *invalidOptionError) Error() string { return e.err.Error() }
+func (e *invalidOptionError) Unwrap() error { return e.err }
+
+// validate confere o texto, o modelo pedido e as opções, sem depender do transporte;
+// a moderação de entrada é etapa do pipeline
+func (r *chatRequest) validate() error {
+	if r.Text == "" {
+		return errTextRequired
+	}
+	if r.Model != "" {
+		if _, err := routing.Models(r.Model, ""); err != nil {
+			return err
+		}
+	}
+	if err := r.Gemini.Validate(); err != nil {
+		return &invalidOptionError{err}
+	}
+	if r.MaxCostUSD < 0 {
+		return &invalidOptionError{errInvalidMaxCost}
+	}
+	if _, err := postprocess.Parse(r.Post); err != nil {
+		return &invalidOptionError{err}
+	}
+	if err := provider.ValidateStop(r.Stop); err != nil {
+		return &invalidOptionError{err}
+	}
+	if err := r.resolveReplyLanguage(); err != nil {
+		return err
+	}
+	level, err := resolveLevel(r.levelSession(), r.Level)
+	if err != nil {
+		return err
+	}
+	r.Level = level
+	return nil
+}
+
+// runTurn comprime o histórico e executa o plano; devolve false se já respondeu com erro
+func runTurn(ctx *fasthttp.RequestCtx, req *chatRequest, in *provider.Request, candidates []routing.Candidate, timer *turnTimer) (*provider.Result, routing.Candidate, bool) {
+	if req.route == "" {
+		req.from(string(ctx.Path()), clientID(ctx, req.SessionID), apiKey(ctx))
+	}
+	result, candidate, err := executeTurn(req, in, candidates, timer)
+
+	var throttled *routing.ThrottledError
+	switch {
+	case errors.As(err, &throttled), errors.Is(err, routing.ErrOverloaded):
+		countShed()
+		writeError(ctx, fasthttp.StatusServiceUnavailable, err)
+		return nil, candidate, false
+	case errors.Is(err, dedupe.ErrTooManyRegenerations):
+		writeError(ctx, fasthttp.StatusTooManyRequests, err)
+		return nil, candidate, false
+	case err == errResponseBlocked, errors.Is(err, moderation.ErrContentBlocked), errors.Is(err, provider.ErrSafetyBlocked), errors.Is(err, routing.ErrCostLimit):
+		writeError(ctx, fasthttp.StatusUnprocessableEntity, err)
+		return nil, candidate, false
+	case err != nil:
+		writeError(ctx, upstreamStatus(err), err)
+		return nil, candidate, false
+	}
+
+	if req.cached {
+		// nada foi gasto desta vez: sem tokens nem latência de provedor
+		ctx.Response.Header.Set("X-Lingobot-Cache", "regenerate")
+		setRoutingHeaders(ctx, &provider.Result{Provider: result.Provider}, routing.Candidate{})
+		return result, candidate, true
+	}
+	setRoutingHeaders(ctx, result, candidate)
+	return result, candidate, true
+}
+
+// setRoutingHeaders expõe nos cabeçalhos quem respondeu, em quanto tempo, com
+// quantos tokens e depois de quantos fallbacks, para proxies e clientes que
+// não leem o corpo
+func setRoutingHeaders(ctx *fasthttp.RequestCtx, result *provider.Result, candidate routing.Candidate) {
+	h := &ctx.Response.Header
+	h.Set("X-Provider", result.Provider)
+	h.Set("X-Upstream-Latency-Ms", strconv.FormatInt(candidate.Latency.Milliseconds(), 10))
+	h.Set("X-Tokens-Prompt", strconv.Itoa(result.Usage.PromptTokens))
+	h.Set("X-Tokens-Completion", strconv.Itoa(result.Usage.CompletionTokens))
+	h.Set("X-Fallback-Depth", strconv.Itoa(candidate.Depth))
+}
+
+// executeTurn é o turno compartilhado entre HTTP e gRPC
+func executeTurn(req *chatRequest, in *provider.Request, candidates []routing.Candidate, timer *turnTimer) (*provider.Result, routing.Candidate, error) {
+	t, err := prepareTurn(req, in)
+	if err != nil {
+		hooks.Emit(finishFor(req, in, nil, routing.Candidate{}, err, timer))
+		return nil, routing.Candidate{}, err
+	}
+
+	if t.cached != nil {
+		req.cached = true
+		timer.startProvider()
+		timer.endProvider()
+		f := finishFor(req, in, t.cached, routing.Candidate{}, nil, timer)
+		f.Cached = true
+		hooks.Emit(f)
+		return t.cached, routing.Candidate{}, nil
+	}
+
+	timer.startProvider()
+	result, candidate, err := routing.Execute(in, candidates)
+	if err == nil {
+		// as reescritas das etapas de resposta somam à latência do candidato
+		t.candidate = candidate
+		result, err = t.pipeline.after(t, result)
+		candidate = t.candidate
+	}
+	timer.endProvider()
+
+	hooks.Emit(finishFor(req, in, result, candidate, err, timer))
+	if err != nil {
+		return nil, candidate, err
+	}
+
+	if req.ConversationID != "" {
+		conversation.Record(req.ConversationID, req.Text, result)
+	}
+
+	return result, candidate, nil
+}
+
+// providerRequest usa o histórico gravado da conversa quando o cliente não manda um
+func (r *chatRequest) providerRequest() *provider.Request {
+	history := r.History
+	if len(history) == 0 && r.ConversationID != "" {
+		history = conversation.History(r.ConversationID)
+	}
+	system := levelSystem(r.Level)
+	if reply := r.replySystem(); reply != "" {
+		system = strings.TrimSpace(system + "\n\n" + reply)
+	}
+	return &provider.Request{
+		Text:      r.Text,
+		System:    system,
+		History:   history,
+		Reasoning: r.Reasoning,
+		Gemini:    r.Gemini,
+		Stop:      r.Stop,
+		Budget:    provider.NewBudget(), // um orçamento de chamadas por turno
+	}
+}
+
+// Handler genérico
+func createAIHandler(p provider.Provider) func(*fasthttp.RequestCtx) {
+	return func(ctx *fasthttp.RequestCtx) {
+		timer := newTurnTimer(ctx)
+
+		var req chatRequest
+		if !parseChatRequest(ctx, &req) {
+			return
+		}
+
+		req.from(string(ctx.Path()), clientID(ctx, req.SessionID), apiKey(ctx))
+		candidates, err := req.plan("", p.Name)
+		if err != nil {
+			writePlanError(ctx, err)
+			return
+		}
+
+		result, _, ok := runTurn(ctx, &req, req.providerRequest(), candidates, timer)
+		if !ok {
+			return
+		}
+
+		writeAIResponse(ctx, newAIResponse(result, req.IncludeReasoning), timer, req.Debug)
+	}
+}
+
+// Handler principal com fallback
+func aiHandler(ctx *fasthttp.RequestCtx) {
+	timer := newTurnTimer(ctx)
+
+	var req chatRequest
+	if !parseChatRequest(ctx, &req) {
+		return
+	}
+
+	req.from("/ai", clientID(ctx, req.SessionID), apiKey(ctx))
+	candidates, err := req.plan("/ai", "")
+	if err != nil {
+		writePlanError(ctx, err)
+		return
+	}
+
+	in := req.providerRequest()
+	result, candidate, ok := runTurn(ctx, &req, in, candidates, timer)
+	if !ok {
+		return
+	}
+
+	out := newAIResponse(result, req.IncludeReasoning)
+	if req.cached {
+		out.Response = postText(&req, out.Response)
+		out.Usage = nil
+		writeAIResponse(ctx, out, timer, false)
+		return
+	}
+
+	routing.Mirror(in, result, timer.providerEnd.Sub(timer.providerStart))
+
+	out.Experiment = candidate.Tag()
+	writeAIResponse(ctx, out, timer, req.Debug)
+}
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/db"
+	"lingobot-ai-engine/hooks"
+	"lingobot-ai-engine/internal/truncate"
+)
+
+// Linha de request_log: um turno por linha, sem o texto do aluno, só o hash
+// para casar com o relato de quem reclamou
+type auditRecord struct {
+	ID               int64     `json:"id"`
+	CreatedAt        time.Time `json:"created_at"`
+	Route            string    `json:"route"`
+	Client           string    `json:"client,omitempty"`
+	ConversationID   string    `json:"conversation_id,omitempty"`
+	Provider         string    `json:"provider,omitempty"`
+	Model            string    `json:"model,omitempty"`
+	PromptHash       string    `json:"prompt_hash"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	UpstreamCalls    int       `json:"upstream_calls"`
+	LatencyMs        int64     `json:"latency_ms"`
+	ProviderMs       int64     `json:"provider_ms"`
+	Cached           bool      `json:"cached"`
+	Stream           bool      `json:"stream"`
+	Error            string    `json:"error,omitempty"`
+}
+
+// AUDIT_LOG=1 grava cada turno em request_log no DATABASE_URL
+func init() {
+	if v := os.Getenv("AUDIT_LOG"); v == "1" || v == "true" {
+		hooks.Register(&auditHook{})
+	}
+}
+
+type auditHook struct {
+	warnOnce sync.Once
+}
+
+func (h *auditHook) Name() string { return "audit" }
+
+// promptHash é o começo do SHA-256 do texto; basta para achar o turno sem guardar o texto
+func promptHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:8])
+}
+
+func (h *auditHook) OnFinish(f *hooks.Finish) {
+	d := db.Default()
+	if d == nil {
+		h.warnOnce.Do(func() { log.Printf("⚠️  AUDIT_LOG ativo sem banco: defina DATABASE_URL") })
+		return
+	}
+
+	r := auditRecord{
+		CreatedAt:      f.At.UTC(),
+		Route:          f.Route,
+		Client:         storedClient(f.Client),
+		ConversationID: f.ConversationID,
+		Provider:       f.Provider,
+		Model:          f.Model,
+		PromptHash:     promptHash(f.Text),
+		LatencyMs:      f.Timings.Total.Milliseconds(),
+		ProviderMs:     f.Timings.Provider.Milliseconds(),
+		Cached:         f.Cached,
+		Stream:         f.Stream,
+	}
+	if f.Result != nil {
+		r.PromptTokens = f.Result.Usage.PromptTokens
+		r.CompletionTokens = f.Result.Usage.CompletionTokens
+	}
+	if f.Request != nil {
+		r.UpstreamCalls = f.Request.Budget.Used()
+	}
+	if f.Err != nil {
+		r.Error = truncate.Bytes(f.Err.Error(), 500)
+	}
+
+	_, err := d.Exec(d.Rebind(`INSERT INTO request_log
+		(created_at, route, client, conversation_id, provider, model, prompt_hash,
+		 prompt_tokens, completion_tokens, upstream_calls, latency_ms, provider_ms, cached, stream, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		r.CreatedAt, r.Route, r.Client, r.ConversationID, r.Provider, r.Model, r.PromptHash,
+		r.PromptTokens, r.CompletionTokens, r.UpstreamCalls, r.LatencyMs, r.ProviderMs, r.Cached, r.Stream, r.Error)
+	if err != nil {
+		log.Printf("⚠️  Turno fora do log de auditoria: %v", err)
+	}
+}
+
+// requestsHandler atende GET /admin/requests, mais recentes primeiro:
+//
+//	?limit=50            até 500 por página
+//	?before=<id>         próxima página, com o next_before da anterior
+//	?client=key:abc      a API key pode vir em claro; é comparada pelo hash
+//	?conversation_id=... ?route=/ai ?prompt=<texto>
+func requestsHandler(ctx *fasthttp.RequestCtx) {
+	if !adminAuthorized(ctx) {
+		return
+	}
+	if !ctx.IsGet() {
+		writeErrorCode(ctx, fasthttp.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	d := db.Default()
+	if d == nil {
+		writeErrorCode(ctx, fasthttp.StatusServiceUnavailable, codeUnavailable, "audit log requires DATABASE_URL")
+		return
+	}
+
+	args := ctx.QueryArgs()
+	limit := 50
+	if n, err := strconv.Atoi(string(args.Peek("limit"))); err == nil && n > 0 {
+		limit = min(n, 500)
+	}
+
+	where := []string{}
+	params := []interface{}{}
+	if raw := string(args.Peek("before")); raw != "" {
+		before, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			writeErrorCode(ctx, fasthttp.StatusBadRequest, codeInvalidRequest, "before must be a request id")
+			return
+		}
+		where = append(where, "id < ?")
+		params = append(params, before)
+	}
+	if client := string(args.Peek("client")); client != "" {
+		where = append(where, "client = ?")
+		params = append(params, storedClient(client))
+	}
+	if id := string(args.Peek("conversation_id")); id != "" {
+		where = append(where, "conversation_id = ?")
+		params = append(params, id)
+	}
+	if route := string(args.Peek("route")); route != "" {
+		where = append(where, "route = ?")
+		params = append(params, route)
+	}
+	if args.Has("prompt") {
+		where = append(where, "prompt_hash = ?")
+		params = append(params, promptHash(string(args.Peek("prompt"))))
+	}
+
+	query := `SELECT id, created_at, route, client, conversation_id, provider, model, prompt_hash,
+		prompt_tokens, completion_tokens, upstream_calls, latency_ms, provider_ms, cached, stream, error
+		FROM request_log`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	params = append(params, limit)
+
+	rows, err := d.QueryContext(ctx, d.Rebind(query), params...)
+	if err != nil {
+		writeError(ctx, fasthttp.StatusInternalServerError, err)
+		return
+	}
+	defer rows.Close()
+
+	records := make([]auditRecord, 0, limit)
+	for rows.Next() {
+		var r auditRecord
+		if err := rows.Scan(&r.ID, &r.CreatedAt, &r.Route, &r.Client, &r.ConversationID, &r.Provider, &r.Model, &r.PromptHash,
+			&r.PromptTokens, &r.CompletionTokens, &r.UpstreamCalls, &r.LatencyMs, &r.ProviderMs, &r.Cached, &r.Stream, &r.Error); err != nil {
+			writeError(ctx, fasthttp.StatusInternalServerError, err)
+			return
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		writeError(ctx, fasthttp.StatusInternalServerError, err)
+		return
+	}
+
+	page := map[string]interface{}{"requests": records}
+	if len(records) == limit {
+		page["next_before"] = records[len(records)-1].ID
+	}
+	body, _ := sonic.Marshal(page)
+	ctx.SetContentType("application/json")
+	ctx.SetBody(body)
+}
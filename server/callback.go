@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// O callback_url vem do cliente: sem cuidado, o gateway posta em qualquer
+// endereço que ele alcança, inclusive na rede interna e no metadata da nuvem.
+//
+//	CALLBACK_ALLOWED_HOSTS=hooks.example.com,*.example.org  só esses hosts; vazio aceita qualquer um público
+//	CALLBACK_ALLOW_PRIVATE=1                                 aceita loopback e rede privada (desenvolvimento)
+//
+// O IP é conferido na conexão, depois de resolvido o nome, para um DNS que
+// muda entre a validação e o envio não furar a regra.
+var (
+	callbackAllowedHosts = loadCallbackHosts()
+	callbackAllowPrivate = os.Getenv("CALLBACK_ALLOW_PRIVATE") == "1" || os.Getenv("CALLBACK_ALLOW_PRIVATE") == "true"
+)
+
+const callbackDialTimeout = 5 * time.Second
+
+var (
+	errCallbackScheme  = errors.New("callback_url must be an http(s) URL")
+	errCallbackHost    = errors.New("callback_url host is not in CALLBACK_ALLOWED_HOSTS")
+	errCallbackAddress = errors.New("callback_url points to a private or local address")
+)
+
+// 100.64.0.0/10 (CGNAT) não entra em net.IP.IsPrivate
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func loadCallbackHosts() []string {
+	var hosts []string
+	for _, h := range strings.Split(os.Getenv("CALLBACK_ALLOWED_HOSTS"), ",") {
+		if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts
+}
+
+// callbackHostAllowed confere o host contra CALLBACK_ALLOWED_HOSTS; "*.x.com"
+// aceita os subdomínios de x.com
+func callbackHostAllowed(host string) bool {
+	if len(callbackAllowedHosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, allowed := range callbackAllowedHosts {
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// blockedCallbackIP diz se o endereço é interno: loopback, rede privada,
+// link-local (onde fica o metadata, 169.254.169.254), não especificado ou multicast
+func blockedCallbackIP(ip net.IP) bool {
+	if callbackAllowPrivate {
+		return false
+	}
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip)
+}
+
+// validCallbackURL confere esquema, host permitido e, quando o host é um IP,
+// o endereço; nomes são conferidos de novo na conexão
+func validCallbackURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errCallbackScheme
+	}
+	if !callbackHostAllowed(u.Hostname()) {
+		return errCallbackHost
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && blockedCallbackIP(ip) {
+		return errCallbackAddress
+	}
+	return nil
+}
+
+// dialCallback resolve o host e só conecta em endereço público
+func dialCallback(addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if !callbackHostAllowed(host) {
+		return nil, errCallbackHost
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), callbackDialTimeout)
+	defer cancel()
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	lastErr := fmt.Errorf("%s: %w", host, errCallbackAddress)
+	for _, ip := range ips {
+		if blockedCallbackIP(ip.IP) {
+			continue
+		}
+		conn, err := fasthttp.DialTimeout(net.JoinHostPort(ip.IP.String(), port), callbackDialTimeout)
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
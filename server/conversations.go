@@ -0,0 +1,55 @@
+package server
+
+import (
+	"bytes"
+
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/conversation"
+	"lingobot-ai-engine/export"
+)
+
+// conversationExportHandler devolve a transcrição da conversa (?format=html|pdf)
+func conversationExportHandler(ctx *fasthttp.RequestCtx, id string) {
+	if !ctx.IsGet() {
+		writeErrorCode(ctx, fasthttp.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	c, ok := conversation.Get(id)
+	if id == "" || !ok {
+		writeErrorCode(ctx, fasthttp.StatusNotFound, codeNotFound, "conversation not found")
+		return
+	}
+
+	report := export.NewReport(c)
+
+	switch format := string(ctx.QueryArgs().Peek("format")); format {
+	case "", "html":
+		var buf bytes.Buffer
+		if err := export.HTML(&buf, report); err != nil {
+			writeError(ctx, fasthttp.StatusInternalServerError, err)
+			return
+		}
+		ctx.SetContentType("text/html; charset=utf-8")
+		ctx.SetBody(buf.Bytes())
+	case "pdf":
+		ctx.SetContentType("application/pdf")
+		ctx.Response.Header.Set("Content-Disposition", `attachment; filename="conversa-`+safeFilename(id)+`.pdf"`)
+		ctx.SetBody(export.PDF(report))
+	default:
+		writeErrorCode(ctx, fasthttp.StatusBadRequest, codeInvalidRequest, "format must be html or pdf")
+	}
+}
+
+// safeFilename mantém só caracteres seguros para o Content-Disposition
+func safeFilename(id string) string {
+	out := make([]byte, 0, len(id))
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' {
+			out = append(out, c)
+		}
+	}
+	return string(out)
+}
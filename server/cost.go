@@ -0,0 +1,59 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"os"
+
+	"github.com/bytedance/sonic"
+
+	"lingobot-ai-engine/provider"
+)
+
+var errInvalidMaxCost = errors.New("max_cost_usd must be positive")
+
+// KEY_MAX_COST_USD: teto de custo por chamada de cada API key, em USD
+// ({"chave-da-escola": 0.002, "*": 0.01}); "*" vale para as outras chaves e
+// para quem chega sem chave. O max_cost_usd do pedido só pode baixar o teto.
+var keyCostLimits = loadKeyCostLimits(os.Getenv("KEY_MAX_COST_USD"))
+
+func loadKeyCostLimits(raw string) map[string]float64 {
+	if raw == "" {
+		return nil
+	}
+	var limits map[string]float64
+	if err := sonic.UnmarshalString(raw, &limits); err != nil {
+		log.Printf("⚠️  KEY_MAX_COST_USD inválido, sem teto por chave: %v", err)
+		return nil
+	}
+	return limits
+}
+
+// costCeiling é o menor entre o teto da chave e o pedido; 0 não limita
+func costCeiling(key string, requested float64) float64 {
+	limit, ok := 0.0, false
+	if key != "" {
+		limit, ok = keyCostLimits[key]
+	}
+	if !ok {
+		limit = keyCostLimits["*"]
+	}
+
+	switch {
+	case limit <= 0:
+		return requested
+	case requested <= 0:
+		return limit
+	}
+	return min(limit, requested)
+}
+
+// prepareTurn aplica o teto de custo, marca a rota para os timeouts e roda as
+// etapas de pedido do pipeline, antes do plano rodar. O turno devolvido traz
+// as cadeias em uso e, se o regenerate achou, a resposta a servir.
+func prepareTurn(req *chatRequest, in *provider.Request) (*turn, error) {
+	in.MaxCostUSD = costCeiling(req.key, req.MaxCostUSD)
+	in.Route = req.route
+	t := &turn{req: req, in: in}
+	return t, currentPipeline().before(t)
+}
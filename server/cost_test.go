@@ -0,0 +1,52 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/provider"
+)
+
+func TestCostCeilingIgnoresSession(t *testing.T) {
+	saved := keyCostLimits
+	defer func() { keyCostLimits = saved }()
+	keyCostLimits = map[string]float64{"escola": 0.002, "*": 0.01}
+
+	tests := []struct {
+		name      string
+		headers   map[string]string
+		sessionID string
+		requested float64
+		want      float64
+	}{
+		{"chave sem sessão", map[string]string{"X-API-Key": "escola"}, "", 0, 0.002},
+		{"chave com session_id", map[string]string{"X-API-Key": "escola"}, "s1", 0, 0.002},
+		{"chave com X-Session-ID", map[string]string{"X-API-Key": "escola", "X-Session-ID": "s1"}, "", 0, 0.002},
+		{"bearer com session_id", map[string]string{"Authorization": "Bearer escola"}, "s1", 0, 0.002},
+		{"pedido abaixo do teto", map[string]string{"X-API-Key": "escola"}, "s1", 0.001, 0.001},
+		{"pedido acima do teto", map[string]string{"X-API-Key": "escola"}, "s1", 0.5, 0.002},
+		{"só sessão", nil, "s1", 0, 0.01},
+		{"outra chave", map[string]string{"X-API-Key": "outra"}, "s1", 0, 0.01},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ctx fasthttp.RequestCtx
+			for k, v := range tt.headers {
+				ctx.Request.Header.Set(k, v)
+			}
+
+			req := &chatRequest{Text: tt.name, SessionID: tt.sessionID, MaxCostUSD: tt.requested}
+			req.from("/ai", clientID(&ctx, req.SessionID), apiKey(&ctx))
+
+			in := &provider.Request{Text: req.Text}
+			if _, err := prepareTurn(req, in); err != nil {
+				t.Fatalf("prepareTurn: %v", err)
+			}
+			if in.MaxCostUSD != tt.want {
+				t.Errorf("MaxCostUSD = %v, want %v (client %q)", in.MaxCostUSD, tt.want, req.client)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/internal/env"
+	"lingobot-ai-engine/kv"
+	"lingobot-ai-engine/routing"
+)
+
+// Job que falhou de vez: fica na fila de mensagens mortas com o erro, as
+// tentativas e o corpo original, até o admin reenfileirar ou descartar
+type deadLetter struct {
+	JobID       string          `json:"job_id"`
+	Error       *apiError       `json:"error"`
+	Cause       string          `json:"cause"` // erro original, com o que o provedor respondeu
+	Attempts    int             `json:"attempts"`
+	Payload     json.RawMessage `json:"payload"`
+	Client      string          `json:"client,omitempty"`
+	MaxCostUSD  float64         `json:"max_cost_usd,omitempty"` // teto já resolvido com a chave, que não é gravada
+	CallbackURL string          `json:"callback_url,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	FailedAt    time.Time       `json:"failed_at"`
+}
+
+var (
+	// DEAD_LETTER_TTL: por quanto tempo o job morto fica disponível;
+	// DEAD_LETTER_MAX: quantos ficam no índice, descartando os mais antigos
+	deadLetterTTL = env.PositiveDuration("DEAD_LETTER_TTL", 7*24*time.Hour)
+	deadLetterMax = env.PositiveInt("DEAD_LETTER_MAX", 1000)
+
+	deadLetters = kv.Prefixed(kv.Default, "deadletter")
+
+	// deadLetterMu serializa o índice nesta instância; entre instâncias vale a última gravação
+	deadLetterMu sync.Mutex
+)
+
+// O índice guarda os IDs em ordem de chegada, já que o kv não lista chaves
+const deadLetterIndex = "index"
+
+// storedClient não grava a API key em claro: o hash mantém o cliente
+// distinguível para os experimentos sem expor a chave no admin
+func storedClient(client string) string {
+	key, ok := strings.CutPrefix(client, "key:")
+	if !ok || strings.HasPrefix(key, "sha256:") {
+		return client
+	}
+	sum := sha256.Sum256([]byte(key))
+	return "key:sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// deadLetterJob grava o job que esgotou as tentativas
+func deadLetterJob(j *job, cause error) {
+	entry := &deadLetter{
+		JobID:       j.ID,
+		Error:       j.Error,
+		Cause:       cause.Error(),
+		Attempts:    j.Attempts,
+		Payload:     j.payload,
+		Client:      storedClient(j.req.client),
+		MaxCostUSD:  costCeiling(j.req.key, j.req.MaxCostUSD),
+		CallbackURL: j.callbackURL,
+		CreatedAt:   j.CreatedAt,
+		FailedAt:    time.Now(),
+	}
+	if putDeadLetter(entry) {
+		log.Printf("☠️  %s na fila de mensagens mortas após %d tentativas: %s", j.ID, j.Attempts, entry.Error.Code)
+	}
+}
+
+func putDeadLetter(entry *deadLetter) bool {
+	body, _ := sonic.Marshal(entry)
+
+	deadLetterMu.Lock()
+	defer deadLetterMu.Unlock()
+
+	if err := deadLetters.Set(entry.JobID, body, deadLetterTTL); err != nil {
+		log.Printf("⚠️  %s não foi para a fila de mensagens mortas: %v", entry.JobID, err)
+		return false
+	}
+
+	ids := append(removeID(loadDeadLetterIndex(), entry.JobID), entry.JobID)
+	if len(ids) > deadLetterMax {
+		for _, old := range ids[:len(ids)-deadLetterMax] {
+			deadLetters.Delete(old)
+		}
+		ids = ids[len(ids)-deadLetterMax:]
+	}
+	saveDeadLetterIndex(ids)
+	return true
+}
+
+func loadDeadLetterIndex() []string {
+	raw, ok, err := deadLetters.Get(deadLetterIndex)
+	if err != nil || !ok {
+		return nil
+	}
+	var ids []string
+	sonic.Unmarshal(raw, &ids)
+	return ids
+}
+
+func saveDeadLetterIndex(ids []string) {
+	raw, _ := sonic.Marshal(ids)
+	if err := deadLetters.Set(deadLetterIndex, raw, deadLetterTTL); err != nil {
+		log.Printf("⚠️  Índice da fila de mensagens mortas não gravado: %v", err)
+	}
+}
+
+func removeID(ids []string, id string) []string {
+	kept := ids[:0]
+	for _, existing := range ids {
+		if existing != id {
+			kept = append(kept, existing)
+		}
+	}
+	return kept
+}
+
+func getDeadLetter(id string) (*deadLetter, bool) {
+	raw, ok, err := deadLetters.Get(id)
+	if err != nil {
+		log.Printf("⚠️  %s indisponível na fila de mensagens mortas: %v", id, err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	var entry deadLetter
+	if sonic.Unmarshal(raw, &entry) != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+func discardDeadLetter(id string) {
+	deadLetterMu.Lock()
+	defer deadLetterMu.Unlock()
+
+	deadLetters.Delete(id)
+	saveDeadLetterIndex(removeID(loadDeadLetterIndex(), id))
+}
+
+// deadLettersHandler atende /admin/dead-letters:
+//
+//	GET    /admin/dead-letters?limit=50       mais recentes primeiro
+//	GET    /admin/dead-letters/{id}
+//	POST   /admin/dead-letters/{id}/requeue   volta para a fila com o mesmo ID
+//	DELETE /admin/dead-letters/{id}
+func deadLettersHandler(ctx *fasthttp.RequestCtx, rest string) {
+	if !adminAuthorized(ctx) {
+		return
+	}
+
+	rest = strings.Trim(rest, "/")
+	id, action, _ := strings.Cut(rest, "/")
+
+	switch {
+	case id == "" && ctx.IsGet():
+		listDeadLetters(ctx)
+	case id != "" && action == "" && ctx.IsGet():
+		entry, ok := getDeadLetter(id)
+		if !ok {
+			writeErrorCode(ctx, fasthttp.StatusNotFound, codeNotFound, "dead letter not found")
+			return
+		}
+		body, _ := sonic.Marshal(entry)
+		ctx.SetContentType("application/json")
+		ctx.SetBody(body)
+	case id != "" && action == "" && ctx.IsDelete():
+		if _, ok := getDeadLetter(id); !ok {
+			writeErrorCode(ctx, fasthttp.StatusNotFound, codeNotFound, "dead letter not found")
+			return
+		}
+		discardDeadLetter(id)
+		ctx.SetStatusCode(fasthttp.StatusNoContent)
+	case id != "" && action == "requeue" && ctx.IsPost():
+		requeueDeadLetter(ctx, id)
+	case id != "" && action != "" && action != "requeue":
+		writeErrorCode(ctx, fasthttp.StatusNotFound, codeNotFound, "endpoint not found")
+	default:
+		writeErrorCode(ctx, fasthttp.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+	}
+}
+
+func listDeadLetters(ctx *fasthttp.RequestCtx) {
+	limit := 50
+	if n, err := strconv.Atoi(string(ctx.QueryArgs().Peek("limit"))); err == nil && n > 0 {
+		limit = min(n, deadLetterMax)
+	}
+
+	ids := loadDeadLetterIndex()
+	entries := make([]deadLetter, 0, min(limit, len(ids)))
+	for i := len(ids) - 1; i >= 0 && len(entries) < limit; i-- {
+		// expirados somem do kv antes do índice
+		if entry, ok := getDeadLetter(ids[i]); ok {
+			entries = append(entries, *entry)
+		}
+	}
+
+	body, _ := sonic.Marshal(map[string]interface{}{"dead_letters": entries, "total": len(ids)})
+	ctx.SetContentType("application/json")
+	ctx.SetBody(body)
+}
+
+// requeueDeadLetter refaz o job a partir do corpo original, com o mesmo ID:
+// quem faz polling em /jobs/{id} vê o job voltar a queued, e o callback
+// dispara de novo ao concluir
+func requeueDeadLetter(ctx *fasthttp.RequestCtx, id string) {
+	entry, ok := getDeadLetter(id)
+	if !ok {
+		writeErrorCode(ctx, fasthttp.StatusNotFound, codeNotFound, "dead letter not found")
+		return
+	}
+
+	var req chatRequest
+	if err := sonic.Unmarshal(entry.Payload, &req); err != nil {
+		writeErrorCode(ctx, fasthttp.StatusUnprocessableEntity, codeInvalidJSON, "stored payload is not valid JSON")
+		return
+	}
+	// a moderação ou a lista de modelos podem ter mudado desde o envio
+	if err := req.validate(); err != nil {
+		writeError(ctx, fasthttp.StatusUnprocessableEntity, err)
+		return
+	}
+
+	// sem a chave em claro, o teto resolvido no envio vira o do pedido e o "*"
+	// só pode baixá-lo
+	req.MaxCostUSD = entry.MaxCostUSD
+
+	j := &job{
+		ID:          entry.JobID,
+		Status:      jobQueued,
+		Attempts:    entry.Attempts,
+		CreatedAt:   entry.CreatedAt,
+		req:         *req.from("/ai/async", entry.Client, ""),
+		in:          req.providerRequest(),
+		candidates:  routing.Plan(req.options(entry.Client)),
+		callbackURL: entry.CallbackURL,
+		payload:     entry.Payload,
+	}
+
+	if !saveJob(j) {
+		writeErrorCode(ctx, fasthttp.StatusServiceUnavailable, codeUnavailable, "job store unavailable")
+		return
+	}
+
+	// sai da fila antes de rodar; se falhar de novo, volta com as tentativas somadas
+	discardDeadLetter(id)
+	if !enqueueJob(j) {
+		putDeadLetter(entry)
+		writeAPIError(ctx, fasthttp.StatusServiceUnavailable, apiError{Code: codeUnavailable, Message: errJobQueueFull.Error(), Retryable: true, RetryAfter: 5})
+		return
+	}
+	log.Printf("🔁 %s reenfileirado pelo admin", id)
+
+	body, _ := sonic.Marshal(map[string]string{"job_id": j.ID, "status": jobQueued})
+	ctx.SetStatusCode(fasthttp.StatusAccepted)
+	ctx.SetContentType("application/json")
+	ctx.Response.Header.Set("Location", "/jobs/"+j.ID)
+	ctx.SetBody(body)
+}
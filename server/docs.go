@@ -0,0 +1,37 @@
+package server
+
+import (
+	_ "embed"
+
+	"github.com/valyala/fasthttp"
+)
+
+// Especificação mantida junto dos handlers; atualizar ao mudar rotas ou corpos
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+const docsPage = `<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+<meta charset="utf-8">
+<title>Lingobot AI Engine — API</title>
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<style>body { margin: 0; }</style>
+</head>
+<body>
+<redoc spec-url="/openapi.json"></redoc>
+<script src="https://cdn.redoc.ly/redoc/v2.1.5/bundles/redoc.standalone.js"></script>
+</body>
+</html>
+`
+
+func openAPIHandler(ctx *fasthttp.RequestCtx) {
+	ctx.SetContentType("application/json")
+	ctx.SetBody(openAPISpec)
+}
+
+func docsHandler(ctx *fasthttp.RequestCtx) {
+	ctx.SetContentType("text/html; charset=utf-8")
+	ctx.SetBodyString(docsPage)
+}
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/dedupe"
+	"lingobot-ai-engine/moderation"
+	"lingobot-ai-engine/provider"
+	"lingobot-ai-engine/routing"
+)
+
+// Envelope de erro de todas as rotas:
+//
+//	{"error":{"code":"upstream_rate_limited","message":"mistral is rate limiting requests","provider":"mistral","retryable":true,"retry_after":12}}
+//
+// code é estável e serve para o cliente decidir; message é para gente ler.
+// O texto cru das falhas de provedor fica só no log.
+type apiError struct {
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	Provider   string `json:"provider,omitempty"`
+	Retryable  bool   `json:"retryable"`
+	RetryAfter int    `json:"retry_after,omitempty"` // segundos
+}
+
+type errorEnvelope struct {
+	Error apiError `json:"error"`
+}
+
+// Códigos dos erros do próprio gateway; os dos provedores estão em provider.Code*
+const (
+	codeInvalidRequest   = "invalid_request"
+	codeInvalidJSON      = "invalid_json"
+	codeInvalidOption    = "invalid_option"
+	codeMethodNotAllowed = "method_not_allowed"
+	codeNotFound         = "not_found"
+	codeUnauthorized     = "unauthorized"
+	codeContentBlocked   = "content_blocked"
+	codeSafetyBlocked    = "safety_blocked"
+	codeModelNotAllowed  = "model_not_allowed"
+	codeProviderDisabled = "provider_disabled"
+	codeRateLimited      = "rate_limited"
+	codeOverloaded       = "overloaded"
+	codeBudgetExhausted  = "call_budget_exhausted"
+	codeCostLimit        = "cost_limit"
+	codeUnavailable      = "unavailable"
+	codeInternal         = "internal_error"
+)
+
+// Mensagens públicas das falhas de provedor, no lugar do que o provedor respondeu
+var upstreamMessages = map[string]string{
+	provider.CodeNotConfigured: "provider %s is not configured",
+	provider.CodeAuthFailed:    "%s rejected the gateway credentials",
+	provider.CodeRateLimited:   "%s is rate limiting requests",
+	provider.CodeQuotaExceeded: "%s quota is exhausted",
+	provider.CodeTimeout:       "%s timed out",
+	provider.CodeUnavailable:   "%s is unavailable",
+	provider.CodeBadRequest:    "%s rejected the request",
+	provider.CodeContextLength: "the conversation is too long for the %s model",
+	provider.CodeModelNotFound: "model not found on %s",
+	provider.CodeBadResponse:   "%s returned an invalid response",
+	provider.CodeUpstream:      "%s request failed",
+}
+
+func upstreamAPIError(e *provider.UpstreamError) apiError {
+	format, ok := upstreamMessages[e.Code]
+	if !ok {
+		format = upstreamMessages[provider.CodeUpstream]
+	}
+	return apiError{
+		Code:       e.Code,
+		Message:    fmt.Sprintf(format, e.Provider),
+		Provider:   e.Provider,
+		Retryable:  e.Retryable,
+		RetryAfter: seconds(e.RetryAfter.Seconds()),
+	}
+}
+
+func seconds(s float64) int {
+	return int(math.Ceil(s))
+}
+
+// describeError monta o envelope do erro. Erros que o gateway não conhece
+// com status 4xx são mensagens de validação nossas e vão como estão; com 5xx
+// viram internal_error e o original vai para o log.
+func describeError(err error, status int) apiError {
+	var (
+		throttled *routing.ThrottledError
+		upstream  *provider.UpstreamError
+	)
+
+	switch {
+	case errors.Is(err, provider.ErrBudgetExhausted):
+		e := apiError{Code: codeBudgetExhausted, Message: provider.ErrBudgetExhausted.Error()}
+		if errors.As(err, &upstream) {
+			e.Provider = upstream.Provider
+		}
+		log.Printf("⚠️  %v", err)
+		return e
+	case errors.As(err, &throttled):
+		return apiError{
+			Code:       codeRateLimited,
+			Message:    throttled.Error(),
+			Provider:   throttled.Provider,
+			Retryable:  true,
+			RetryAfter: seconds(throttled.RetryAfter.Seconds()),
+		}
+	case errors.Is(err, routing.ErrOverloaded):
+		return apiError{
+			Code:       codeOverloaded,
+			Message:    err.Error(),
+			Retryable:  true,
+			RetryAfter: seconds(routing.OverloadRetryAfter().Seconds()),
+		}
+	case errors.As(err, &upstream):
+		log.Printf("⚠️  Falha do provedor: %v", err)
+		return upstreamAPIError(upstream)
+	case errors.Is(err, provider.ErrStreamStalled):
+		return apiError{Code: provider.CodeTimeout, Message: err.Error(), Retryable: true}
+	case errors.Is(err, provider.ErrSafetyBlocked):
+		return apiError{Code: codeSafetyBlocked, Message: err.Error()}
+	case err == errResponseBlocked, errors.Is(err, moderation.ErrContentBlocked):
+		return apiError{Code: codeContentBlocked, Message: err.Error()}
+	case errors.Is(err, routing.ErrCostLimit):
+		return apiError{Code: codeCostLimit, Message: err.Error()}
+	case errors.Is(err, routing.ErrModelNotAllowed):
+		return apiError{Code: codeModelNotAllowed, Message: err.Error()}
+	case errors.Is(err, routing.ErrProviderDisabled):
+		return apiError{Code: codeProviderDisabled, Message: err.Error()}
+	case errors.As(err, new(*invalidOptionError)):
+		return apiError{Code: codeInvalidOption, Message: err.Error()}
+	case err == errRouteDisabled:
+		return apiError{Code: codeNotFound, Message: err.Error()}
+	case errors.Is(err, dedupe.ErrTooManyRegenerations):
+		return apiError{Code: codeRateLimited, Message: err.Error(), Retryable: true, RetryAfter: 10}
+	case status < fasthttp.StatusInternalServerError:
+		return apiError{Code: codeInvalidRequest, Message: err.Error()}
+	}
+
+	log.Printf("❌ Erro interno: %v", err)
+	return apiError{Code: codeInternal, Message: "internal error"}
+}
+
+// upstreamStatus é o status HTTP da falha do turno depois de esgotado o plano
+func upstreamStatus(err error) int {
+	var upstream *provider.UpstreamError
+	switch {
+	case errors.Is(err, provider.ErrBudgetExhausted):
+		return fasthttp.StatusBadGateway
+	case errors.Is(err, routing.ErrProviderDisabled):
+		return fasthttp.StatusForbidden
+	case errors.Is(err, routing.ErrCostLimit):
+		return fasthttp.StatusUnprocessableEntity
+	case errors.Is(err, routing.ErrOverloaded):
+		return fasthttp.StatusServiceUnavailable
+	case errors.Is(err, provider.ErrStreamStalled):
+		return fasthttp.StatusGatewayTimeout
+	case !errors.As(err, &upstream):
+		return fasthttp.StatusInternalServerError
+	}
+
+	switch upstream.Code {
+	case provider.CodeContextLength:
+		return fasthttp.StatusBadRequest
+	case provider.CodeRateLimited, provider.CodeQuotaExceeded:
+		return fasthttp.StatusServiceUnavailable
+	case provider.CodeTimeout:
+		return fasthttp.StatusGatewayTimeout
+	}
+	return fasthttp.StatusBadGateway
+}
+
+func writeAPIError(ctx *fasthttp.RequestCtx, status int, e apiError) {
+	if e.RetryAfter > 0 {
+		ctx.Response.Header.Set("Retry-After", strconv.Itoa(e.RetryAfter))
+	}
+	body, _ := sonic.Marshal(errorEnvelope{Error: e})
+	ctx.SetContentType("application/json")
+	ctx.SetStatusCode(status)
+	ctx.SetBody(body)
+}
+
+// writeError responde com o envelope do erro
+func writeError(ctx *fasthttp.RequestCtx, status int, err error) {
+	writeAPIError(ctx, status, describeError(err, status))
+}
+
+// writeErrorCode responde com um erro do próprio gateway
+func writeErrorCode(ctx *fasthttp.RequestCtx, status int, code, message string) {
+	writeAPIError(ctx, status, apiError{Code: code, Message: message})
+}
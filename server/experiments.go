@@ -0,0 +1,78 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/routing"
+)
+
+// clientID identifica o cliente para atribuição estável: sessão ou API key
+func clientID(ctx *fasthttp.RequestCtx, sessionID string) string {
+	if sessionID != "" {
+		return "session:" + sessionID
+	}
+	if id := ctx.Request.Header.Peek("X-Session-ID"); len(id) > 0 {
+		return "session:" + string(id)
+	}
+	if key := apiKey(ctx); key != "" {
+		return "key:" + key
+	}
+	return ""
+}
+
+// apiKey é a chave do chamador (X-API-Key ou Bearer), lida à parte da sessão:
+// o teto de custo da chave não pode sumir só porque o pedido traz session_id
+func apiKey(ctx *fasthttp.RequestCtx) string {
+	if key := ctx.Request.Header.Peek("X-API-Key"); len(key) > 0 {
+		return string(key)
+	}
+	if auth := string(ctx.Request.Header.Peek("Authorization")); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return ""
+}
+
+// modelsHandler lista os aliases e modelos aceitos no campo model (GET /models)
+func modelsHandler(ctx *fasthttp.RequestCtx) {
+	body, _ := sonic.Marshal(map[string]interface{}{"models": routing.ModelList()})
+	ctx.SetContentType("application/json")
+	ctx.SetBody(body)
+}
+
+// experimentsHandler expõe as métricas por braço
+func experimentsHandler(ctx *fasthttp.RequestCtx) {
+	body, _ := sonic.Marshal(map[string]interface{}{"experiments": routing.Reports()})
+	ctx.SetContentType("application/json")
+	ctx.SetBody(body)
+}
+
+// experimentFeedbackHandler registra a preferência do usuário no braço atribuído
+func experimentFeedbackHandler(ctx *fasthttp.RequestCtx) {
+	if !ctx.IsPost() {
+		writeErrorCode(ctx, fasthttp.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req struct {
+		SessionID string `json:"session_id"`
+		Liked     *bool  `json:"liked"`
+	}
+
+	if err := sonic.Unmarshal(ctx.PostBody(), &req); err != nil || req.Liked == nil {
+		writeErrorCode(ctx, fasthttp.StatusBadRequest, codeInvalidRequest, "liked field is required")
+		return
+	}
+
+	tag, ok := routing.RecordFeedback(clientID(ctx, req.SessionID), *req.Liked)
+	if !ok {
+		writeErrorCode(ctx, fasthttp.StatusNotFound, codeNotFound, "client is not enrolled in any experiment")
+		return
+	}
+
+	body, _ := sonic.Marshal(tag)
+	ctx.SetContentType("application/json")
+	ctx.SetBody(body)
+}
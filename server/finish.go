@@ -0,0 +1,45 @@
+package server
+
+import (
+	"time"
+
+	"lingobot-ai-engine/hooks"
+	"lingobot-ai-engine/provider"
+	"lingobot-ai-engine/routing"
+)
+
+// finishFor monta o turno canônico entregue aos hooks, com ou sem erro
+func finishFor(req *chatRequest, in *provider.Request, result *provider.Result, candidate routing.Candidate, err error, timer *turnTimer) *hooks.Finish {
+	f := &hooks.Finish{
+		Route:          req.route,
+		Client:         req.client,
+		Text:           req.Text,
+		Persona:        req.Persona,
+		Language:       req.Language,
+		ConversationID: req.ConversationID,
+		Level:          req.Level,
+		Vocabulary:     req.vocabulary,
+
+		ExpectedLanguage: req.replyLanguage(),
+
+		Request:    in,
+		Model:      candidate.Model,
+		Experiment: candidate.Tag(),
+		Err:        err,
+		Timings: hooks.Timings{
+			Queue:    timer.providerStart.Sub(timer.received),
+			Provider: timer.providerEnd.Sub(timer.providerStart),
+			Total:    time.Since(timer.received),
+		},
+		At: time.Now(),
+	}
+
+	if err == nil {
+		f.Result = result
+		f.Provider = result.Provider
+		f.ResponseLanguage = result.Language
+	} else {
+		f.Provider = candidate.Provider.Name
+	}
+	return f
+}
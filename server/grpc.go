@@ -0,0 +1,320 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"lingobot-ai-engine/conversation"
+	"lingobot-ai-engine/hooks"
+	"lingobot-ai-engine/language"
+	"lingobot-ai-engine/moderation"
+	"lingobot-ai-engine/proto/lingobotpb"
+	"lingobot-ai-engine/provider"
+	"lingobot-ai-engine/routing"
+)
+
+// Serviço gRPC com as mesmas operações de chat e tradução do HTTP, mais os
+// embeddings da busca nos documentos
+type grpcService struct {
+	lingobotpb.UnimplementedLingobotServer
+}
+
+// GRPCServer devolve o servidor gRPC com o serviço Lingobot registrado
+func GRPCServer() *grpc.Server {
+	s := grpc.NewServer()
+	lingobotpb.RegisterLingobotServer(s, &grpcService{})
+	return s
+}
+
+// grpcClientID segue a mesma ordem do clientID do HTTP, lendo os metadados
+func grpcClientID(ctx context.Context, sessionID string) string {
+	if sessionID != "" {
+		return "session:" + sessionID
+	}
+
+	if id := grpcMetadata(ctx, "x-session-id"); id != "" {
+		return "session:" + id
+	}
+	if key := grpcAPIKey(ctx); key != "" {
+		return "key:" + key
+	}
+	return ""
+}
+
+// grpcAPIKey é o apiKey do HTTP, lendo os metadados
+func grpcAPIKey(ctx context.Context) string {
+	if key := grpcMetadata(ctx, "x-api-key"); key != "" {
+		return key
+	}
+	if auth := grpcMetadata(ctx, "authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return ""
+}
+
+func grpcMetadata(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func fromProto(in *lingobotpb.ChatRequest) *chatRequest {
+	req := &chatRequest{
+		Text:             in.GetText(),
+		Persona:          in.GetPersona(),
+		SessionID:        in.GetSessionId(),
+		ConversationID:   in.GetConversationId(),
+		Language:         in.GetLanguage(),
+		Strategy:         in.GetStrategy(),
+		Model:            in.GetModel(),
+		MaxCostUSD:       in.GetMaxCostUsd(),
+		Level:            in.GetLevel(),
+		Reasoning:        in.GetReasoning(),
+		IncludeReasoning: in.GetIncludeReasoning(),
+	}
+	for _, m := range in.GetHistory() {
+		req.History = append(req.History, provider.Message{Role: m.GetRole(), Content: m.GetContent()})
+	}
+	return req
+}
+
+// grpcError converte os erros do turno nos códigos gRPC equivalentes aos status
+// HTTP; o code do envelope vai em ErrorInfo.Reason, com provider e retryable
+// nos metadados, e o retry_after em RetryInfo
+func grpcError(err error) error {
+	httpStatus := upstreamStatus(err)
+	code := codes.Unavailable
+	switch {
+	case errors.Is(err, errTextRequired), errors.Is(err, routing.ErrModelNotAllowed), errors.As(err, new(*invalidOptionError)):
+		code, httpStatus = codes.InvalidArgument, fasthttp.StatusBadRequest
+	case err == errRouteDisabled:
+		code, httpStatus = codes.Unimplemented, fasthttp.StatusNotFound
+	case errors.Is(err, errResponseBlocked), errors.Is(err, moderation.ErrContentBlocked), errors.Is(err, provider.ErrSafetyBlocked):
+		code, httpStatus = codes.FailedPrecondition, fasthttp.StatusUnprocessableEntity
+	case errors.As(err, new(*routing.ThrottledError)):
+	case httpStatus == fasthttp.StatusBadRequest:
+		code = codes.InvalidArgument
+	case httpStatus == fasthttp.StatusForbidden:
+		code = codes.PermissionDenied
+	case httpStatus == fasthttp.StatusUnprocessableEntity:
+		code = codes.FailedPrecondition
+	case httpStatus == fasthttp.StatusGatewayTimeout:
+		code = codes.DeadlineExceeded
+	case httpStatus == fasthttp.StatusInternalServerError:
+		code = codes.Internal
+	}
+
+	e := describeError(err, httpStatus)
+	st := status.New(code, e.Message)
+
+	info := &errdetails.ErrorInfo{
+		Reason:   e.Code,
+		Domain:   "lingobot",
+		Metadata: map[string]string{"retryable": strconv.FormatBool(e.Retryable)},
+	}
+	if e.Provider != "" {
+		info.Metadata["provider"] = e.Provider
+	}
+	details := []protoadapt.MessageV1{info}
+	if e.RetryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(time.Duration(e.RetryAfter) * time.Second)})
+	}
+	if detailed, err := st.WithDetails(details...); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+func toProto(result *provider.Result, candidate routing.Candidate, includeReasoning bool) *lingobotpb.ChatResponse {
+	out := &lingobotpb.ChatResponse{
+		Response:         result.Text,
+		Usage:            usageProto(result.Usage),
+		Experiment:       experimentProto(candidate.Tag()),
+		ResponseLanguage: result.Language,
+	}
+	if includeReasoning {
+		out.Reasoning = result.Reasoning
+	}
+	return out
+}
+
+func usageProto(u provider.Usage) *lingobotpb.Usage {
+	if u.TotalTokens == 0 {
+		return nil
+	}
+	return &lingobotpb.Usage{
+		PromptTokens:     int32(u.PromptTokens),
+		CompletionTokens: int32(u.CompletionTokens),
+		TotalTokens:      int32(u.TotalTokens),
+	}
+}
+
+func experimentProto(tag *routing.ExperimentTag) *lingobotpb.ExperimentTag {
+	if tag == nil {
+		return nil
+	}
+	return &lingobotpb.ExperimentTag{Experiment: tag.Experiment, Arm: tag.Arm}
+}
+
+func (s *grpcService) Chat(ctx context.Context, in *lingobotpb.ChatRequest) (*lingobotpb.ChatResponse, error) {
+	timer := &turnTimer{received: time.Now()}
+
+	req := fromProto(in)
+	if err := req.validate(); err != nil {
+		return nil, grpcError(err)
+	}
+	req.from("grpc:Chat", grpcClientID(ctx, req.SessionID), grpcAPIKey(ctx))
+
+	candidates, err := req.plan("/ai", in.GetProvider())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	pin := req.providerRequest()
+	result, candidate, err := executeTurn(req, pin, candidates, timer)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	if in.GetProvider() == "" {
+		routing.Mirror(pin, result, timer.providerEnd.Sub(timer.providerStart))
+	}
+
+	return toProto(result, candidate, req.IncludeReasoning), nil
+}
+
+func (s *grpcService) ChatStream(in *lingobotpb.ChatRequest, stream lingobotpb.Lingobot_ChatStreamServer) error {
+	timer := &turnTimer{received: time.Now()}
+
+	req := fromProto(in)
+	if err := req.validate(); err != nil {
+		return grpcError(err)
+	}
+	req.from("grpc:ChatStream", grpcClientID(stream.Context(), req.SessionID), grpcAPIKey(stream.Context()))
+
+	candidates, err := req.plan("/ai/stream", in.GetProvider())
+	if err != nil {
+		return grpcError(err)
+	}
+
+	pin := req.providerRequest()
+	t, err := prepareTurn(req, pin)
+	if err != nil {
+		return grpcError(err)
+	}
+
+	timer.startProvider()
+	result, candidate, err := routing.ExecuteStream(pin, candidates, func(chunk string) error {
+		if err := t.pipeline.chunk(t, chunk); err != nil {
+			return err
+		}
+		return stream.Send(&lingobotpb.ChatChunk{Delta: chunk})
+	})
+	timer.endProvider()
+	if err == nil {
+		t.candidate = candidate
+		t.pipeline.done(t, result)
+	}
+
+	f := finishFor(req, pin, result, candidate, err, timer)
+	f.Stream = true
+	hooks.Emit(f)
+
+	if err != nil {
+		if status.Code(err) != codes.Unknown {
+			return err
+		}
+		return grpcError(err)
+	}
+
+	if req.ConversationID != "" {
+		conversation.Record(req.ConversationID, req.Text, result)
+	}
+
+	return stream.Send(&lingobotpb.ChatChunk{
+		Done:             true,
+		Usage:            usageProto(result.Usage),
+		Experiment:       experimentProto(candidate.Tag()),
+		ResponseLanguage: result.Language,
+	})
+}
+
+func (s *grpcService) Translate(ctx context.Context, in *lingobotpb.TranslateRequest) (*lingobotpb.ChatResponse, error) {
+	timer := &turnTimer{received: time.Now()}
+
+	if in.GetText() == "" || in.GetTargetLanguage() == "" {
+		return nil, status.Error(codes.InvalidArgument, "text and target_language fields are required")
+	}
+	if err := moderation.Check(in.GetText()); err != nil {
+		return nil, grpcError(err)
+	}
+
+	text, err := translationPrompt(in.GetText(), in.GetSourceLanguage(), in.GetTargetLanguage())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	req := &chatRequest{
+		Text:      text,
+		SessionID: in.GetSessionId(),
+		Language:  in.GetSourceLanguage(),
+		reply:     language.Normalize(in.GetTargetLanguage()),
+	}
+
+	req.from("grpc:Translate", grpcClientID(ctx, req.SessionID), grpcAPIKey(ctx))
+
+	candidates, err := req.plan("/translate", "")
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	result, candidate, err := executeTurn(req, req.providerRequest(), candidates, timer)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	out := toProto(result, candidate, false)
+	out.Response = strings.TrimSpace(out.Response)
+	return out, nil
+}
+
+// Textos por Embed; o embedder ainda divide o lote nas chamadas à API
+const maxEmbedTexts = 100
+
+func (s *grpcService) Embed(ctx context.Context, in *lingobotpb.EmbedRequest) (*lingobotpb.EmbedResponse, error) {
+	texts := in.GetTexts()
+	if len(texts) == 0 || len(texts) > maxEmbedTexts {
+		return nil, status.Errorf(codes.InvalidArgument, "texts field must have 1 to %d items", maxEmbedTexts)
+	}
+	for _, t := range texts {
+		if strings.TrimSpace(t) == "" {
+			return nil, status.Error(codes.InvalidArgument, "texts must not be empty")
+		}
+	}
+
+	embedder := provider.DefaultEmbedder()
+	vectors, err := embedder.Embed(texts)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	out := &lingobotpb.EmbedResponse{Model: embedder.Name, Embeddings: make([]*lingobotpb.Embedding, len(vectors))}
+	for i, v := range vectors {
+		out.Embeddings[i] = &lingobotpb.Embedding{Values: v}
+	}
+	return out, nil
+}
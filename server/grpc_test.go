@@ -0,0 +1,43 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"lingobot-ai-engine/proto/lingobotpb"
+)
+
+// O Embed usa o embedder padrão (local, sem chaves) e devolve um vetor por texto
+func TestGRPCEmbed(t *testing.T) {
+	t.Setenv("EMBEDDINGS_PROVIDER", "local")
+
+	tests := []struct {
+		name  string
+		texts []string
+		want  codes.Code
+	}{
+		{"dois textos", []string{"olá mundo", "bom dia"}, codes.OK},
+		{"sem textos", nil, codes.InvalidArgument},
+		{"texto vazio", []string{"olá", " "}, codes.InvalidArgument},
+		{"lote grande", strings.Split(strings.Repeat("a,", maxEmbedTexts), ","), codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := (&grpcService{}).Embed(context.Background(), &lingobotpb.EmbedRequest{Texts: tt.texts})
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("code = %v, want %v (err %v)", got, tt.want, err)
+			}
+			if err != nil {
+				return
+			}
+			if out.GetModel() != "local:hash-512" || len(out.GetEmbeddings()) != len(tt.texts) {
+				t.Errorf("model %q, %d embeddings; want local:hash-512 and %d", out.GetModel(), len(out.GetEmbeddings()), len(tt.texts))
+			}
+		})
+	}
+}
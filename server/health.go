@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/instance"
+	"lingobot-ai-engine/routing"
+	"lingobot-ai-engine/version"
+)
+
+// Estado da instância no GET /health e no registro compartilhado
+type healthResponse struct {
+	Status      string                   `json:"status"` // ok, ou degraded sem nenhum provedor no ar
+	Instance    string                   `json:"instance_id"`
+	URL         string                   `json:"url,omitempty"`
+	Version     string                   `json:"version"`
+	Commit      string                   `json:"commit,omitempty"`
+	Started     time.Time                `json:"started"`
+	Uptime      int64                    `json:"uptime_s"`
+	ProvidersUp []string                 `json:"providers_up"`
+	Providers   []routing.ProviderHealth `json:"providers"`
+	InFlight    int64                    `json:"in_flight"`
+	Utilization float64                  `json:"utilization"`
+	Checked     time.Time                `json:"checked"`
+}
+
+func health() healthResponse {
+	info := version.Get()
+	out := healthResponse{
+		Status:      "ok",
+		Instance:    instance.ID,
+		URL:         instance.URL(),
+		Version:     info.Version,
+		Commit:      info.Commit,
+		Started:     instance.Started,
+		Uptime:      int64(instance.Uptime().Seconds()),
+		ProvidersUp: []string{},
+		Providers:   routing.Health(),
+		InFlight:    load.inFlight.Load(),
+		Utilization: float64(load.inFlight.Load()+load.queued.Load()) / float64(targetInFlight),
+		Checked:     time.Now(),
+	}
+	for _, p := range out.Providers {
+		if p.Up {
+			out.ProvidersUp = append(out.ProvidersUp, p.Name)
+		}
+	}
+	if len(out.ProvidersUp) == 0 {
+		out.Status = "degraded"
+	}
+	return out
+}
+
+// healthHandler responde 200 enquanto o processo atende, mesmo degradado: a
+// plataforma reinicia instância com health check falhando, e reiniciar não
+// traz provedor de volta. O roteador decide pelo status e providers_up (GET /health).
+func healthHandler(ctx *fasthttp.RequestCtx) {
+	body, _ := sonic.Marshal(health())
+	ctx.SetContentType("application/json")
+	ctx.Response.Header.Set("Cache-Control", "no-store")
+	ctx.SetBody(body)
+}
+
+// Heartbeat registra a instância no Redis com o mesmo estado do /health,
+// quando INSTANCE_REGISTRY está ligado
+func Heartbeat() {
+	instance.Heartbeat(func() interface{} { return health() })
+}
+
+type instancesResponse struct {
+	Instances []json.RawMessage `json:"instances"`
+}
+
+// instancesHandler lista as instâncias vivas do registro compartilhado, no
+// formato do /health de cada uma (GET /admin/instances)
+func instancesHandler(ctx *fasthttp.RequestCtx) {
+	if !adminAuthorized(ctx) {
+		return
+	}
+	if !ctx.IsGet() {
+		writeErrorCode(ctx, fasthttp.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !instance.Registered() {
+		writeErrorCode(ctx, fasthttp.StatusNotFound, codeNotFound, "instance registry not enabled: set REDIS_URL and INSTANCE_REGISTRY=1")
+		return
+	}
+
+	states, err := instance.List()
+	if err != nil {
+		writeErrorCode(ctx, fasthttp.StatusServiceUnavailable, codeUnavailable, "instance registry unavailable")
+		return
+	}
+
+	out := instancesResponse{Instances: []json.RawMessage{}}
+	ids := make([]string, 0, len(states))
+	for id := range states {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		out.Instances = append(out.Instances, states[id])
+	}
+	body, _ := sonic.Marshal(out)
+	ctx.SetContentType("application/json")
+	ctx.Response.Header.Set("Cache-Control", "no-store")
+	ctx.SetBody(body)
+}
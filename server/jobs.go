@@ -0,0 +1,311 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/internal/env"
+	"lingobot-ai-engine/kv"
+	"lingobot-ai-engine/provider"
+	"lingobot-ai-engine/routing"
+)
+
+// Estados de um job assíncrono
+const (
+	jobQueued  = "queued"
+	jobRunning = "running"
+	jobDone    = "done"
+	jobFailed  = "failed"
+)
+
+// Geração assíncrona do POST /ai/async
+type job struct {
+	ID          string      `json:"id"`
+	Status      string      `json:"status"`
+	Result      *aiResponse `json:"result,omitempty"`
+	Error       *apiError   `json:"error,omitempty"`
+	Attempts    int         `json:"attempts,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	CompletedAt *time.Time  `json:"completed_at,omitempty"`
+
+	req         chatRequest
+	in          *provider.Request
+	candidates  []routing.Candidate
+	callbackURL string
+
+	payload  []byte    // corpo original do POST, para a fila de mensagens mortas
+	runs     int       // tentativas desde o último enfileiramento pelo cliente ou admin
+	queuedAt time.Time // início da espera na fila, para os tempos do turno
+}
+
+var (
+	// JOB_WORKERS gerações em paralelo; JOB_QUEUE_SIZE jobs esperando
+	jobWorkers = env.PositiveInt("JOB_WORKERS", 4)
+	jobQueue   = make(chan *job, env.PositiveInt("JOB_QUEUE_SIZE", 100))
+
+	// JOB_TTL: por quanto tempo o resultado fica disponível para polling
+	jobTTL = env.PositiveDuration("JOB_TTL", time.Hour)
+
+	// JOB_MAX_ATTEMPTS: tentativas por job quando a falha é transitória
+	// (retryable); esgotadas, o job vai para a fila de mensagens mortas
+	jobMaxAttempts = env.PositiveInt("JOB_MAX_ATTEMPTS", 3)
+
+	// estado público dos jobs, para o polling em qualquer instância
+	jobStore = kv.Prefixed(kv.Default, "job")
+
+	// JOB_CALLBACK_SECRET assina o callback em X-Lingobot-Signature (sha256=hex)
+	callbackSecret = os.Getenv("JOB_CALLBACK_SECRET")
+
+	callbackClient = &fasthttp.Client{
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+		Dial:         dialCallback,
+	}
+)
+
+func init() {
+	for i := 0; i < jobWorkers; i++ {
+		go jobWorker()
+	}
+}
+
+func newJobID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "job_" + hex.EncodeToString(b)
+}
+
+func jobWorker() {
+	for j := range jobQueue {
+		load.queued.Add(-1)
+		runJob(j)
+	}
+}
+
+func runJob(j *job) {
+	load.inFlight.Add(1)
+	defer load.inFlight.Add(-1)
+
+	setJob(j, func(j *job) {
+		j.Status = jobRunning
+		j.Attempts++
+		j.runs++
+	})
+
+	timer := &turnTimer{received: j.queuedAt}
+	result, candidate, err := executeTurn(&j.req, j.in, j.candidates, timer)
+
+	if err != nil {
+		e := describeError(err, upstreamStatus(err))
+		if e.Retryable && j.runs < jobMaxAttempts {
+			retryJob(j, &e)
+			return
+		}
+		setJob(j, func(j *job) {
+			now := time.Now()
+			j.CompletedAt = &now
+			j.Status = jobFailed
+			j.Error = &e
+		})
+		deadLetterJob(j, err)
+	} else {
+		setJob(j, func(j *job) {
+			now := time.Now()
+			j.CompletedAt = &now
+			out := newAIResponse(result, j.req.IncludeReasoning)
+			out.Experiment = candidate.Tag()
+			j.Status = jobDone
+			j.Error = nil
+			j.Result = &out
+		})
+	}
+
+	if j.callbackURL != "" {
+		go deliverCallback(j)
+	}
+}
+
+// retryJob volta o job para a fila depois de um backoff exponencial; o
+// cliente vê o status queued com o último erro
+func retryJob(j *job, last *apiError) {
+	backoff := time.Duration(1<<uint(j.runs)) * time.Second
+	if retryAfter := time.Duration(last.RetryAfter) * time.Second; retryAfter > backoff {
+		backoff = retryAfter
+	}
+	log.Printf("🔁 %s falhou (%s), nova tentativa em %s", j.ID, last.Code, backoff)
+
+	setJob(j, func(j *job) {
+		j.Status = jobQueued
+		j.Error = last
+	})
+
+	time.AfterFunc(backoff, func() {
+		// o orçamento de chamadas é por tentativa
+		j.in = j.req.providerRequest()
+		if !enqueueJob(j) {
+			setJob(j, func(j *job) {
+				now := time.Now()
+				j.CompletedAt = &now
+				j.Status = jobFailed
+			})
+			deadLetterJob(j, errJobQueueFull)
+			if j.callbackURL != "" {
+				go deliverCallback(j)
+			}
+		}
+	})
+}
+
+var errJobQueueFull = errors.New("job queue is full")
+
+// enqueueJob coloca o job na fila dos workers; false se a fila está cheia
+func enqueueJob(j *job) bool {
+	j.queuedAt = time.Now()
+	load.queued.Add(1)
+	select {
+	case jobQueue <- j:
+		return true
+	default:
+		load.queued.Add(-1)
+		countShed()
+		return false
+	}
+}
+
+// setJob altera o job, que só o worker toca, e publica o novo estado
+func setJob(j *job, update func(*job)) {
+	update(j)
+	saveJob(j)
+}
+
+// saveJob grava o estado público; o ttl recomeça a cada mudança, então o
+// resultado fica JOB_TTL disponível depois de concluído
+func saveJob(j *job) bool {
+	body, _ := sonic.Marshal(j)
+	if err := jobStore.Set(j.ID, body, jobTTL); err != nil {
+		log.Printf("⚠️  Estado do %s não gravado: %v", j.ID, err)
+		return false
+	}
+	return true
+}
+
+func getJob(id string) ([]byte, bool) {
+	body, ok, err := jobStore.Get(id)
+	if err != nil {
+		log.Printf("⚠️  Estado do %s indisponível: %v", id, err)
+		return nil, false
+	}
+	return body, ok
+}
+
+// deliverCallback envia o job concluído ao cliente, com até 3 tentativas
+func deliverCallback(j *job) {
+	body, _ := getJob(j.ID)
+
+	for attempt := 0; attempt < 3; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt*attempt) * time.Second)
+		}
+
+		req := fasthttp.AcquireRequest()
+		resp := fasthttp.AcquireResponse()
+		req.SetRequestURI(j.callbackURL)
+		req.Header.SetMethod(fasthttp.MethodPost)
+		req.Header.SetContentType("application/json")
+		if callbackSecret != "" {
+			mac := hmac.New(sha256.New, []byte(callbackSecret))
+			mac.Write(body)
+			req.Header.Set("X-Lingobot-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		}
+		req.SetBody(body)
+
+		err := callbackClient.Do(req, resp)
+		status := resp.StatusCode()
+		fasthttp.ReleaseRequest(req)
+		fasthttp.ReleaseResponse(resp)
+
+		if err == nil && status < 300 {
+			return
+		}
+		log.Printf("⚠️  Callback do %s falhou (tentativa %d): status=%d err=%v", j.ID, attempt+1, status, err)
+		if errors.Is(err, errCallbackAddress) || errors.Is(err, errCallbackHost) {
+			// endereço recusado não muda na próxima tentativa
+			return
+		}
+	}
+}
+
+// aiAsyncHandler enfileira o turno e devolve o ID do job (POST /ai/async)
+func aiAsyncHandler(ctx *fasthttp.RequestCtx) {
+	var req chatRequest
+	if !parseChatRequest(ctx, &req) {
+		return
+	}
+
+	var extra struct {
+		CallbackURL string `json:"callback_url"`
+	}
+	sonic.Unmarshal(ctx.PostBody(), &extra)
+
+	if extra.CallbackURL != "" {
+		if err := validCallbackURL(extra.CallbackURL); err != nil {
+			writeErrorCode(ctx, fasthttp.StatusBadRequest, codeInvalidRequest, err.Error())
+			return
+		}
+	}
+
+	j := &job{
+		ID:          newJobID(),
+		Status:      jobQueued,
+		CreatedAt:   time.Now(),
+		req:         *req.from("/ai/async", clientID(ctx, req.SessionID), apiKey(ctx)),
+		in:          req.providerRequest(),
+		candidates:  routing.Plan(req.routingOptions(ctx)),
+		callbackURL: extra.CallbackURL,
+		payload:     append([]byte(nil), ctx.PostBody()...),
+	}
+
+	if !saveJob(j) {
+		writeErrorCode(ctx, fasthttp.StatusServiceUnavailable, codeUnavailable, "job store unavailable")
+		return
+	}
+
+	if !enqueueJob(j) {
+		jobStore.Delete(j.ID)
+		writeAPIError(ctx, fasthttp.StatusServiceUnavailable, apiError{Code: codeUnavailable, Message: errJobQueueFull.Error(), Retryable: true, RetryAfter: 5})
+		return
+	}
+
+	body, _ := sonic.Marshal(map[string]string{"job_id": j.ID, "status": jobQueued})
+	ctx.SetStatusCode(fasthttp.StatusAccepted)
+	ctx.SetContentType("application/json")
+	ctx.Response.Header.Set("Location", "/jobs/"+j.ID)
+	ctx.SetBody(body)
+}
+
+// jobHandler devolve o estado do job (GET /jobs/{id})
+func jobHandler(ctx *fasthttp.RequestCtx, id string) {
+	if !ctx.IsGet() {
+		writeErrorCode(ctx, fasthttp.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	body, ok := getJob(strings.TrimSuffix(id, "/"))
+	if !ok {
+		writeErrorCode(ctx, fasthttp.StatusNotFound, codeNotFound, "job not found")
+		return
+	}
+
+	ctx.SetContentType("application/json")
+	ctx.SetBody(body)
+}
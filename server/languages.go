@@ -0,0 +1,128 @@
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/hooks"
+)
+
+// Contadores de qualidade por idioma esperado da resposta
+type languageStats struct {
+	requests      int
+	errors        int
+	wrongLanguage int // resposta detectada em outro idioma
+	undetected    int // resposta sem idioma reconhecível
+	latency       time.Duration
+	responses     map[string]int // idioma detectado → respostas
+}
+
+// Métricas expostas em GET /languages/metrics
+type languageReport struct {
+	Language          string         `json:"language"`
+	Requests          int            `json:"requests"`
+	Errors            int            `json:"errors"`
+	WrongLanguage     int            `json:"wrong_language"`
+	WrongLanguageRate float64        `json:"wrong_language_rate"`
+	Undetected        int            `json:"undetected"`
+	AvgLatencyMs      int64          `json:"avg_latency_ms"`
+	Responses         map[string]int `json:"responses"`
+}
+
+// Turnos sem idioma esperado entram como "unknown"
+const unknownLanguage = "unknown"
+
+type languageMetrics struct {
+	mu    sync.Mutex
+	stats map[string]*languageStats
+}
+
+var langMetrics = &languageMetrics{stats: map[string]*languageStats{}}
+
+func init() {
+	hooks.Register(langMetrics)
+}
+
+func (m *languageMetrics) Name() string { return "language-metrics" }
+
+// OnFinish conta só turnos gerados agora; respostas reaproveitadas não medem o provedor
+func (m *languageMetrics) OnFinish(f *hooks.Finish) {
+	if f.Cached {
+		return
+	}
+
+	expected := f.ExpectedLanguage
+	if expected == "" {
+		expected = unknownLanguage
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.stats[expected]
+	if !ok {
+		s = &languageStats{responses: map[string]int{}}
+		m.stats[expected] = s
+	}
+
+	s.requests++
+	s.latency += f.Timings.Provider
+	switch {
+	case f.Err != nil:
+		s.errors++
+	case f.ResponseLanguage == "":
+		s.undetected++
+	default:
+		s.responses[f.ResponseLanguage]++
+		if expected != unknownLanguage && f.ResponseLanguage != expected {
+			s.wrongLanguage++
+		}
+	}
+}
+
+func (m *languageMetrics) reports() []languageReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]languageReport, 0, len(m.stats))
+	for lang, s := range m.stats {
+		r := languageReport{
+			Language:      lang,
+			Requests:      s.requests,
+			Errors:        s.errors,
+			WrongLanguage: s.wrongLanguage,
+			Undetected:    s.undetected,
+			AvgLatencyMs:  (s.latency / time.Duration(s.requests)).Milliseconds(),
+			Responses:     make(map[string]int, len(s.responses)),
+		}
+		for k, v := range s.responses {
+			r.Responses[k] = v
+		}
+		if answered := s.requests - s.errors; answered > 0 {
+			r.WrongLanguageRate = float64(s.wrongLanguage) / float64(answered)
+		}
+		out = append(out, r)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Requests > out[j].Requests })
+	return out
+}
+
+// languageMetricsHandler expõe a qualidade por idioma (GET /languages/metrics)
+func languageMetricsHandler(ctx *fasthttp.RequestCtx) {
+	if !ctx.IsGet() {
+		writeErrorCode(ctx, fasthttp.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	body, _ := sonic.Marshal(map[string]interface{}{
+		"languages": langMetrics.reports(),
+		"levels":    lvlMetrics.reports(),
+	})
+	ctx.SetContentType("application/json")
+	ctx.SetBody(body)
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"lingobot-ai-engine/conversation"
+	"lingobot-ai-engine/vocabulary"
+)
+
+// Instrução de sistema de cada nível do CEFR: limita o vocabulário e a
+// complexidade das frases de todas as respostas de tutoria. Vai no campo de
+// sistema de cada provedor (systemInstruction no Gemini, preamble na Cohere,
+// mensagem system no formato OpenAI).
+var levelGuides = map[string]string{
+	"A1": "O aluno é iniciante, nível A1 do CEFR. No idioma que ele estuda, use só palavras muito frequentes do dia a dia, " +
+		"frases curtas (até 8 palavras), presente do indicativo e nenhuma oração subordinada. Não use expressões idiomáticas.",
+	"A2": "O aluno é básico, nível A2 do CEFR. No idioma que ele estuda, use vocabulário frequente de situações cotidianas, " +
+		"frases curtas e simples ligadas por \"e\", \"mas\" e \"porque\", e só os tempos verbais mais comuns. Explique qualquer palavra menos comum.",
+	"B1": "O aluno é intermediário, nível B1 do CEFR. No idioma que ele estuda, use vocabulário comum de temas conhecidos, " +
+		"frases de tamanho médio com no máximo uma oração subordinada e evite expressões idiomáticas raras e termos técnicos.",
+	"B2": "O aluno é intermediário avançado, nível B2 do CEFR. No idioma que ele estuda, pode usar vocabulário variado e abstrato " +
+		"e frases com subordinadas, mas sem jargão nem referências culturais obscuras.",
+	"C1": "O aluno é avançado, nível C1 do CEFR. No idioma que ele estuda, use linguagem natural e idiomática e estruturas complexas, " +
+		"mantendo a clareza.",
+	"C2": "O aluno é proficiente, nível C2 do CEFR. No idioma que ele estuda, use a língua sem simplificar, com nuances de registro " +
+		"e expressões idiomáticas, como um falante nativo culto.",
+}
+
+// levelSystem é a instrução de sistema do nível; vazia sem nível
+func levelSystem(level string) string {
+	return levelGuides[level]
+}
+
+// resolveLevel valida o nível pedido e o grava como padrão da sessão; sem
+// nível no pedido, usa o que a sessão já tem
+func resolveLevel(session, level string) (string, error) {
+	if level == "" {
+		if session == "" {
+			return "", nil
+		}
+		return conversation.Level(session), nil
+	}
+
+	parsed, ok := vocabulary.ParseLevel(level)
+	if !ok {
+		return "", &invalidOptionError{errInvalidLevel}
+	}
+	if session != "" {
+		conversation.SetLevel(session, parsed)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/internal/env"
+)
+
+// Ajuste do servidor HTTP para o app, que abre muitas conexões curtas:
+//
+//	HTTP_MAX_BODY_BYTES=4194304  tamanho máximo do corpo do pedido (413 acima)
+//	HTTP_CONCURRENCY=262144      pedidos simultâneos antes de responder 503
+//	HTTP_IDLE_TIMEOUT=2m         quanto uma conexão keep-alive parada fica aberta; vazio não fecha
+//	HTTP_TCP_KEEPALIVE=30s       liga o keep-alive do TCP com esse período; vazio deixa o do sistema
+//	HTTP2=h2c                    HTTP/2 sem TLS (prior knowledge) atrás do proxy, com HTTP/1.1 na mesma porta
+//	HTTP2=h2                     HTTP/2 com TLS e ALPN; exige TLS_CERT_FILE e TLS_KEY_FILE
+//
+// Sem HTTP2 quem atende é o fasthttp, como sempre; com ele, o net/http, que
+// passa cada pedido aos mesmos handlers.
+var (
+	maxBodyBytes = env.PositiveInt("HTTP_MAX_BODY_BYTES", fasthttp.DefaultMaxRequestBodySize)
+	concurrency  = env.PositiveInt("HTTP_CONCURRENCY", fasthttp.DefaultConcurrency)
+	idleTimeout  = env.PositiveDuration("HTTP_IDLE_TIMEOUT", 0)
+	tcpKeepAlive = env.PositiveDuration("HTTP_TCP_KEEPALIVE", 0)
+)
+
+// Serve atende em addr até o processo terminar
+func Serve(addr string) error {
+	mode := os.Getenv("HTTP2")
+	switch mode {
+	case "":
+		return serveFastHTTP(addr)
+	case "h2c", "h2":
+		return serveHTTP2(addr, mode)
+	}
+	return fmt.Errorf("invalid HTTP2 %q: use h2c or h2", mode)
+}
+
+func serveFastHTTP(addr string) error {
+	s := &fasthttp.Server{
+		Handler:            Handler(),
+		MaxRequestBodySize: maxBodyBytes,
+		Concurrency:        concurrency,
+		IdleTimeout:        idleTimeout,
+		TCPKeepalive:       tcpKeepAlive > 0,
+		TCPKeepalivePeriod: tcpKeepAlive,
+	}
+	return s.ListenAndServe(addr)
+}
+
+func serveHTTP2(addr, mode string) error {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+
+	s := &http.Server{
+		Handler:     &bridge{handler: Handler(), slots: make(chan struct{}, concurrency)},
+		IdleTimeout: idleTimeout,
+		Protocols:   protocols,
+	}
+	lc := net.ListenConfig{KeepAlive: tcpKeepAlive}
+	if tcpKeepAlive == 0 {
+		lc.KeepAlive = -1
+	}
+
+	if mode == "h2c" {
+		protocols.SetUnencryptedHTTP2(true)
+		ln, err := lc.Listen(context.Background(), "tcp", addr)
+		if err != nil {
+			return err
+		}
+		log.Printf("⚡ HTTP/2 sem TLS (h2c) e HTTP/1.1 em %s", addr)
+		return s.Serve(ln)
+	}
+
+	certFile, keyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	if certFile == "" || keyFile == "" {
+		return fmt.Errorf("HTTP2=h2 requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	protocols.SetHTTP2(true)
+	s.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	ln, err := lc.Listen(context.Background(), "tcp", addr)
+	if err != nil {
+		return err
+	}
+	log.Printf("⚡ HTTP/2 com TLS (h2) e HTTP/1.1 em %s", addr)
+	return s.ServeTLS(ln, "", "")
+}
+
+// bridge passa os pedidos do net/http ao handler do fasthttp, com os mesmos
+// limites de corpo e de concorrência do servidor fasthttp
+type bridge struct {
+	handler fasthttp.RequestHandler
+	slots   chan struct{}
+}
+
+// Cabeçalhos de conexão: proibidos no HTTP/2, o net/http cuida deles
+var hopHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Keep-Alive":        true,
+	"Transfer-Encoding": true,
+}
+
+func (b *bridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	select {
+	case b.slots <- struct{}{}:
+		defer func() { <-b.slots }()
+	default:
+		http.Error(w, "server is overloaded", http.StatusServiceUnavailable)
+		return
+	}
+
+	if r.ContentLength > int64(maxBodyBytes) {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(maxBodyBytes)))
+	if err != nil {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var req fasthttp.Request
+	req.Header.SetMethod(r.Method)
+	req.Header.SetProtocol(r.Proto)
+	req.SetRequestURI(r.URL.RequestURI())
+	req.Header.SetHost(r.Host)
+	for k, values := range r.Header {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	req.SetBody(body)
+
+	var remote net.Addr
+	if host, port, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		p, _ := strconv.Atoi(port)
+		remote = &net.TCPAddr{IP: net.ParseIP(host), Port: p}
+	}
+	var ctx fasthttp.RequestCtx
+	ctx.Init(&req, remote, nil)
+	b.handler(&ctx)
+
+	resp := &ctx.Response
+	for k, v := range resp.Header.All() {
+		if key := string(k); !hopHeaders[key] {
+			w.Header().Add(key, string(v))
+		}
+	}
+	w.WriteHeader(resp.StatusCode())
+
+	if !resp.IsBodyStream() {
+		w.Write(resp.Body())
+		return
+	}
+	// SSE: cada Flush do handler vira um frame para o cliente
+	defer resp.CloseBodyStream()
+	io.Copy(flushWriter{w, http.NewResponseController(w)}, resp.BodyStream())
+}
+
+type flushWriter struct {
+	w  io.Writer
+	rc *http.ResponseController
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if err == nil {
+		err = f.rc.Flush()
+	}
+	return n, err
+}
@@ -0,0 +1,132 @@
+package server
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/internal/env"
+	"lingobot-ai-engine/routing"
+)
+
+const (
+	loadBucket  = 10 * time.Second
+	loadBuckets = 6 // janela de 1 minuto para a taxa de descarte
+)
+
+// Contadores de carga da instância, lidos pelo /scaling-hint
+var load = struct {
+	inFlight atomic.Int64
+	queued   atomic.Int64 // esperando vaga para chamar o provedor
+
+	mu      sync.Mutex
+	buckets [loadBuckets]struct {
+		start    time.Time
+		requests int64
+		shed     int64
+	}
+}{}
+
+// SCALING_TARGET_INFLIGHT: requisições simultâneas que uma réplica atende bem
+var targetInFlight = env.PositiveInt("SCALING_TARGET_INFLIGHT", 50)
+
+// bucket devolve o balde da janela atual, zerando os que expiraram
+func bucket(now time.Time) int {
+	start := now.Truncate(loadBucket)
+	i := int(start.Unix()/int64(loadBucket/time.Second)) % loadBuckets
+	if !load.buckets[i].start.Equal(start) {
+		load.buckets[i].start = start
+		load.buckets[i].requests = 0
+		load.buckets[i].shed = 0
+	}
+	return i
+}
+
+func countRequest() {
+	load.mu.Lock()
+	load.buckets[bucket(time.Now())].requests++
+	load.mu.Unlock()
+}
+
+// countShed registra uma requisição recusada por excesso de carga
+func countShed() {
+	load.mu.Lock()
+	load.buckets[bucket(time.Now())].shed++
+	load.mu.Unlock()
+}
+
+// shedRate é a fração de requisições descartadas no último minuto
+func shedRate() float64 {
+	cutoff := time.Now().Add(-loadBucket * loadBuckets)
+
+	load.mu.Lock()
+	defer load.mu.Unlock()
+
+	var requests, shed int64
+	for _, b := range load.buckets {
+		if b.start.After(cutoff) {
+			requests += b.requests
+			shed += b.shed
+		}
+	}
+	if requests == 0 {
+		return 0
+	}
+	return float64(shed) / float64(requests)
+}
+
+// Middleware que conta as requisições em andamento
+func withLoadTracking(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		switch string(ctx.Path()) {
+		case "/health", "/scaling-hint", "/status", "/version", "/warmup":
+			next(ctx)
+			return
+		}
+
+		countRequest()
+		load.inFlight.Add(1)
+		defer load.inFlight.Add(-1)
+
+		next(ctx)
+	}
+}
+
+// Sinal de escala consumido pelo autoscaler da plataforma
+type scalingHint struct {
+	InFlight         int64   `json:"in_flight"`
+	QueueDepth       int64   `json:"queue_depth"`
+	UpstreamInFlight int64   `json:"upstream_in_flight"` // chamadas aos provedores em andamento
+	UpstreamQueued   int64   `json:"upstream_queued"`    // esperando vaga em UPSTREAM_MAX_INFLIGHT
+	ShedRate         float64 `json:"shed_rate"`
+	TargetInFlight   int     `json:"target_in_flight"`
+	// Carga em relação ao alvo: >1 pede mais réplicas, <1 permite reduzir
+	Utilization float64 `json:"utilization"`
+}
+
+// scalingHintHandler expõe a carga atual (GET /scaling-hint)
+func scalingHintHandler(ctx *fasthttp.RequestCtx) {
+	if !ctx.IsGet() {
+		writeErrorCode(ctx, fasthttp.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	upstreamInFlight, upstreamQueued := routing.Upstream()
+	hint := scalingHint{
+		InFlight:         load.inFlight.Load(),
+		QueueDepth:       load.queued.Load(),
+		UpstreamInFlight: upstreamInFlight,
+		UpstreamQueued:   upstreamQueued,
+		ShedRate:         shedRate(),
+		TargetInFlight:   targetInFlight,
+	}
+	hint.Utilization = float64(hint.InFlight+hint.QueueDepth) / float64(targetInFlight)
+
+	body, _ := sonic.Marshal(hint)
+	ctx.SetContentType("application/json")
+	ctx.Response.Header.Set("Cache-Control", "no-store")
+	ctx.SetBody(body)
+}
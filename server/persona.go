@@ -0,0 +1,44 @@
+package server
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/bytedance/sonic"
+)
+
+// PERSONA_PROMPTS: instrução de sistema de cada persona, que a etapa "persona"
+// põe antes das do nível e do idioma da resposta. "default" vale para quem
+// chega sem persona ou com uma que não está na lista.
+//
+//	{"tutor": "Você é um tutor paciente...", "default": "Você é o LingoBot..."}
+var personaPrompts = loadPersonaPrompts(os.Getenv("PERSONA_PROMPTS"))
+
+func loadPersonaPrompts(raw string) map[string]string {
+	if raw == "" {
+		return nil
+	}
+	var prompts map[string]string
+	if err := sonic.UnmarshalString(raw, &prompts); err != nil {
+		log.Printf("⚠️  PERSONA_PROMPTS inválido, sem instrução de persona: %v", err)
+		return nil
+	}
+	return prompts
+}
+
+func personaSystem(persona string) string {
+	if prompt, ok := personaPrompts[persona]; ok {
+		return prompt
+	}
+	return personaPrompts["default"]
+}
+
+func init() {
+	registerPre("persona", func(t *turn) error {
+		if prompt := personaSystem(t.req.Persona); prompt != "" {
+			t.in.System = strings.TrimSpace(prompt + "\n\n" + t.in.System)
+		}
+		return nil
+	})
+}
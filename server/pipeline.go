@@ -0,0 +1,318 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/compression"
+	"lingobot-ai-engine/dedupe"
+	"lingobot-ai-engine/moderation"
+	"lingobot-ai-engine/provider"
+	"lingobot-ai-engine/routing"
+)
+
+// Etapas do turno de IA em volta da chamada ao provedor. Cada etapa é
+// registrada por nome no init do arquivo que a implementa; quais rodam, e em
+// que ordem, vem de PIPELINE_PRE e PIPELINE_POST, nomes separados por vírgula.
+// Sem elas vale a ordem padrão abaixo. PUT /admin/pipeline troca as cadeias
+// desta instância sem reiniciar.
+//
+//	PIPELINE_PRE=moderation,persona,regenerate,compression
+//	PIPELINE_POST=vocabulary,post,moderation,regenerate,log
+//
+// As etapas de pedido rodam também no streaming. Das de resposta, no
+// streaming só rodam as que têm forma de streaming (moderation, regenerate e
+// log), porque a resposta sai em pedaços e não pode mais ser reescrita; GET
+// /admin/pipeline lista quais são. O regenerate precisa vir antes da
+// compression, que altera o histórico, e vale só para /ai e /ai/stream. Os
+// hooks ficam fora do pipeline: são os assinantes do fim do turno e veem
+// também os turnos que falharam, que não passam pelas etapas de resposta.
+var (
+	defaultPre  = []string{"moderation", "persona", "regenerate", "compression"}
+	defaultPost = []string{"vocabulary", "post", "moderation", "regenerate"}
+)
+
+// turn é o que as etapas veem: o pedido do cliente, o pedido ao provedor e,
+// nas etapas de resposta, o candidato que respondeu
+type turn struct {
+	req       *chatRequest
+	in        *provider.Request
+	candidate routing.Candidate
+
+	// cadeias em uso quando o turno começou; um PUT no meio não o afeta
+	pipeline *pipelineConfig
+
+	// regenerate: a chave do prompt e, na repetição, a resposta já gerada que
+	// é servida no lugar do provedor
+	key    dedupe.Key
+	cached *provider.Result
+
+	// moderation no streaming: o fim do texto já conferido, para achar o termo
+	// partido entre dois pedaços
+	overlap string
+}
+
+// preStage ajusta o pedido ao provedor; o erro encerra o turno
+type preStage func(t *turn) error
+
+// postStage trata a resposta; o erro encerra o turno sem resposta
+type postStage func(t *turn, result *provider.Result) (*provider.Result, error)
+
+// streamStage é a forma de uma etapa de resposta no streaming: chunk confere
+// cada pedaço antes de ele ir ao cliente, e o erro encerra o stream; done vê a
+// resposta completa no fim, quando ela já saiu. Qualquer um dos dois pode faltar.
+type streamStage struct {
+	chunk func(t *turn, chunk string) error
+	done  func(t *turn, result *provider.Result)
+}
+
+var (
+	preStages    = map[string]preStage{}
+	postStages   = map[string]postStage{}
+	streamStages = map[string]streamStage{}
+)
+
+// registerPre e registerPost adicionam uma etapa; registerStream dá a forma de
+// streaming de uma etapa de resposta. Chamar no init.
+func registerPre(name string, s preStage)       { preStages[name] = s }
+func registerPost(name string, s postStage)     { postStages[name] = s }
+func registerStream(name string, s streamStage) { streamStages[name] = s }
+
+// Rotas em que o regenerate age, como antes de ele virar etapa: os tutores, os
+// jobs e o gRPC repetem prompts de propósito
+var regenerateRoutes = map[string]bool{"/ai": true, "/ai/stream": true}
+
+func init() {
+	registerPre("moderation", func(t *turn) error {
+		return moderation.Check(t.req.Text)
+	})
+	registerPre("compression", func(t *turn) error {
+		compression.Apply(t.in, t.req.Persona)
+		return nil
+	})
+	registerPre("regenerate", func(t *turn) error {
+		if !regenerateRoutes[t.req.route] {
+			return nil
+		}
+		var err error
+		t.key, t.cached, err = dedupe.Check(t.req.client, t.in)
+		return err
+	})
+
+	registerPost("vocabulary", func(t *turn, result *provider.Result) (*provider.Result, error) {
+		return steerVocabulary(t.req, t.in, result, &t.candidate), nil
+	})
+	registerPost("post", func(t *turn, result *provider.Result) (*provider.Result, error) {
+		return postProcess(t.req, t.in, result, &t.candidate), nil
+	})
+	registerPost("moderation", func(t *turn, result *provider.Result) (*provider.Result, error) {
+		if moderation.Check(result.Text) != nil {
+			return nil, errResponseBlocked
+		}
+		return result, nil
+	})
+	registerStream("moderation", streamStage{chunk: func(t *turn, chunk string) error {
+		window := t.overlap + chunk
+		if moderation.Check(window) != nil {
+			return errResponseBlocked
+		}
+		t.overlap = moderation.Overlap(window)
+		return nil
+	}})
+	registerPost("regenerate", func(t *turn, result *provider.Result) (*provider.Result, error) {
+		dedupe.Store(t.key, result)
+		return result, nil
+	})
+	registerStream("regenerate", streamStage{done: func(t *turn, result *provider.Result) {
+		dedupe.Store(t.key, result)
+	}})
+	registerPost("log", func(t *turn, result *provider.Result) (*provider.Result, error) {
+		logTurn(t, result)
+		return result, nil
+	})
+	registerStream("log", streamStage{done: logTurn})
+}
+
+// logTurn registra o turno concluído, sem o texto nem o cliente, que pode ser
+// uma API key
+func logTurn(t *turn, result *provider.Result) {
+	log.Printf("📝 %s: %s %s, %d+%d tokens, %d caracteres",
+		t.req.route, result.Provider, t.candidate.Model, result.Usage.PromptTokens, result.Usage.CompletionTokens, len(result.Text))
+}
+
+// Cadeias em uso
+type pipelineConfig struct {
+	Pre  []string `json:"pre"`
+	Post []string `json:"post"`
+}
+
+var pipeline atomic.Pointer[pipelineConfig]
+
+// currentPipeline lê as cadeias do env na primeira chamada; o init dos arquivos
+// com etapas precisa ter rodado antes
+func currentPipeline() *pipelineConfig {
+	if p := pipeline.Load(); p != nil {
+		return p
+	}
+	p := &pipelineConfig{Pre: envStages("PIPELINE_PRE", defaultPre), Post: envStages("PIPELINE_POST", defaultPost)}
+	if err := p.validate(); err != nil {
+		log.Printf("⚠️  PIPELINE_PRE/PIPELINE_POST inválido, usando a ordem padrão: %v", err)
+		p = &pipelineConfig{Pre: defaultPre, Post: defaultPost}
+	}
+	pipeline.CompareAndSwap(nil, p)
+	return pipeline.Load()
+}
+
+func envStages(name string, fallback []string) []string {
+	raw, ok := os.LookupEnv(name)
+	if !ok {
+		return fallback
+	}
+	stages := []string{}
+	for _, s := range strings.Split(raw, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			stages = append(stages, s)
+		}
+	}
+	return stages
+}
+
+func (p *pipelineConfig) validate() error {
+	for _, name := range p.Pre {
+		if preStages[name] == nil {
+			return fmt.Errorf("unknown pre stage %q", name)
+		}
+	}
+	for _, name := range p.Post {
+		if postStages[name] == nil {
+			return fmt.Errorf("unknown post stage %q", name)
+		}
+	}
+	return nil
+}
+
+// before roda as etapas de pedido; para quando o regenerate acha a resposta
+func (p *pipelineConfig) before(t *turn) error {
+	t.pipeline = p
+	for _, name := range p.Pre {
+		if err := preStages[name](t); err != nil {
+			return err
+		}
+		if t.cached != nil {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (p *pipelineConfig) after(t *turn, result *provider.Result) (*provider.Result, error) {
+	for _, name := range p.Post {
+		var err error
+		if result, err = postStages[name](t, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// chunk roda no streaming as etapas de resposta com forma de streaming sobre
+// cada pedaço que chega do provedor
+func (p *pipelineConfig) chunk(t *turn, chunk string) error {
+	for _, name := range p.Post {
+		if s := streamStages[name]; s.chunk != nil {
+			if err := s.chunk(t, chunk); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// done fecha o stream concluído nas etapas de resposta com forma de streaming
+func (p *pipelineConfig) done(t *turn, result *provider.Result) {
+	for _, name := range p.Post {
+		if s := streamStages[name]; s.done != nil {
+			s.done(t, result)
+		}
+	}
+}
+
+// streamed são as etapas de Post que também rodam no streaming, na ordem
+func (p *pipelineConfig) streamed() []string {
+	out := []string{}
+	for _, name := range p.Post {
+		if _, ok := streamStages[name]; ok {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// Corpo do GET e do PUT /admin/pipeline
+type pipelineResponse struct {
+	pipelineConfig
+	Stream    []string `json:"stream"` // etapas de post que rodam no /ai/stream
+	Available struct {
+		Pre    []string `json:"pre"`
+		Post   []string `json:"post"`
+		Stream []string `json:"stream"`
+	} `json:"available"`
+}
+
+// pipelineHandler mostra as cadeias em uso e as etapas registradas (GET) ou
+// troca as cadeias desta instância (PUT /admin/pipeline)
+func pipelineHandler(ctx *fasthttp.RequestCtx) {
+	if !adminAuthorized(ctx) {
+		return
+	}
+
+	switch {
+	case ctx.IsGet():
+	case ctx.IsPut():
+		var p pipelineConfig
+		if err := sonic.Unmarshal(ctx.PostBody(), &p); err != nil {
+			writeErrorCode(ctx, fasthttp.StatusBadRequest, codeInvalidJSON, "invalid JSON")
+			return
+		}
+		if p.Pre == nil || p.Post == nil {
+			writeErrorCode(ctx, fasthttp.StatusBadRequest, codeInvalidRequest, "pre and post fields are required")
+			return
+		}
+		if err := p.validate(); err != nil {
+			writeErrorCode(ctx, fasthttp.StatusBadRequest, codeInvalidRequest, err.Error())
+			return
+		}
+		pipeline.Store(&p)
+		log.Printf("🧩 Pipeline trocado: pre=%v post=%v", p.Pre, p.Post)
+	default:
+		writeErrorCode(ctx, fasthttp.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	current := currentPipeline()
+	out := pipelineResponse{pipelineConfig: *current, Stream: current.streamed()}
+	for name := range streamStages {
+		out.Available.Stream = append(out.Available.Stream, name)
+	}
+	for name := range preStages {
+		out.Available.Pre = append(out.Available.Pre, name)
+	}
+	for name := range postStages {
+		out.Available.Post = append(out.Available.Post, name)
+	}
+	sort.Strings(out.Available.Pre)
+	sort.Strings(out.Available.Post)
+	sort.Strings(out.Available.Stream)
+
+	body, _ := sonic.Marshal(out)
+	ctx.SetContentType("application/json")
+	ctx.Response.Header.Set("Cache-Control", "no-store")
+	ctx.SetBody(body)
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+	"unicode/utf8"
+
+	"lingobot-ai-engine/moderation"
+	"lingobot-ai-engine/provider"
+)
+
+// No streaming só rodam as etapas de post com forma de streaming, e só as
+// que estão na cadeia em uso
+func TestPipelineStream(t *testing.T) {
+	if err := moderation.Apply([]byte(`{"version":"test","terms":["proibido"]}`)); err != nil {
+		t.Fatal(err)
+	}
+	defer moderation.Apply([]byte(`{}`))
+
+	tests := []struct {
+		name     string
+		post     []string
+		streamed []string
+		blocked  bool
+	}{
+		{"padrão", defaultPost, []string{"moderation", "regenerate"}, true},
+		{"sem moderation", []string{"vocabulary", "post", "regenerate"}, []string{"regenerate"}, false},
+		{"com log", []string{"moderation", "log"}, []string{"moderation", "log"}, true},
+		{"vazio", []string{}, []string{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &pipelineConfig{Pre: []string{}, Post: tt.post}
+			if err := p.validate(); err != nil {
+				t.Fatal(err)
+			}
+			if got := p.streamed(); !reflect.DeepEqual(got, tt.streamed) {
+				t.Errorf("streamed = %v, want %v", got, tt.streamed)
+			}
+
+			turn := &turn{req: &chatRequest{route: "/ai/stream"}, in: &provider.Request{}, pipeline: p}
+			err := p.chunk(turn, "um texto proibido")
+			if blocked := err == errResponseBlocked; blocked != tt.blocked {
+				t.Errorf("chunk err = %v, want blocked %v", err, tt.blocked)
+			}
+			p.done(turn, &provider.Result{Text: "um texto"})
+		})
+	}
+}
+
+// O regenerate só age no /ai e no /ai/stream, e a resposta guardada encerra as
+// etapas de pedido
+func TestPipelineRegenerate(t *testing.T) {
+	p := &pipelineConfig{Pre: []string{"regenerate", "compression"}, Post: []string{"regenerate"}}
+
+	for _, route := range []string{"/ai", "/ai/async"} {
+		t.Run(route, func(t *testing.T) {
+			var served *provider.Result
+			for i, text := range []string{"um", "dois", "um", "dois", "um", "dois"} {
+				req := &chatRequest{route: route, client: "session:regenerate" + route}
+				in := &provider.Request{Text: "mesma pergunta"}
+				turn := &turn{req: req, in: in}
+				if err := p.before(turn); err != nil {
+					t.Fatalf("turn %d: %v", i+1, err)
+				}
+				if turn.cached != nil {
+					served = turn.cached
+					continue
+				}
+				if _, err := p.after(turn, &provider.Result{Text: text}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if route == "/ai" && served == nil {
+				t.Error("repeated prompt on /ai was never served from regenerate")
+			}
+			if route != "/ai" && served != nil {
+				t.Errorf("regenerate served %q on %s", served.Text, route)
+			}
+		})
+	}
+}
+
+// O moderation do streaming confere só o pedaço novo e o fim do anterior, e
+// ainda acha o termo partido entre pedaços
+func TestPipelineStreamOverlap(t *testing.T) {
+	if err := moderation.Apply([]byte(`{"version":"test","terms":["proibido","não"]}`)); err != nil {
+		t.Fatal(err)
+	}
+	defer moderation.Apply([]byte(`{}`))
+
+	tests := []struct {
+		name    string
+		chunks  []string
+		blocked bool
+	}{
+		{"inteiro num pedaço", []string{"um texto ", "proibido"}, true},
+		{"partido ao meio", []string{"um texto proi", "bido"}, true},
+		{"partido em três", []string{"um texto p", "roibi", "do"}, true},
+		{"runa no corte", []string{"isso n", "ão"}, true},
+		{"maiúsculas", []string{"PROI", "BIDO"}, true},
+		{"sem termo", []string{"uma proi", "bi", "ção"}, false},
+		{"termo espalhado", []string{"proi", " e ", "bido"}, false},
+	}
+
+	p := &pipelineConfig{Pre: []string{}, Post: []string{"moderation"}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			turn := &turn{req: &chatRequest{route: "/ai/stream"}, in: &provider.Request{}, pipeline: p}
+			var err error
+			for _, chunk := range tt.chunks {
+				if err = p.chunk(turn, chunk); err != nil {
+					break
+				}
+				if utf8.RuneCountInString(turn.overlap) >= len("proibido") {
+					t.Fatalf("overlap %q longer than the longest term minus one", turn.overlap)
+				}
+			}
+			if blocked := err == errResponseBlocked; blocked != tt.blocked {
+				t.Errorf("err = %v, want blocked %v", err, tt.blocked)
+			}
+		})
+	}
+}
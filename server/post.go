@@ -0,0 +1,127 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"lingobot-ai-engine/language"
+	"lingobot-ai-engine/postprocess"
+	"lingobot-ai-engine/prompt"
+	"lingobot-ai-engine/provider"
+	"lingobot-ai-engine/routing"
+)
+
+// Pedido de reescrita quando a resposta veio em outro idioma (post force_language)
+const forceLanguageTemplate = "Sua última resposta não está no idioma de código ISO 639-1 {{language}}. " +
+	"Reescreva a mesma resposta inteira nesse idioma, sem mudar o conteúdo nem comentar a troca. " +
+	"Responda só com o texto reescrito."
+
+// Instrução de sistema do reply_language
+const replyLanguageTemplate = "Responda sempre no idioma de código ISO 639-1 {{language}}, mesmo quando o aluno escrever em outro idioma."
+
+var errInvalidReplyLanguage = errors.New(`reply_language must be an ISO 639-1 code (e.g. "pt") or "auto"`)
+
+// resolveReplyLanguage valida o reply_language e o grava como idioma cobrado
+// da resposta; "auto" segue o idioma em que o aluno escreveu e, sem idioma
+// detectável, não cobra nenhum
+func (r *chatRequest) resolveReplyLanguage() error {
+	if r.ReplyLanguage == "" {
+		return nil
+	}
+	lang := language.Normalize(r.ReplyLanguage)
+	if lang == "auto" {
+		lang = language.Detect(r.Text)
+	} else if len(lang) != 2 || strings.Trim(lang, "abcdefghijklmnopqrstuvwxyz") != "" {
+		return &invalidOptionError{errInvalidReplyLanguage}
+	}
+	if r.reply == "" {
+		r.reply = lang
+		r.replyUnknown = lang == ""
+	}
+	return nil
+}
+
+// replySystem é a instrução de idioma do reply_language; vazia sem ele
+func (r *chatRequest) replySystem() string {
+	lang := r.replyLanguage()
+	if r.ReplyLanguage == "" || lang == "" {
+		return ""
+	}
+	text, err := prompt.Render(replyLanguageTemplate, prompt.Vars{
+		"language": prompt.Name(lang, maxNameRunes),
+	})
+	if err != nil {
+		return ""
+	}
+	return text
+}
+
+// postProcess roda a cadeia pedida em post sobre a resposta do turno. Com
+// reply_language, a resposta em outro idioma é reescrita como no force_language.
+func postProcess(req *chatRequest, in *provider.Request, result *provider.Result, candidate *routing.Candidate) *provider.Result {
+	chain, _ := postprocess.Parse(req.Post) // já validado no pedido
+	if req.ReplyLanguage != "" || chain.Has(postprocess.ForceLanguage) {
+		result = forceLanguage(req, in, result, candidate)
+	}
+	if len(chain) == 0 {
+		return result
+	}
+
+	out := *result
+	out.Text = chain.Apply(result.Text)
+	return &out
+}
+
+// postText roda só as etapas de texto, para respostas reaproveitadas
+func postText(req *chatRequest, text string) string {
+	chain, _ := postprocess.Parse(req.Post)
+	return chain.Apply(text)
+}
+
+// forceLanguage pede ao mesmo candidato a resposta no idioma esperado quando
+// ela veio em outro; idioma não detectado passa, e a falha da reescrita não
+// derruba o turno. O tempo da reescrita entra na latência do candidato.
+func forceLanguage(req *chatRequest, in *provider.Request, result *provider.Result, candidate *routing.Candidate) *provider.Result {
+	expected := req.replyLanguage()
+	if expected == "" || result.Language == "" || result.Language == expected {
+		return result
+	}
+
+	text, err := prompt.Render(forceLanguageTemplate, prompt.Vars{
+		"language": prompt.Name(expected, maxNameRunes),
+	})
+	if err != nil {
+		return result
+	}
+
+	retry := *in
+	retry.Text = text
+	retry.History = append(slices.Clone(in.History),
+		provider.Message{Role: "user", Content: in.Text},
+		provider.Message{Role: "assistant", Content: result.Text},
+	)
+
+	start := time.Now()
+	rewritten, _, err := routing.Execute(&retry, []routing.Candidate{*candidate})
+	candidate.Latency += time.Since(start)
+	if err != nil {
+		log.Printf("⚠️  Reescrita para o idioma %s falhou: %v", expected, err)
+		return result
+	}
+	usage := result.Usage
+	usage.PromptTokens += rewritten.Usage.PromptTokens
+	usage.CompletionTokens += rewritten.Usage.CompletionTokens
+	usage.TotalTokens += rewritten.Usage.TotalTokens
+
+	// reescrita que continua no idioma errado não vale a troca
+	if rewritten.Language != expected {
+		out := *result
+		out.Usage = usage
+		return &out
+	}
+	rewritten.Usage = usage
+	return rewritten
+}
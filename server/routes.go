@@ -0,0 +1,77 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/valyala/fasthttp"
+)
+
+// ENABLED_ROUTES limita a instância às rotas listadas; DISABLED_ROUTES remove rotas.
+// Ambas separadas por vírgula; "/conversations/*" cobre tudo abaixo do prefixo.
+// Valem também no gRPC: Chat responde como /ai ou /<provider>, ChatStream como
+// /ai/stream ou /<provider> e Translate como /translate.
+var (
+	enabledRoutes  = parseRoutes(os.Getenv("ENABLED_ROUTES"))
+	disabledRoutes = parseRoutes(os.Getenv("DISABLED_ROUTES"))
+)
+
+// errRouteDisabled é a rota desligada nesta instância, pedida por outro
+// transporte que não o HTTP
+var errRouteDisabled = errors.New("endpoint not found")
+
+// Rotas que continuam no ar mesmo fora de ENABLED_ROUTES
+var alwaysEnabled = map[string]bool{"/health": true, "/scaling-hint": true, "/status": true, "/version": true, "/warmup": true}
+
+func parseRoutes(raw string) []string {
+	var routes []string
+	for _, r := range strings.Split(raw, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			routes = append(routes, r)
+		}
+	}
+	return routes
+}
+
+func matchRoute(routes []string, path string) bool {
+	for _, r := range routes {
+		if prefix, ok := strings.CutSuffix(r, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == r {
+			return true
+		}
+	}
+	return false
+}
+
+// RouteEnabled diz se a instância atende o caminho
+func RouteEnabled(path string) bool {
+	if alwaysEnabled[path] {
+		return true
+	}
+	if len(enabledRoutes) > 0 && !matchRoute(enabledRoutes, path) {
+		return false
+	}
+	return !matchRoute(disabledRoutes, path)
+}
+
+// Middleware que responde 404 para rotas desligadas nesta instância
+func withRouteFilter(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	if len(enabledRoutes) == 0 && len(disabledRoutes) == 0 {
+		return next
+	}
+
+	log.Printf("🔀 Rotas: habilitadas=%v desabilitadas=%v", enabledRoutes, disabledRoutes)
+
+	return func(ctx *fasthttp.RequestCtx) {
+		if !RouteEnabled(string(ctx.Path())) {
+			writeErrorCode(ctx, fasthttp.StatusNotFound, codeNotFound, "endpoint not found")
+			return
+		}
+		next(ctx)
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// O gRPC recusa as rotas que o HTTP desliga, com o código equivalente ao 404
+func TestPlanRouteFilter(t *testing.T) {
+	saved := disabledRoutes
+	defer func() { disabledRoutes = saved }()
+	disabledRoutes = []string{"/groq", "/ai/stream"}
+
+	tests := []struct {
+		name     string
+		path     string
+		provider string
+		want     codes.Code
+	}{
+		{"fallback", "/ai", "", codes.OK},
+		{"provedor fixo", "/ai", "mistral", codes.OK},
+		{"provedor desligado", "/ai", "groq", codes.Unimplemented},
+		{"stream desligado", "/ai/stream", "", codes.Unimplemented},
+		{"provedor no stream desligado", "/ai/stream", "mistral", codes.OK},
+		{"provedor desconhecido", "/ai", "nope", codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &chatRequest{Text: "olá"}
+			candidates, err := req.plan(tt.path, tt.provider)
+			if tt.want == codes.OK {
+				if err != nil || len(candidates) == 0 {
+					t.Fatalf("plan = %v, %v; want candidates", candidates, err)
+				}
+				if tt.provider != "" && candidates[0].Provider.Name != tt.provider {
+					t.Errorf("candidate = %q, want %q", candidates[0].Provider.Name, tt.provider)
+				}
+				return
+			}
+			if got := status.Code(grpcError(err)); got != tt.want {
+				t.Errorf("code = %v, want %v (err %v)", got, tt.want, err)
+			}
+			if tt.want == codes.Unimplemented && !errors.Is(err, errRouteDisabled) {
+				t.Errorf("err = %v, want errRouteDisabled", err)
+			}
+		})
+	}
+}
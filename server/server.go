@@ -0,0 +1,144 @@
+// Package server expõe os endpoints HTTP (fasthttp) do gateway de IA.
+package server
+
+import (
+	"strings"
+
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/instance"
+	"lingobot-ai-engine/internal/routes"
+	"lingobot-ai-engine/provider"
+)
+
+// Middleware de CORS
+func withCORS(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+		ctx.Response.Header.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		ctx.Response.Header.Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Session-ID")
+		ctx.Response.Header.Set("Access-Control-Expose-Headers", "X-Provider, X-Upstream-Latency-Ms, X-Tokens-Prompt, X-Tokens-Completion, X-Fallback-Depth, X-Instance-ID, X-Lingobot-Cache, Retry-After")
+
+		if string(ctx.Method()) == fasthttp.MethodOptions {
+			ctx.SetStatusCode(fasthttp.StatusNoContent)
+			return
+		}
+
+		next(ctx)
+	}
+}
+
+// Handler devolve o roteador de endpoints com CORS
+func Handler() fasthttp.RequestHandler {
+	byName := func(name string) provider.Provider {
+		p, _ := provider.ByName(name)
+		return p
+	}
+
+	// rotas fixas que não são de provedor: os caminhos são os de routes.Fixed,
+	// que o provider usa para recusar provedores de CUSTOM_PROVIDERS com o
+	// mesmo nome
+	fixed := map[string]fasthttp.RequestHandler{
+		"/ai":                   aiHandler,
+		"/ai/stream":            aiStreamHandler,
+		"/ai/async":             aiAsyncHandler,
+		"/translate":            translateHandler,
+		"/exercises":            exercisesHandler,
+		"/conjugate":            conjugateHandler,
+		"/define":               defineHandler,
+		"/flashcards":           flashcardsHandler,
+		"/pronunciation":        pronunciationHandler,
+		"/documents":            documentsHandler,
+		"/tokenize":             tokenizeHandler,
+		"/models":               modelsHandler,
+		"/experiments":          experimentsHandler,
+		"/experiments/feedback": experimentFeedbackHandler,
+		"/languages/metrics":    languageMetricsHandler,
+		"/admin/blocklist":      blocklistWebhookHandler,
+		"/admin/requests":       requestsHandler,
+		"/admin/keys":           keysHandler,
+		"/admin/instances":      instancesHandler,
+		"/admin/pipeline":       pipelineHandler,
+		"/scaling-hint":         scalingHintHandler,
+		"/openapi.json":         openAPIHandler,
+		"/docs":                 docsHandler,
+		"/status":               statusHandler,
+		"/version":              versionHandler,
+		"/warmup":               warmupHandler,
+		"/health":               healthHandler,
+	}
+	mustMatchRoutes(fixed)
+
+	handler := func(ctx *fasthttp.RequestCtx) {
+		path := string(ctx.Path())
+		ctx.Response.Header.Set("X-Instance-ID", instance.ID)
+
+		if h, ok := fixed[path]; ok {
+			h(ctx)
+			return
+		}
+
+		switch path {
+		case "/gemini":
+			createAIHandler(byName("gemini"))(ctx)
+		case "/mistral":
+			createAIHandler(byName("mistral"))(ctx)
+		case "/cohere":
+			createAIHandler(byName("cohere"))(ctx)
+		case "/groq":
+			createAIHandler(byName("groq"))(ctx)
+		case "/openrouter":
+			createAIHandler(byName("openrouter"))(ctx)
+		case "/deepseek":
+			createAIHandler(byName("deepseek"))(ctx)
+		case "/together":
+			createAIHandler(byName("together"))(ctx)
+		case "/huggingface":
+			createAIHandler(byName("huggingface"))(ctx)
+		case "/azure":
+			createAIHandler(byName("azure"))(ctx)
+		case "/local":
+			createAIHandler(byName("local"))(ctx)
+		case "/mock":
+			createAIHandler(provider.Mock)(ctx)
+		default:
+			if p, ok := provider.ByName(strings.TrimPrefix(path, "/")); ok && p.Custom {
+				createAIHandler(p)(ctx)
+				return
+			}
+			// caminhos com ID, os prefixos de routes.Prefixes
+			if id, ok := strings.CutPrefix(path, "/jobs/"); ok {
+				jobHandler(ctx, id)
+				return
+			}
+			if rest, ok := strings.CutPrefix(path, "/admin/dead-letters"); ok && (rest == "" || rest[0] == '/') {
+				deadLettersHandler(ctx, rest)
+				return
+			}
+			if strings.HasPrefix(path, "/documents/") && strings.HasSuffix(path, "/ask") {
+				askDocumentHandler(ctx, strings.TrimSuffix(strings.TrimPrefix(path, "/documents/"), "/ask"))
+				return
+			}
+			if strings.HasPrefix(path, "/conversations/") && strings.HasSuffix(path, "/export") {
+				conversationExportHandler(ctx, strings.TrimSuffix(strings.TrimPrefix(path, "/conversations/"), "/export"))
+				return
+			}
+			writeErrorCode(ctx, fasthttp.StatusNotFound, codeNotFound, "endpoint not found")
+		}
+	}
+
+	return withCORS(withRouteFilter(withLoadTracking(handler)))
+}
+
+// mustMatchRoutes confere a tabela do roteador com routes.Fixed: uma rota fora
+// da lista deixaria um provedor configurado com o mesmo nome inalcançável
+func mustMatchRoutes(fixed map[string]fasthttp.RequestHandler) {
+	for _, path := range routes.Fixed {
+		if fixed[path] == nil {
+			panic("server: " + path + " is in routes.Fixed but has no handler")
+		}
+	}
+	if len(fixed) != len(routes.Fixed) {
+		panic("server: a fixed route is missing from routes.Fixed")
+	}
+}
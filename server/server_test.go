@@ -0,0 +1,8 @@
+package server
+
+import "testing"
+
+// Handler entra em pânico se a tabela de rotas fixas não bater com routes.Fixed
+func TestHandlerMatchesFixedRoutes(t *testing.T) {
+	Handler()
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/provider"
+)
+
+// Provedor em GET /status
+type providerStatus struct {
+	Name       string `json:"name"`
+	Configured bool   `json:"configured"`
+}
+
+type statusResponse struct {
+	Providers  []providerStatus           `json:"providers"`
+	Local      *provider.LocalStatus      `json:"local,omitempty"`
+	OpenRouter *provider.OpenRouterStatus `json:"openrouter,omitempty"`
+}
+
+// statusHandler mostra os provedores configurados, com LOCAL_LLM_URL a
+// saúde do backend local e os modelos carregados, e com OPENROUTER_KEY o
+// ranking dos modelos gratuitos do OpenRouter (GET /status)
+func statusHandler(ctx *fasthttp.RequestCtx) {
+	if !ctx.IsGet() {
+		writeErrorCode(ctx, fasthttp.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var out statusResponse
+	for _, p := range provider.All() {
+		out.Providers = append(out.Providers, providerStatus{Name: p.Name, Configured: p.Configured()})
+	}
+	if local, ok := provider.Local(); ok {
+		out.Local = &local
+	}
+	if p, _ := provider.ByName("openrouter"); p.Configured() {
+		openRouter := provider.OpenRouter()
+		out.OpenRouter = &openRouter
+	}
+
+	body, _ := sonic.Marshal(out)
+	ctx.SetContentType("application/json")
+	ctx.SetBody(body)
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/conversation"
+	"lingobot-ai-engine/dedupe"
+	"lingobot-ai-engine/hooks"
+	"lingobot-ai-engine/provider"
+	"lingobot-ai-engine/routing"
+)
+
+// Último evento do stream, com os metadados do turno. Os cabeçalhos de
+// roteamento do /ai (X-Provider, X-Upstream-Latency-Ms, X-Fallback-Depth) saem
+// antes do primeiro trecho, quando nada disso é conhecido; aqui vão no fim.
+type streamSummary struct {
+	Language      string                 `json:"response_language,omitempty"`
+	Usage         *provider.Usage        `json:"usage,omitempty"`
+	Experiment    *routing.ExperimentTag `json:"experiment,omitempty"`
+	Provider      string                 `json:"provider,omitempty"`
+	LatencyMs     int64                  `json:"upstream_latency_ms"`
+	FallbackDepth int                    `json:"fallback_depth"`
+}
+
+var errPostStream = errors.New("post is not supported on streaming responses; use /ai")
+
+// writeEvent escreve um evento SSE e força o envio ao cliente
+func writeEvent(w *bufio.Writer, event string, payload interface{}) error {
+	data, _ := sonic.Marshal(payload)
+
+	if event != "" {
+		w.WriteString("event: ")
+		w.WriteString(event)
+		w.WriteString("\n")
+	}
+	w.WriteString("data: ")
+	w.Write(data)
+	w.WriteString("\n\n")
+	return w.Flush()
+}
+
+// aiStreamHandler é o /ai com resposta em Server-Sent Events:
+// eventos "data: {"delta":...}", depois "event: done" ou "event: error"
+func aiStreamHandler(ctx *fasthttp.RequestCtx) {
+	timer := newTurnTimer(ctx)
+
+	var req chatRequest
+	if !parseChatRequest(ctx, &req) {
+		return
+	}
+	if len(req.Post) > 0 {
+		// as etapas precisam da resposta inteira
+		writeError(ctx, fasthttp.StatusBadRequest, &invalidOptionError{errPostStream})
+		return
+	}
+
+	in := req.providerRequest()
+	req.from("/ai/stream", clientID(ctx, req.SessionID), apiKey(ctx))
+	candidates, err := req.plan("/ai/stream", "")
+	if err != nil {
+		writePlanError(ctx, err)
+		return
+	}
+
+	t, err := prepareTurn(&req, in)
+	switch {
+	case errors.Is(err, dedupe.ErrTooManyRegenerations):
+		writeError(ctx, fasthttp.StatusTooManyRequests, err)
+		return
+	case err != nil:
+		writeError(ctx, fasthttp.StatusUnprocessableEntity, err)
+		return
+	}
+	cached := t.cached
+	if cached != nil {
+		ctx.Response.Header.Set("X-Lingobot-Cache", "regenerate")
+	}
+
+	ctx.SetContentType("text/event-stream")
+	ctx.Response.Header.Set("Cache-Control", "no-cache")
+	ctx.Response.Header.Set("X-Accel-Buffering", "no")
+
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		// o corpo é escrito depois que o handler retorna
+		load.inFlight.Add(1)
+		defer load.inFlight.Add(-1)
+
+		if cached != nil {
+			timer.startProvider()
+			timer.endProvider()
+			f := finishFor(&req, in, cached, routing.Candidate{}, nil, timer)
+			f.Cached, f.Stream = true, true
+			hooks.Emit(f)
+
+			writeEvent(w, "", map[string]string{"delta": cached.Text})
+			writeEvent(w, "done", streamSummary{Language: cached.Language, Provider: cached.Provider})
+			return
+		}
+
+		timer.startProvider()
+		result, candidate, err := routing.ExecuteStream(in, candidates, func(chunk string) error {
+			if err := t.pipeline.chunk(t, chunk); err != nil {
+				return err
+			}
+			return writeEvent(w, "", map[string]string{"delta": chunk})
+		})
+		timer.endProvider()
+		if err == nil {
+			t.candidate = candidate
+			t.pipeline.done(t, result)
+		}
+
+		f := finishFor(&req, in, result, candidate, err, timer)
+		f.Stream = true
+		hooks.Emit(f)
+
+		if err != nil {
+			if errors.Is(err, routing.ErrOverloaded) {
+				countShed()
+			}
+			writeEvent(w, "error", errorEnvelope{Error: describeError(err, upstreamStatus(err))})
+			return
+		}
+
+		if req.ConversationID != "" {
+			conversation.Record(req.ConversationID, req.Text, result)
+		}
+
+		summary := streamSummary{
+			Language:      result.Language,
+			Experiment:    candidate.Tag(),
+			Provider:      result.Provider,
+			LatencyMs:     candidate.Latency.Milliseconds(),
+			FallbackDepth: candidate.Depth,
+		}
+		if result.Usage.TotalTokens > 0 {
+			summary.Usage = &result.Usage
+		}
+		writeEvent(w, "done", summary)
+	})
+}
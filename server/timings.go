@@ -0,0 +1,69 @@
+package server
+
+import (
+	"os"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+)
+
+// DEBUG_TIMINGS=1 libera o campo "timings" para requisições com "debug": true
+var timingsEnabled = os.Getenv("DEBUG_TIMINGS") == "1" || os.Getenv("DEBUG_TIMINGS") == "true"
+
+// Tempo gasto em cada etapa de um turno, em milissegundos
+type Timings struct {
+	QueueMs          float64 `json:"queue_ms"`
+	ProviderMs       float64 `json:"provider_ms"`
+	PostProcessingMs float64 `json:"post_processing_ms"`
+	SerializationMs  float64 `json:"serialization_ms"`
+	TotalMs          float64 `json:"total_ms"`
+}
+
+// Marcações de um turno, da chegada da requisição até a serialização
+type turnTimer struct {
+	received      time.Time
+	providerStart time.Time
+	providerEnd   time.Time
+	postEnd       time.Time
+}
+
+func newTurnTimer(ctx *fasthttp.RequestCtx) *turnTimer {
+	received := ctx.Time()
+	if received.IsZero() {
+		// contexto montado pela ponte HTTP/2, criado com o pedido
+		received = ctx.ConnTime()
+	}
+	return &turnTimer{received: received}
+}
+
+func (t *turnTimer) startProvider() { t.providerStart = time.Now() }
+func (t *turnTimer) endProvider()   { t.providerEnd = time.Now() }
+func (t *turnTimer) endPost()       { t.postEnd = time.Now() }
+
+func ms(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// writeAIResponse serializa a resposta, anexando timings quando pedidos
+func writeAIResponse(ctx *fasthttp.RequestCtx, out aiResponse, timer *turnTimer, debug bool) {
+	timer.endPost()
+
+	serializeStart := time.Now()
+	result, _ := sonic.Marshal(out)
+
+	if debug && timingsEnabled {
+		serialization := time.Since(serializeStart)
+		out.Timings = &Timings{
+			QueueMs:          ms(timer.providerStart.Sub(timer.received)),
+			ProviderMs:       ms(timer.providerEnd.Sub(timer.providerStart)),
+			PostProcessingMs: ms(timer.postEnd.Sub(timer.providerEnd)),
+			SerializationMs:  ms(serialization),
+			TotalMs:          ms(time.Since(timer.received)),
+		}
+		result, _ = sonic.Marshal(out)
+	}
+
+	ctx.SetContentType("application/json")
+	ctx.SetBody(result)
+}
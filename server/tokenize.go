@@ -0,0 +1,95 @@
+package server
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/conversation"
+	"lingobot-ai-engine/provider"
+	"lingobot-ai-engine/routing"
+	"lingobot-ai-engine/vocabulary"
+)
+
+// Pedidos maiores que isso não cabem em nenhum modelo servido
+const maxTokenizeRunes = 4 << 20
+
+type tokenizeResponse struct {
+	Characters int                  `json:"characters"`
+	Counts     []routing.TokenCount `json:"counts"`
+}
+
+// tokenizeHandler estima os tokens do pedido em cada provedor e modelo, com a
+// mesma conta que corta o histórico antes de chamar o provedor (POST /tokenize)
+func tokenizeHandler(ctx *fasthttp.RequestCtx) {
+	if !ctx.IsPost() {
+		writeErrorCode(ctx, fasthttp.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req struct {
+		Text           string             `json:"text"`
+		History        []provider.Message `json:"history"`
+		ConversationID string             `json:"conversation_id"`
+		Level          string             `json:"level"`
+		Provider       string             `json:"provider"`
+		Model          string             `json:"model"`
+		MaxTokens      int                `json:"max_tokens"`
+	}
+	if err := sonic.Unmarshal(ctx.PostBody(), &req); err != nil {
+		writeErrorCode(ctx, fasthttp.StatusBadRequest, codeInvalidJSON, "invalid JSON")
+		return
+	}
+
+	if req.Text == "" {
+		writeError(ctx, fasthttp.StatusBadRequest, errTextRequired)
+		return
+	}
+	if req.Provider != "" {
+		if _, ok := provider.ByName(req.Provider); !ok {
+			writeErrorCode(ctx, fasthttp.StatusBadRequest, codeInvalidRequest, fmt.Sprintf("unknown provider %q", req.Provider))
+			return
+		}
+	}
+	if req.MaxTokens < 0 {
+		writeErrorCode(ctx, fasthttp.StatusBadRequest, codeInvalidRequest, "max_tokens must not be negative")
+		return
+	}
+	if req.Level != "" {
+		level, ok := vocabulary.ParseLevel(req.Level)
+		if !ok {
+			writeError(ctx, fasthttp.StatusBadRequest, &invalidOptionError{errInvalidLevel})
+			return
+		}
+		req.Level = level
+	}
+
+	history := req.History
+	if len(history) == 0 && req.ConversationID != "" {
+		history = conversation.History(req.ConversationID)
+	}
+	in := &provider.Request{
+		Text:      req.Text,
+		System:    levelSystem(req.Level),
+		History:   history,
+		MaxTokens: req.MaxTokens,
+	}
+
+	characters := utf8.RuneCountInString(in.System) + utf8.RuneCountInString(in.Text)
+	for _, m := range history {
+		characters += utf8.RuneCountInString(m.Content)
+	}
+	if characters > maxTokenizeRunes {
+		writeErrorCode(ctx, fasthttp.StatusRequestEntityTooLarge, codeInvalidRequest, "prompt too large to count")
+		return
+	}
+
+	body, _ := sonic.Marshal(tokenizeResponse{
+		Characters: characters,
+		Counts:     routing.Tokenize(in, req.Provider, req.Model),
+	})
+	ctx.SetContentType("application/json")
+	ctx.SetBody(body)
+}
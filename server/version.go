@@ -0,0 +1,80 @@
+package server
+
+import (
+	"os"
+	"sort"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/alerts"
+	"lingobot-ai-engine/db"
+	"lingobot-ai-engine/instance"
+	"lingobot-ai-engine/moderation"
+	"lingobot-ai-engine/provider"
+	"lingobot-ai-engine/version"
+)
+
+type versionResponse struct {
+	version.Info
+	Features      []string          `json:"features"`
+	Providers     map[string]string `json:"providers"`
+	Transcription string            `json:"transcription"`
+	Blocklist     string            `json:"blocklist,omitempty"`
+}
+
+// versionHandler diz qual build está no ar e com o quê: versão, commit, hora
+// do build, recursos ligados por variável de ambiente e a API de cada
+// provedor (GET /version)
+func versionHandler(ctx *fasthttp.RequestCtx) {
+	if !ctx.IsGet() {
+		writeErrorCode(ctx, fasthttp.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	out := versionResponse{
+		Info:          version.Get(),
+		Features:      features(),
+		Providers:     map[string]string{},
+		Transcription: provider.TranscriptionModel(),
+		Blocklist:     moderation.Version(),
+	}
+	for _, p := range provider.All() {
+		out.Providers[p.Name] = p.APIVersion()
+	}
+
+	body, _ := sonic.Marshal(out)
+	ctx.SetContentType("application/json")
+	ctx.SetBody(body)
+}
+
+// features lista, em ordem alfabética, os recursos opcionais ligados nesta instância
+func features() []string {
+	flags := map[string]bool{
+		"alerts":             alerts.Enabled(),
+		"audit_log":          os.Getenv("AUDIT_LOG") == "1" || os.Getenv("AUDIT_LOG") == "true",
+		"azure_only":         os.Getenv("AZURE_ONLY") == "1" || os.Getenv("AZURE_ONLY") == "true",
+		"custom_providers":   len(provider.Custom()) > 0,
+		"database":           db.Default() != nil,
+		"experiments":        os.Getenv("EXPERIMENTS") != "",
+		"grpc":               os.Getenv("GRPC_PORT") != "",
+		"http2":              os.Getenv("HTTP2") != "",
+		"instance_registry":  instance.Registered(),
+		"keep_alive":         keepAliveTarget != "",
+		"local_llm":          os.Getenv("LOCAL_LLM_URL") != "",
+		"mock_mode":          provider.MockMode(),
+		"persona_prompts":    len(personaPrompts) > 0,
+		"prompt_compression": os.Getenv("PROMPT_COMPRESSION") != "",
+		"redis":              os.Getenv("REDIS_URL") != "",
+		"route_filter":       len(enabledRoutes) > 0 || len(disabledRoutes) > 0,
+		"shadow_traffic":     os.Getenv("SHADOW_PROVIDER") != "",
+	}
+	enabled := []string{}
+	for name, on := range flags {
+		if on {
+			enabled = append(enabled, name)
+		}
+	}
+	sort.Strings(enabled)
+	return enabled
+}
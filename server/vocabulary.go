@@ -0,0 +1,173 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"lingobot-ai-engine/hooks"
+	"lingobot-ai-engine/prompt"
+	"lingobot-ai-engine/provider"
+	"lingobot-ai-engine/routing"
+	"lingobot-ai-engine/vocabulary"
+)
+
+var errInvalidLevel = errors.New("level must be one of " + strings.Join(vocabulary.Levels, ", "))
+
+// Pedido de reescrita quando a resposta usa palavras acima do nível do aluno
+const simplifyTemplate = "Sua última resposta está difícil demais para um aluno de nível {{level}} do CEFR. " +
+	"Reescreva a mesma resposta com palavras simples e frequentes e frases curtas, no mesmo idioma, " +
+	"sem mudar o conteúdo. Responda só com o texto reescrito. " +
+	"Troque principalmente estas palavras:\n{{words}}"
+
+// steerVocabulary confere o vocabulário da resposta contra o nível pedido e,
+// se estiver difícil demais, pede uma reescrita ao mesmo candidato. Fica com a
+// versão mais simples das duas; a falha da reescrita não derruba o turno. O
+// tempo da reescrita entra na latência do candidato.
+func steerVocabulary(req *chatRequest, in *provider.Request, result *provider.Result, candidate *routing.Candidate) *provider.Result {
+	if req.Level == "" || req.levelOnly {
+		return result
+	}
+	lang := result.Language
+	if expected := req.replyLanguage(); expected != "" && lang != expected {
+		return result
+	}
+	report, ok := vocabulary.Check(result.Text, lang, req.Level)
+	if !ok {
+		return result
+	}
+	req.vocabulary = &hooks.Vocabulary{First: report, Final: report}
+	if !report.TooHard {
+		return result
+	}
+
+	text, err := prompt.Render(simplifyTemplate, prompt.Vars{
+		"level": prompt.OneOf(report.Level, vocabulary.Levels...),
+		"words": prompt.Text(strings.Join(report.RareWords, ", "), 300),
+	})
+	if err != nil {
+		return result
+	}
+
+	retry := *in
+	retry.Text = text
+	retry.History = append(slices.Clone(in.History),
+		provider.Message{Role: "user", Content: in.Text},
+		provider.Message{Role: "assistant", Content: result.Text},
+	)
+	req.vocabulary.Retried = true
+
+	start := time.Now()
+	simpler, _, err := routing.Execute(&retry, []routing.Candidate{*candidate})
+	candidate.Latency += time.Since(start)
+	if err != nil {
+		log.Printf("⚠️  Reescrita para %s falhou: %v", report.Level, err)
+		return result
+	}
+	usage := result.Usage
+	usage.PromptTokens += simpler.Usage.PromptTokens
+	usage.CompletionTokens += simpler.Usage.CompletionTokens
+	usage.TotalTokens += simpler.Usage.TotalTokens
+
+	// reescrita em outro idioma não serve, mesmo que mais simples
+	second, ok := vocabulary.Check(simpler.Text, lang, report.Level)
+	if !ok || simpler.Language != lang || second.Ratio >= report.Ratio {
+		out := *result
+		out.Usage = usage
+		return &out
+	}
+	req.vocabulary.Final = second
+	simpler.Usage = usage
+	return simpler
+}
+
+// Contadores por nível, expostos em GET /languages/metrics
+type levelStats struct {
+	checked   int
+	tooHard   int // primeira resposta acima do nível
+	retried   int
+	fixed     int // a reescrita ficou dentro do nível
+	stillHard int // a resposta entregue continuou acima do nível
+	rareFirst float64
+	rareFinal float64
+}
+
+type levelReport struct {
+	Level         string  `json:"level"`
+	Checked       int     `json:"checked"`
+	TooHard       int     `json:"too_hard"`
+	Retried       int     `json:"retried"`
+	Fixed         int     `json:"fixed"`
+	StillHard     int     `json:"still_hard"`
+	AvgRareBefore float64 `json:"avg_rare_ratio_before"`
+	AvgRareAfter  float64 `json:"avg_rare_ratio_after"`
+}
+
+type levelMetrics struct {
+	mu    sync.Mutex
+	stats map[string]*levelStats
+}
+
+var lvlMetrics = &levelMetrics{stats: map[string]*levelStats{}}
+
+func init() {
+	hooks.Register(lvlMetrics)
+}
+
+func (m *levelMetrics) Name() string { return "level-metrics" }
+
+func (m *levelMetrics) OnFinish(f *hooks.Finish) {
+	v := f.Vocabulary
+	if f.Cached || f.Err != nil || v == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.stats[v.First.Level]
+	if !ok {
+		s = &levelStats{}
+		m.stats[v.First.Level] = s
+	}
+	s.checked++
+	s.rareFirst += v.First.Ratio
+	s.rareFinal += v.Final.Ratio
+	if v.First.TooHard {
+		s.tooHard++
+	}
+	if v.Retried {
+		s.retried++
+		if !v.Final.TooHard {
+			s.fixed++
+		}
+	}
+	if v.Final.TooHard {
+		s.stillHard++
+	}
+}
+
+func (m *levelMetrics) reports() []levelReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]levelReport, 0, len(m.stats))
+	for level, s := range m.stats {
+		out = append(out, levelReport{
+			Level:         level,
+			Checked:       s.checked,
+			TooHard:       s.tooHard,
+			Retried:       s.retried,
+			Fixed:         s.fixed,
+			StillHard:     s.stillHard,
+			AvgRareBefore: s.rareFirst / float64(s.checked),
+			AvgRareAfter:  s.rareFinal / float64(s.checked),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Level < out[j].Level })
+	return out
+}
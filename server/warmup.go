@@ -0,0 +1,109 @@
+package server
+
+import (
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+	"github.com/valyala/fasthttp"
+
+	"lingobot-ai-engine/internal/env"
+	"lingobot-ai-engine/provider"
+)
+
+type warmupResponse struct {
+	Providers []provider.WarmResult `json:"providers"`
+	Encoder   bool                  `json:"encoder_primed"`
+	TookMs    int64                 `json:"took_ms"`
+}
+
+// warmupHandler abre as conexões com os provedores e compila o sonic para os
+// tipos do servidor, para o primeiro pedido real não pagar o cold start
+// (GET /warmup)
+func warmupHandler(ctx *fasthttp.RequestCtx) {
+	if !ctx.IsGet() {
+		writeErrorCode(ctx, fasthttp.StatusMethodNotAllowed, codeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	start := time.Now()
+	out := warmupResponse{Providers: warmup(), Encoder: true}
+	out.TookMs = time.Since(start).Milliseconds()
+
+	body, _ := sonic.Marshal(out)
+	ctx.SetContentType("application/json")
+	ctx.SetBody(body)
+}
+
+var primeOnce sync.Once
+
+// warmup aquece os provedores e, uma vez por processo, o encoder
+func warmup() []provider.WarmResult {
+	primeOnce.Do(func() {
+		provider.Pretouch(
+			chatRequest{}, aiResponse{}, errorEnvelope{}, streamSummary{},
+			statusResponse{}, tokenizeResponse{}, exercisesResponse{},
+			conjugateResponse{}, defineResponse{}, flashcardsResponse{},
+			pronunciationResponse{}, documentResponse{}, documentAnswer{},
+		)
+	})
+	return provider.Warmup()
+}
+
+// Keep-alive para hospedagem gratuita (Render free tier dorme após 15 min
+// sem tráfego e o primeiro pedido leva segundos):
+//
+//	KEEPALIVE=1                pinga RENDER_EXTERNAL_URL/health
+//	KEEPALIVE_URL=https://...  URL pingada; liga o keep-alive sozinha
+//	KEEPALIVE_INTERVAL=10m     intervalo entre pings
+//	WARMUP_ON_START=1          aquece provedores e encoder ao subir
+//
+// O ping passa pelo balanceador, que é o que conta como tráfego para o Render;
+// a cada ping as conexões com os provedores também são reaquecidas, porque o
+// pool descarta as ociosas depois de 90s.
+var (
+	keepAliveInterval = env.PositiveDuration("KEEPALIVE_INTERVAL", 10*time.Minute)
+	keepAliveTarget   = keepAliveURL()
+)
+
+// KeepAlive roda o aquecimento inicial e o ping periódico, quando configurados
+func KeepAlive() {
+	if os.Getenv("WARMUP_ON_START") == "1" || os.Getenv("WARMUP_ON_START") == "true" {
+		start := time.Now()
+		results := warmup()
+		log.Printf("🔥 Aquecimento inicial: %d provedores em %s", len(results), time.Since(start).Round(time.Millisecond))
+	}
+
+	target := keepAliveTarget
+	if target == "" {
+		return
+	}
+
+	log.Printf("⏰ Keep-alive a cada %s em %s", keepAliveInterval, target)
+	ping := &fasthttp.Client{ReadTimeout: 30 * time.Second, WriteTimeout: 30 * time.Second}
+	for range time.Tick(keepAliveInterval) {
+		status, _, err := ping.GetTimeout(nil, target, 30*time.Second)
+		if err != nil || status >= fasthttp.StatusInternalServerError {
+			log.Printf("⚠️  Keep-alive em %s falhou: status %d, %v", target, status, err)
+		}
+		warmup()
+	}
+}
+
+func keepAliveURL() string {
+	if raw := os.Getenv("KEEPALIVE_URL"); raw != "" {
+		return raw
+	}
+	if os.Getenv("KEEPALIVE") != "1" && os.Getenv("KEEPALIVE") != "true" {
+		return ""
+	}
+	base := strings.TrimRight(os.Getenv("RENDER_EXTERNAL_URL"), "/")
+	if base == "" {
+		log.Printf("⚠️  KEEPALIVE ligado sem RENDER_EXTERNAL_URL nem KEEPALIVE_URL, ignorado")
+		return ""
+	}
+	return base + "/health"
+}
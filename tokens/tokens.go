@@ -0,0 +1,104 @@
+// Package tokens estima quantos tokens um texto ocupa em cada família de
+// tokenizador, sem baixar os vocabulários: conta palavras, números, ideogramas
+// e pontuação com a média de caracteres por token de cada família.
+package tokens
+
+import (
+	"math"
+	"unicode"
+)
+
+// Famílias de tokenizador dos modelos servidos
+const (
+	Gemini   = "gemini"   // SentencePiece do Gemini
+	Llama3   = "llama3"   // tiktoken de 128k (Llama 3 e 4)
+	Mistral  = "mistral"  // SentencePiece/Tekken da Mistral
+	Cohere   = "cohere"   // BPE da Cohere
+	DeepSeek = "deepseek" // BPE do DeepSeek V3/R1
+	OpenAI   = "openai"   // o200k (GPT-4o)
+	Generic  = "generic"  // desconhecido: ~4 caracteres por token
+)
+
+// Tokens por mensagem no formato de chat (marcadores de papel e separadores)
+const MessageOverhead = 4
+
+type family struct {
+	latin      float64 // caracteres por token em palavras de escrita latina
+	other      float64 // idem em outras escritas alfabéticas (cirílico, árabe...)
+	cjk        float64 // tokens por ideograma, kana ou hangul
+	digitGroup int     // dígitos por token; 1 quando o tokenizador separa cada dígito
+}
+
+var families = map[string]family{
+	Gemini:   {latin: 4.0, other: 2.5, cjk: 0.8, digitGroup: 1},
+	Llama3:   {latin: 4.2, other: 2.2, cjk: 1.1, digitGroup: 3},
+	Mistral:  {latin: 3.6, other: 1.8, cjk: 1.3, digitGroup: 1},
+	Cohere:   {latin: 4.0, other: 2.3, cjk: 1.0, digitGroup: 1},
+	DeepSeek: {latin: 3.8, other: 2.0, cjk: 0.7, digitGroup: 3},
+	OpenAI:   {latin: 4.3, other: 2.6, cjk: 0.9, digitGroup: 3},
+	Generic:  {latin: 4.0, other: 2.0, cjk: 1.0, digitGroup: 1},
+}
+
+// Known diz se a família existe
+func Known(tokenizer string) bool {
+	_, ok := families[tokenizer]
+	return ok
+}
+
+// Count estima os tokens de text na família pedida; família desconhecida usa Generic
+func Count(tokenizer, text string) int {
+	f, ok := families[tokenizer]
+	if !ok {
+		f = families[Generic]
+	}
+
+	total := 0.0
+	var latin, other, digits int
+	flush := func() {
+		total += math.Ceil(float64(latin) / f.latin)
+		total += math.Ceil(float64(other) / f.other)
+		total += math.Ceil(float64(digits) / float64(f.digitGroup))
+		latin, other, digits = 0, 0, 0
+	}
+
+	newline := false
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flush()
+			total += f.cjk
+		case unicode.IsLetter(r) || unicode.IsMark(r) || r == '\'':
+			if digits > 0 {
+				flush()
+			}
+			if r < 0x250 || unicode.IsMark(r) {
+				latin++
+			} else {
+				other++
+			}
+		case unicode.IsDigit(r):
+			if latin > 0 || other > 0 {
+				flush()
+			}
+			digits++
+		case r == '\n':
+			flush()
+			// quebras seguidas costumam virar um token só
+			if !newline {
+				total++
+			}
+		case unicode.IsSpace(r):
+			flush() // o espaço vai junto da palavra seguinte
+		default:
+			flush()
+			if r > 0xFFFF {
+				total += 2 // emoji e símbolos fora do plano básico
+			} else {
+				total++
+			}
+		}
+		newline = r == '\n'
+	}
+	flush()
+	return int(math.Ceil(total))
+}
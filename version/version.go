@@ -0,0 +1,88 @@
+// Package version identifica o build em execução. Os valores entram pelo
+// -ldflags na hora do build:
+//
+//	go build -ldflags "-X lingobot-ai-engine/version.Version=1.8.0 \
+//	  -X lingobot-ai-engine/version.Commit=$(git rev-parse HEAD) \
+//	  -X lingobot-ai-engine/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Sem eles, o commit e a hora vêm das informações de VCS que o go build grava
+// no binário (vcs.revision, vcs.time) e a versão fica "dev".
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	Version   = "dev"
+	Commit    string
+	BuildTime string
+)
+
+// Dependências cuja versão interessa num incidente (JSON, HTTP, gRPC, banco)
+var tracked = []string{
+	"github.com/bytedance/sonic",
+	"github.com/valyala/fasthttp",
+	"google.golang.org/grpc",
+	"google.golang.org/protobuf",
+	"github.com/jackc/pgx/v5",
+	"modernc.org/sqlite",
+}
+
+// Info é o build em execução
+type Info struct {
+	Version      string            `json:"version"`
+	Commit       string            `json:"commit"`
+	BuildTime    string            `json:"build_time,omitempty"`
+	Modified     bool              `json:"modified,omitempty"` // build com alterações fora do commit
+	GoVersion    string            `json:"go_version"`
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+}
+
+// Get junta o que veio do -ldflags com as informações de build do binário
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, BuildTime: BuildTime, GoVersion: runtime.Version()}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, s := range build.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = s.Value
+			}
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = s.Value
+			}
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+
+	for _, dep := range build.Deps {
+		for _, path := range tracked {
+			if dep.Path != path {
+				continue
+			}
+			if info.Dependencies == nil {
+				info.Dependencies = map[string]string{}
+			}
+			v := dep.Version
+			if dep.Replace != nil {
+				v = dep.Replace.Version
+				if v == "" {
+					v = dep.Replace.Path
+				}
+			}
+			info.Dependencies[path] = v
+		}
+	}
+	return info
+}
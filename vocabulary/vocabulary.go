@@ -0,0 +1,203 @@
+// Package vocabulary compara as palavras de uma resposta com listas de
+// frequência por idioma, para apontar vocabulário acima do nível do aluno.
+package vocabulary
+
+import (
+	"bufio"
+	"embed"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Listas embutidas: uma palavra por linha, da mais frequente para a menos
+// frequente. Cobrem só A1 e A2; listas maiores entram por VOCABULARY_DIR.
+//
+//go:embed lists
+var embedded embed.FS
+
+// Levels são os níveis do CEFR aceitos, do mais básico ao mais avançado
+var Levels = []string{"A1", "A2", "B1", "B2", "C1", "C2"}
+
+// Palavras mais frequentes que o aluno de cada nível deve conhecer. C1 e C2
+// não são conferidos.
+var bands = map[string]int{"A1": 800, "A2": 1200, "B1": 2500, "B2": 5000}
+
+// Resposta com ao menos minRare palavras raras e proporção acima de maxRare é
+// difícil demais para o nível
+const minRare = 3
+
+var (
+	// VOCABULARY_MAX_RARE: proporção de palavras fora da faixa tolerada por resposta
+	maxRare = loadRatio("VOCABULARY_MAX_RARE", 0.08)
+
+	// VOCABULARY_DIR: diretório com <idioma>.txt no lugar das listas embutidas,
+	// no formato "palavra" ou "palavra contagem" por linha (FrequencyWords)
+	dir = os.Getenv("VOCABULARY_DIR")
+
+	mu    sync.Mutex
+	ranks = map[string]map[string]int{} // idioma → palavra → posição (1 = mais frequente)
+)
+
+func loadRatio(name string, fallback float64) float64 {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil || f <= 0 || f >= 1 {
+		log.Printf("⚠️  %s inválido, usando %.2f", name, fallback)
+		return fallback
+	}
+	return f
+}
+
+// ParseLevel normaliza "a2" para "A2"; false para nível desconhecido
+func ParseLevel(level string) (string, bool) {
+	level = strings.ToUpper(strings.TrimSpace(level))
+	for _, l := range Levels {
+		if l == level {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// Report é o resultado da conferência de uma resposta
+type Report struct {
+	Level     string   `json:"level"`
+	Language  string   `json:"language"`
+	Words     int      `json:"words"`
+	Rare      int      `json:"rare"`
+	RareWords []string `json:"rare_words,omitempty"` // até 10, sem repetir
+	Ratio     float64  `json:"ratio"`
+	TooHard   bool     `json:"too_hard"`
+}
+
+// Check confere o texto no idioma lang contra a faixa do nível. Devolve false
+// quando não há lista do idioma que cubra o nível.
+func Check(text, lang, level string) (Report, bool) {
+	level, ok := ParseLevel(level)
+	band := bands[level]
+	if !ok || band == 0 {
+		return Report{}, false
+	}
+	rank := list(lang)
+	if len(rank) < band {
+		return Report{}, false
+	}
+
+	r := Report{Level: level, Language: lang}
+	seen := map[string]bool{}
+	for _, w := range words(text) {
+		lower := strings.ToLower(w)
+		r.Words++
+		if known(rank, lower, band) {
+			continue
+		}
+		// nome próprio: maiúscula e fora da lista
+		if unicode.IsUpper([]rune(w)[0]) {
+			r.Words--
+			continue
+		}
+		r.Rare++
+		if !seen[lower] && len(r.RareWords) < 10 {
+			seen[lower] = true
+			r.RareWords = append(r.RareWords, lower)
+		}
+	}
+
+	if r.Words > 0 {
+		r.Ratio = float64(r.Rare) / float64(r.Words)
+	}
+	r.TooHard = r.Rare >= minRare && r.Ratio > maxRare
+	return r, true
+}
+
+// words separa as palavras com letras, mantendo o apóstrofo interno (don't)
+func words(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\'' && r != '’'
+	})
+	out := fields[:0]
+	for _, f := range fields {
+		f = strings.Trim(strings.ReplaceAll(f, "’", "'"), "'")
+		if len([]rune(f)) > 1 {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Sufixos de flexão tentados quando a forma exata não está na lista: as listas
+// trazem as formas mais comuns, mas não todos os plurais e femininos
+var suffixes = []string{"'s", "s", "es", "ed", "d", "ing", "ly", "mente"}
+
+func known(rank map[string]int, w string, band int) bool {
+	in := func(w string) bool {
+		n, ok := rank[w]
+		return ok && n <= band
+	}
+	if in(w) {
+		return true
+	}
+	for _, s := range suffixes {
+		if stem, ok := strings.CutSuffix(w, s); ok && len(stem) > 1 && in(stem) {
+			return true
+		}
+	}
+	// bonita, bonitas → bonito
+	if stem, ok := strings.CutSuffix(strings.TrimSuffix(w, "s"), "a"); ok && in(stem+"o") {
+		return true
+	}
+	return false
+}
+
+// list carrega a lista do idioma uma vez; idioma sem lista fica vazio
+func list(lang string) map[string]int {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if rank, ok := ranks[lang]; ok {
+		return rank
+	}
+	rank := map[string]int{}
+	ranks[lang] = rank
+	if lang == "" || strings.ContainsAny(lang, `/\.`) {
+		return rank
+	}
+
+	var f io.ReadCloser
+	var err error
+	if dir != "" {
+		f, err = os.Open(filepath.Join(dir, lang+".txt"))
+	}
+	if dir == "" || os.IsNotExist(err) {
+		f, err = embedded.Open("lists/" + lang + ".txt")
+	}
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("⚠️  Lista de frequência %s: %v", lang, err)
+		}
+		return rank
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		w := strings.ToLower(fields[0])
+		if _, dup := rank[w]; !dup {
+			rank[w] = len(rank) + 1
+		}
+	}
+	return rank
+}